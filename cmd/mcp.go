@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/navidrome/navidrome/conf"
+	"github.com/navidrome/navidrome/core/agents/mcp"
+	"github.com/navidrome/navidrome/db"
+	"github.com/navidrome/navidrome/log"
+	"github.com/navidrome/navidrome/persistence"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	mcpCmd.AddCommand(mcpDoctorCmd)
+	mcpCmd.AddCommand(mcpPrefetchCmd)
+	rootCmd.AddCommand(mcpCmd)
+}
+
+var mcpCmd = &cobra.Command{
+	Use:   "mcp",
+	Short: "MCP agent utilities",
+	Long:  "Utilities for the MCP metadata agent",
+}
+
+var mcpPrefetchCmd = &cobra.Command{
+	Use:   "prefetch",
+	Short: "Warm the MCP agent's caches for the whole library",
+	Long: "Walk the library's artists warming the MCP agent's caches, ordered by MCP.PrefetchOrder. " +
+		"The walk checkpoints its position, so an interrupted run resumes where it left off",
+	Run: func(cmd *cobra.Command, args []string) {
+		runMCPPrefetch(cmd.Context())
+	},
+}
+
+func runMCPPrefetch(ctx context.Context) {
+	sqlDB := db.Db()
+	defer db.Db().Close()
+	ds := persistence.New(sqlDB)
+	if err := mcp.RunPrefetch(ctx, ds); err != nil {
+		log.Fatal("MCP prefetch failed", err)
+	}
+}
+
+var mcpDoctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Diagnose the MCP agent configuration",
+	Long:  "Print the MCP agent's lifecycle state and, when it is not ready, the reason",
+	Run: func(cmd *cobra.Command, args []string) {
+		runMCPDoctor()
+	},
+}
+
+func runMCPDoctor() {
+	// GetStatus runs the constructor-time checks, so the printed state
+	// reflects the current configuration.
+	_ = mcp.GetStatus()
+	state, reason := mcp.AgentStateInfo()
+	fmt.Printf("state: %s\n", state)
+	if reason != "" {
+		fmt.Printf("reason: %s\n", reason)
+	}
+	fmt.Println("effective config:")
+	effective := conf.MCPEffectiveConfig()
+	keys := make([]string, 0, len(effective))
+	for key := range effective {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		setting := effective[key]
+		fmt.Printf("  %s = %v (%s)\n", key, setting.Value, setting.Source)
+	}
+}
@@ -12,6 +12,7 @@ import (
 	_ "github.com/navidrome/navidrome/adapters/taglib"
 	"github.com/navidrome/navidrome/conf"
 	"github.com/navidrome/navidrome/consts"
+	"github.com/navidrome/navidrome/core/agents/mcp"
 	"github.com/navidrome/navidrome/db"
 	"github.com/navidrome/navidrome/log"
 	"github.com/navidrome/navidrome/model"
@@ -95,6 +96,10 @@ func runNavidrome(ctx context.Context) {
 	if err := g.Wait(); err != nil {
 		log.Error("Fatal error in Navidrome. Aborting", err)
 	}
+
+	// All services are down; stop the MCP server too, instead of leaving its
+	// child process for the OS to clean up.
+	mcp.Shutdown()
 }
 
 // mainContext returns a context that is cancelled when the process receives a signal to exit.
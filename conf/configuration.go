@@ -101,6 +101,7 @@ type configOptions struct {
 	LastFM                          lastfmOptions       `json:",omitzero"`
 	Spotify                         spotifyOptions      `json:",omitzero"`
 	ListenBrainz                    listenBrainzOptions `json:",omitzero"`
+	MCP                             mcpOptions          `json:",omitzero"`
 	Tags                            map[string]TagConf  `json:",omitempty"`
 	Agents                          string
 
@@ -175,6 +176,39 @@ type listenBrainzOptions struct {
 	BaseURL string
 }
 
+type mcpOptions struct {
+	Enabled             bool
+	ServerPath          string
+	ToolServers         map[string]string
+	StartupTimeout      time.Duration
+	InitTimeout         time.Duration
+	InitTimeoutNative   time.Duration
+	InitTimeoutWasm     time.Duration
+	CallTimeout         time.Duration
+	IdleTimeout         time.Duration
+	MaxCallsPerInstance int
+	MaxInstanceAge      time.Duration
+	RecycleOnMemoryMB   int
+	MaxMemoryMB         int
+	MaxCPUSeconds       int
+	MaxRestartAttempts  int
+	RetryQueueSize      int
+	DegradedStreak      int
+	DegradedAge         time.Duration
+	SelfTestMBID        string
+	AnonymizeUsers      bool
+	ValidateURLs        bool
+	StartAtBoot         bool
+	PrefetchOrder       string
+	MaxRequestsPerHour  int
+	SearchURLTemplate   string
+	RequestLog          string
+	CacheMode           string
+	CacheTTL            time.Duration
+	UserAgent           string
+	HealthCheckOnReuse  bool
+}
+
 type secureOptions struct {
 	CustomFrameOptionsValue string
 }
@@ -356,6 +390,66 @@ func logDeprecatedOptions(options ...string) {
 	}
 }
 
+// EffectiveSetting is one resolved configuration option: its final value and
+// the source that supplied it ("default", "config" or "env"). Secret-looking
+// values are masked before they leave this package.
+type EffectiveSetting struct {
+	Value  any    `json:"value"`
+	Source string `json:"source"`
+}
+
+// secretSettingMarkers flags option names whose values must never appear in
+// clear text in an effective-config dump.
+var secretSettingMarkers = []string{"secret", "password", "token", "apikey"}
+
+// MCPEffectiveConfig resolves every mcp.* option to its effective value and
+// where that value came from, for the agent status report and `mcp doctor`.
+func MCPEffectiveConfig() map[string]EffectiveSetting {
+	effective := map[string]EffectiveSetting{}
+	for _, key := range viper.AllKeys() {
+		name, ok := strings.CutPrefix(key, "mcp.")
+		if !ok {
+			continue
+		}
+		effective[name] = EffectiveSetting{
+			Value:  maskSecretSetting(name, viper.Get(key)),
+			Source: settingSource(key),
+		}
+	}
+	return effective
+}
+
+// settingSource reports where an option's effective value came from. An
+// environment variable wins over the config file, mirroring viper's own
+// precedence.
+func settingSource(key string) string {
+	envVar := "ND_" + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+	switch {
+	case os.Getenv(envVar) != "":
+		return "env"
+	case viper.InConfig(key):
+		return "config"
+	default:
+		return "default"
+	}
+}
+
+// maskSecretSetting replaces non-empty values of secret-looking options with a
+// fixed placeholder.
+func maskSecretSetting(name string, value any) any {
+	lower := strings.ToLower(name)
+	for _, marker := range secretSettingMarkers {
+		if !strings.Contains(lower, marker) {
+			continue
+		}
+		if s, ok := value.(string); ok && s == "" {
+			return s
+		}
+		return "****"
+	}
+	return value
+}
+
 // parseIniFileConfiguration is used to parse the config file when it is in INI format. For INI files, it
 // would require a nested structure, so instead we unmarshal it to a map and then merge the nested [default]
 // section into the root level.
@@ -387,6 +481,7 @@ func disableExternalServices() {
 	Server.LastFM.Enabled = false
 	Server.Spotify.ID = ""
 	Server.ListenBrainz.Enabled = false
+	Server.MCP.Enabled = false
 	Server.Agents = ""
 	if Server.UILoginBackgroundURL == consts.DefaultUILoginBackgroundURL {
 		Server.UILoginBackgroundURL = consts.DefaultUILoginBackgroundURLOffline
@@ -555,6 +650,50 @@ func setViperDefaults() {
 	viper.SetDefault("spotify.secret", "")
 	viper.SetDefault("listenbrainz.enabled", true)
 	viper.SetDefault("listenbrainz.baseurl", "https://api.listenbrainz.org/1/")
+	viper.SetDefault("mcp.enabled", false)
+	viper.SetDefault("mcp.serverpath", "")
+	// Tool name → server path; when set, tool calls are routed per entry and
+	// unmapped tools are not offered at all.
+	viper.SetDefault("mcp.toolservers", map[string]string{})
+	viper.SetDefault("mcp.startuptimeout", 60*time.Second)
+	// Zero picks a default per transport (native vs wasm); setting
+	// mcp.inittimeout applies to both.
+	viper.SetDefault("mcp.inittimeout", time.Duration(0))
+	viper.SetDefault("mcp.inittimeoutnative", time.Duration(0))
+	viper.SetDefault("mcp.inittimeoutwasm", time.Duration(0))
+	// Zero leaves tool calls bounded only by the caller's context.
+	viper.SetDefault("mcp.calltimeout", time.Duration(0))
+	viper.SetDefault("mcp.idletimeout", 30*time.Minute)
+	viper.SetDefault("mcp.maxcallsperinstance", 0)
+	viper.SetDefault("mcp.maxinstanceage", time.Duration(0))
+	viper.SetDefault("mcp.recycleonmemorymb", 0)
+	viper.SetDefault("mcp.maxmemorymb", 0)
+	viper.SetDefault("mcp.maxcpuseconds", 0)
+	viper.SetDefault("mcp.maxrestartattempts", 0)
+	viper.SetDefault("mcp.retryqueuesize", 0)
+	viper.SetDefault("mcp.degradedstreak", 0)
+	viper.SetDefault("mcp.degradedage", time.Duration(0))
+	viper.SetDefault("mcp.selftestmbid", "b10bbbfc-cf9e-42e0-be17-e2c3e1d2600d") // The Beatles
+	viper.SetDefault("mcp.anonymizeusers", false)
+	viper.SetDefault("mcp.validateurls", false)
+	viper.SetDefault("mcp.startatboot", false)
+	viper.SetDefault("mcp.prefetchorder", "smart")
+	viper.SetDefault("mcp.maxrequestsperhour", 0)
+	viper.SetDefault("mcp.searchurltemplate", "")
+	// How outbound URLs appear in logs: "full-url", "hosts-only" (paths and
+	// queries redacted) or "off".
+	viper.SetDefault("mcp.requestlog", "full-url")
+	// Where lookup results are cached: "memory" (bounded LRU, suits ephemeral
+	// containers), "disk" (persisted under the data folder) or "none".
+	viper.SetDefault("mcp.cachemode", "memory")
+	// How long cached lookup results stay valid; zero keeps the default (24h).
+	viper.SetDefault("mcp.cachettl", time.Duration(0))
+	// The User-Agent for outbound lookup requests; empty derives one from the
+	// Navidrome version. Wikimedia's API policy asks for a real contact.
+	viper.SetDefault("mcp.useragent", "")
+	// Whether to verify a running server still answers (a quick tools/list)
+	// before reusing it for a call; off by default to spare the round-trip.
+	viper.SetDefault("mcp.healthcheckonreuse", false)
 	viper.SetDefault("httpsecurityheaders.customframeoptionsvalue", "DENY")
 	viper.SetDefault("backup.path", "")
 	viper.SetDefault("backup.schedule", "")
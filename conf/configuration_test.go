@@ -2,8 +2,10 @@ package conf_test
 
 import (
 	"fmt"
+	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/navidrome/navidrome/conf"
 	. "github.com/onsi/ginkgo/v2"
@@ -49,3 +51,38 @@ var _ = Describe("Configuration", func() {
 		Entry("JSON format", "json"),
 	)
 })
+
+var _ = Describe("MCPEffectiveConfig", func() {
+	BeforeEach(func() {
+		viper.Reset()
+		conf.SetViperDefaults()
+	})
+
+	It("reports built-in defaults as such", func() {
+		effective := conf.MCPEffectiveConfig()
+		Expect(effective["enabled"]).To(Equal(conf.EffectiveSetting{Value: false, Source: "default"}))
+		Expect(effective["startuptimeout"]).To(Equal(conf.EffectiveSetting{Value: 60 * time.Second, Source: "default"}))
+	})
+
+	It("attributes values supplied via environment variables", func() {
+		GinkgoT().Setenv("ND_MCP_SERVERPATH", "/usr/local/bin/mcp-server")
+		Expect(conf.MCPEffectiveConfig()["serverpath"].Source).To(Equal("env"))
+	})
+
+	It("attributes values supplied by the config file", func() {
+		cfgFile := filepath.Join(GinkgoT().TempDir(), "navidrome.toml")
+		Expect(os.WriteFile(cfgFile, []byte("[MCP]\nServerPath = '/opt/mcp-server'\n"), 0o600)).To(Succeed())
+		conf.InitConfig(cfgFile)
+
+		effective := conf.MCPEffectiveConfig()
+		Expect(effective["serverpath"]).To(Equal(conf.EffectiveSetting{Value: "/opt/mcp-server", Source: "config"}))
+		// Options the file does not mention keep their default attribution.
+		Expect(effective["enabled"].Source).To(Equal("default"))
+	})
+
+	It("masks secret-looking values, leaving empty ones alone", func() {
+		Expect(conf.MaskSecretSetting("apitoken", "hunter2")).To(Equal("****"))
+		Expect(conf.MaskSecretSetting("proxypassword", "")).To(Equal(""))
+		Expect(conf.MaskSecretSetting("serverpath", "/opt/mcp-server")).To(Equal("/opt/mcp-server"))
+	})
+})
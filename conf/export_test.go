@@ -5,3 +5,5 @@ func ResetConf() {
 }
 
 var SetViperDefaults = setViperDefaults
+
+var MaskSecretSetting = maskSecretSetting
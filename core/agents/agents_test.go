@@ -236,6 +236,17 @@ var _ = Describe("Agents", func() {
 				Expect(err).To(MatchError(ErrNotFound))
 				Expect(mock.Args).To(BeEmpty())
 			})
+			It("passes MBID-only results from an MCP-style agent through intact", func() {
+				external := []Artist{
+					{Name: "First External", MBID: "mbid-ext-1"},
+					{Name: "Second External", MBID: "mbid-ext-2"},
+				}
+				Register("mcpMock", func(model.DataStore) Interface { return &mockSimilarOnlyAgent{similar: external} })
+				conf.Server.Agents = "mcpMock"
+				ag = createAgents(ds, nil)
+
+				Expect(ag.GetSimilarArtists(ctx, "123", "test", "mb123", 5)).To(Equal(external))
+			})
 		})
 
 		Describe("GetArtistTopSongs", func() {
@@ -386,3 +397,15 @@ func (t *testImageAgent) GetArtistImages(_ context.Context, id, name, mbid strin
 	t.Args = []interface{}{id, name, mbid}
 	return t.Images, t.Err
 }
+
+// mockSimilarOnlyAgent mimics the MCP agent: it only knows similar artists,
+// identified by MBID, with no images or local library presence.
+type mockSimilarOnlyAgent struct {
+	similar []Artist
+}
+
+func (a *mockSimilarOnlyAgent) AgentName() string { return "mcpMock" }
+
+func (a *mockSimilarOnlyAgent) GetSimilarArtists(context.Context, string, string, string, int) ([]Artist, error) {
+	return a.similar, nil
+}
@@ -0,0 +1,925 @@
+package mcp
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/navidrome/navidrome/conf"
+	"github.com/navidrome/navidrome/core/agents"
+	"github.com/navidrome/navidrome/log"
+	"github.com/navidrome/navidrome/model"
+	"github.com/navidrome/navidrome/utils/singleton"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const mcpAgentName = "mcp"
+
+const (
+	toolGetArtistBiography = "get_artist_biography"
+	toolGetArtistURL       = "get_artist_url"
+	toolGetArtistThumbnail = "get_artist_thumbnail"
+	toolGetArtistSortName  = "get_artist_sortname"
+	toolGetSimilarArtists  = "get_similar_artists"
+	toolGetArtistImages    = "get_artist_images"
+	toolGetArtistTopSongs  = "get_artist_top_songs"
+	toolGetAlbumInfo       = "get_album_info"
+	toolGetArtistMBID      = "get_artist_mbid"
+)
+
+// mcpAgent delegates metadata lookups to an external MCP server, configured
+// via MCP.ServerPath. The server is started lazily, on the first call.
+type mcpAgent struct {
+	client      *client
+	health      *toolHealth
+	pending     *retryQueue
+	replayDelay time.Duration
+	overrides   artistOverrides
+	quota       *requestQuota
+	provenance  *provenanceLog
+	// plugin is the configured server's file name, for provenance reporting;
+	// pluginHash keys the persisted tool-list cache to the exact binary.
+	plugin     string
+	pluginHash string
+	// routes maps tool names to their clients when MCP.ToolServers is
+	// configured; nil routes everything through the primary client.
+	routes map[string]*client
+	// cache is the lookup cache selected by MCP.CacheMode, shared by every
+	// transport; it also holds the agent's own tool results (structured
+	// biographies, URLs), so "disk" mode keeps them across restarts.
+	// nil disables caching.
+	cache Cache
+
+	mu           sync.Mutex
+	attributions map[string]Attribution
+	selfTest     *SelfTestResult
+}
+
+// Attribution is the upstream license info the server attaches to each tool
+// response, kept per tool for the status endpoint.
+type Attribution struct {
+	Source      string    `json:"source"`
+	License     string    `json:"license"`
+	URL         string    `json:"url,omitempty"`
+	RetrievedAt time.Time `json:"retrievedAt,omitzero"`
+}
+
+func mcpConstructor(ds model.DataStore) *mcpAgent {
+	state, reason := computeAgentState()
+	switch state {
+	case StateDisabled:
+		// Off on purpose - not an error worth shouting about on every startup.
+		setAgentState(state, reason)
+		log.Info("MCP agent not active", "state", state, "reason", reason)
+		return nil
+	case StateNotConfigured:
+		// Enabled but no server path yet; routine during initial setup, so
+		// keep it out of the default log.
+		setAgentState(state, reason)
+		log.Debug("MCP agent not active", "state", state, "reason", reason)
+		return nil
+	case StateConfiguredButMissing:
+		setAgentState(state, reason)
+		log.Error("MCP agent disabled due to invalid configuration", "state", state, "reason", reason)
+		return nil
+	}
+	return singleton.GetInstance(func() *mcpAgent {
+		checkDataFolderWritable()
+		serverPath := conf.Server.MCP.ServerPath
+		cache := newCacheFromConfig()
+		a := &mcpAgent{
+			client:      newClientForPath(serverPath, cache),
+			cache:       cache,
+			health:      newToolHealth(),
+			pending:     newRetryQueue(cmp.Or(conf.Server.MCP.RetryQueueSize, defaultRetryQueueSize)),
+			replayDelay: replayDelay,
+			overrides:   loadArtistOverrides(),
+			quota:       newRequestQuota(conf.Server.MCP.MaxRequestsPerHour),
+			provenance:  newProvenanceLog(),
+			plugin:      filepath.Base(serverPath),
+		}
+		if toolServers := conf.Server.MCP.ToolServers; len(toolServers) > 0 {
+			a.routes = buildToolRoutes(toolServers, serverPath, a.client, cache)
+		}
+		if hash, err := pluginFileHash(serverPath); err == nil {
+			a.pluginHash = hash
+			if tools := loadCachedToolList(hash); tools != nil {
+				// Same binary, same catalog: capability gating and the status
+				// endpoint work before the first initialize completes.
+				a.client.setKnownTools(tools)
+				log.Debug("Reusing cached MCP tool list", "plugin", a.plugin, "tools", len(tools))
+			}
+		}
+		a.client.onReady = a.onServerReady
+		setAgentState(StateReady, "")
+		if conf.Server.MCP.StartAtBoot {
+			go a.warmUpAtBoot(context.Background())
+		}
+		return a
+	})
+}
+
+// newClientForPath builds a client for one server path, resolving its
+// manifest-declared restart policy the same way for every server. All clients
+// share the agent's cache.
+func newClientForPath(path string, cache Cache) *client {
+	manifest, err := loadServerManifest(path)
+	if err != nil {
+		log.Error("Could not load MCP server manifest, ignoring it", "path", path, err)
+		manifest = &serverManifest{}
+	}
+	return newClient(newTransport(path, cache), resolveRestartPolicy(manifest, globalRestartPolicy()))
+}
+
+// buildToolRoutes maps each tool in MCP.ToolServers to a client, creating one
+// client per distinct server path. Every client starts its server lazily, on
+// the first call routed to it; paths equal to the primary server's reuse the
+// primary client.
+func buildToolRoutes(toolServers map[string]string, primaryPath string, primary *client, cache Cache) map[string]*client {
+	byPath := map[string]*client{primaryPath: primary}
+	routes := make(map[string]*client, len(toolServers))
+	for tool, path := range toolServers {
+		c, ok := byPath[path]
+		if !ok {
+			c = newClientForPath(path, cache)
+			byPath[path] = c
+		}
+		routes[tool] = c
+	}
+	return routes
+}
+
+// clientFor resolves the client serving a tool. In single-server mode that is
+// always the primary client; with MCP.ToolServers configured the map is
+// authoritative, and unmapped tools are not offered at all.
+func (a *mcpAgent) clientFor(tool string) (*client, error) {
+	if a.routes == nil {
+		return a.client, nil
+	}
+	if c, ok := a.routes[tool]; ok {
+		return c, nil
+	}
+	return nil, agents.ErrNotFound
+}
+
+// allClients lists the primary client plus every distinct routed one.
+func (a *mcpAgent) allClients() []*client {
+	clients := []*client{a.client}
+	for _, c := range a.routes {
+		if !slices.Contains(clients, c) {
+			clients = append(clients, c)
+		}
+	}
+	return clients
+}
+
+// Close stops every MCP server the agent started and fails in-flight calls.
+// Native servers get a SIGTERM and a grace period to exit before being
+// killed; closing the last wasm transport also releases the shared
+// compilation cache. Called from Navidrome's shutdown path via Shutdown.
+func (a *mcpAgent) Close() error {
+	for _, c := range a.allClients() {
+		c.shutdown()
+	}
+	return nil
+}
+
+// Shutdown closes the active agent's servers on application exit. A no-op
+// when the agent never activated; without it the native child would be left
+// for the OS to kill.
+func Shutdown() {
+	a := mcpConstructor(nil)
+	if a == nil {
+		return
+	}
+	log.Debug("Shutting down MCP agent")
+	_ = a.Close()
+}
+
+// toolListTimeout bounds the post-initialize tools/list refresh; the cached
+// list already covers gating, so a slow answer is not worth waiting for.
+const toolListTimeout = 10 * time.Second
+
+// onServerReady runs in the background after the first successful initialize:
+// it reconciles the tool-list cache and kicks off the self-test.
+func (a *mcpAgent) onServerReady() {
+	a.refreshToolList()
+	a.runSelfTest()
+}
+
+// refreshToolList re-discovers the server's tool catalog and updates the
+// hash-keyed cache, as a consistency check: a byte-identical plugin should
+// never report a different list than the cached one.
+func (a *mcpAgent) refreshToolList() {
+	ctx, cancel := context.WithTimeout(context.Background(), toolListTimeout)
+	defer cancel()
+	tools, err := a.client.listTools(ctx)
+	if err != nil {
+		log.Debug("Could not list MCP tools", "plugin", a.plugin, err)
+		return
+	}
+	if cached := a.client.knownToolList(); len(cached) > 0 && !slices.Equal(cached, tools) {
+		log.Warn("MCP plugin reports a different tool list than the cached one",
+			"plugin", a.plugin, "cached", cached, "reported", tools)
+	}
+	a.client.setKnownTools(tools)
+	if a.pluginHash != "" {
+		saveCachedToolList(a.pluginHash, tools)
+	}
+}
+
+func (a *mcpAgent) AgentName() string {
+	return mcpAgentName
+}
+
+// BiographyResult is the structured form of an artist biography: the text
+// plus the language and provenance the server reports alongside it.
+type BiographyResult struct {
+	Text        string    `json:"text"`
+	Language    string    `json:"language,omitempty"`
+	Source      string    `json:"source,omitempty"`
+	License     string    `json:"license,omitempty"`
+	URL         string    `json:"url,omitempty"`
+	RetrievedAt time.Time `json:"retrievedAt,omitzero"`
+}
+
+// biographyLanguage tags every biography result; the server's content is
+// English-only today, so this is a constant until that changes.
+const biographyLanguage = "en"
+
+// GetArtistBiographyExt returns the biography with its full provenance, for
+// downstream features (attribution, language tagging) that need more than the
+// text. Results are cached in the structured form, so this method and the
+// plain-string wrapper share entries.
+func (a *mcpAgent) GetArtistBiographyExt(ctx context.Context, id, name, mbid string) (*BiographyResult, error) {
+	args := a.artistArgs(id, name, mbid)
+	if cached := a.cachedBiography(toolGetArtistBiography, args); cached != nil {
+		a.recordProvenance(id, "biography", toolGetArtistBiography)
+		return cached, nil
+	}
+	text, _, attr, err := a.callToolFull(ctx, toolGetArtistBiography, args)
+	if err != nil {
+		return nil, err
+	}
+	result := &BiographyResult{Text: text, Language: biographyLanguage}
+	if attr != nil {
+		result.Source = attr.Source
+		result.License = attr.License
+		result.URL = attr.URL
+		result.RetrievedAt = attr.RetrievedAt
+	}
+	a.storeBiography(toolGetArtistBiography, args, result)
+	a.recordProvenance(id, "biography", toolGetArtistBiography)
+	return result, nil
+}
+
+func (a *mcpAgent) GetArtistBiography(ctx context.Context, id, name, mbid string) (string, error) {
+	result, err := a.GetArtistBiographyExt(ctx, id, name, mbid)
+	if err != nil {
+		return "", err
+	}
+	return result.Text, nil
+}
+
+// toolCacheKey keys agent-side tool results by tool and arguments. Go
+// marshals map keys sorted, so the key is stable across calls.
+func toolCacheKey(tool string, args map[string]any) (string, bool) {
+	data, err := json.Marshal(args)
+	if err != nil {
+		return "", false
+	}
+	return tool + "\x00" + string(data), true
+}
+
+// cachedBiography returns the structured entry for the arguments, or nil on a
+// miss (or with caching disabled). Undecodable entries are treated as misses.
+func (a *mcpAgent) cachedBiography(tool string, args map[string]any) *BiographyResult {
+	if a.cache == nil {
+		return nil
+	}
+	key, ok := toolCacheKey(tool, args)
+	if !ok {
+		return nil
+	}
+	data, ok := a.cache.Get(key)
+	if !ok {
+		return nil
+	}
+	var result BiographyResult
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil
+	}
+	return &result
+}
+
+func (a *mcpAgent) storeBiography(tool string, args map[string]any, result *BiographyResult) {
+	if a.cache == nil {
+		return
+	}
+	key, ok := toolCacheKey(tool, args)
+	if !ok {
+		return
+	}
+	if data, err := json.Marshal(result); err == nil {
+		a.cache.Set(key, data)
+	}
+}
+
+// cachedToolText returns the cached plain-text result for the arguments;
+// misses (and disabled caching) report ok=false.
+func (a *mcpAgent) cachedToolText(tool string, args map[string]any) (string, bool) {
+	if a.cache == nil {
+		return "", false
+	}
+	key, ok := toolCacheKey(tool, args)
+	if !ok {
+		return "", false
+	}
+	data, ok := a.cache.Get(key)
+	if !ok {
+		return "", false
+	}
+	return string(data), true
+}
+
+func (a *mcpAgent) storeToolText(tool string, args map[string]any, text string) {
+	if a.cache == nil {
+		return
+	}
+	if key, ok := toolCacheKey(tool, args); ok {
+		a.cache.Set(key, []byte(text))
+	}
+}
+
+func (a *mcpAgent) GetArtistURL(ctx context.Context, id, name, mbid string) (string, error) {
+	if o, ok := a.overrides[id]; ok && o.URL != "" {
+		// The admin pinned the canonical URL; no lookup needed.
+		return o.URL, nil
+	}
+	args := a.artistArgs(id, name, mbid)
+	if conf.Server.MCP.ValidateURLs {
+		// Asks the server to HEAD-check candidates and fall back past dead
+		// links. Off by default, as it costs an extra request per lookup.
+		args["validate"] = true
+	}
+	if t := conf.Server.MCP.SearchURLTemplate; t != "" {
+		// Overrides the server's search-URL fallback (e.g. with a DuckDuckGo
+		// template); validated at startup by validateConfig.
+		args["searchUrlTemplate"] = t
+	}
+	if url, ok := a.cachedToolText(toolGetArtistURL, args); ok {
+		// Served from the agent's own cache - with CacheMode "disk" this
+		// survives restarts without even starting the server.
+		a.recordProvenance(id, "url", toolGetArtistURL)
+		return url, nil
+	}
+	url, err := a.callTextTool(ctx, toolGetArtistURL, args)
+	if err != nil {
+		return "", err
+	}
+	a.storeToolText(toolGetArtistURL, args, url)
+	a.recordProvenance(id, "url", toolGetArtistURL)
+	return url, nil
+}
+
+// GetArtistThumbnail returns a single small image URL, from the fastest source
+// the server has. It is meant for list views, where fetching the full image
+// candidate set would be wasteful.
+func (a *mcpAgent) GetArtistThumbnail(ctx context.Context, id, name, mbid string) (string, error) {
+	url, err := a.callTextTool(ctx, toolGetArtistThumbnail, a.artistArgs(id, name, mbid))
+	if err != nil {
+		return "", err
+	}
+	a.recordProvenance(id, "thumbnail", toolGetArtistThumbnail)
+	return url, nil
+}
+
+// GetArtistSortName returns the artist's sort name ("Beatles, The"), as
+// curated by MusicBrainz or derived from the display name.
+func (a *mcpAgent) GetArtistSortName(ctx context.Context, id, name, mbid string) (string, error) {
+	return a.callTextTool(ctx, toolGetArtistSortName, a.artistArgs(id, name, mbid))
+}
+
+// GetSimilarArtists returns related artists with their MBIDs populated, so the
+// external-info layer can match them against the library (or carry them as
+// external-only entries) the same way it does for other agents.
+func (a *mcpAgent) GetSimilarArtists(ctx context.Context, id, name, mbid string, limit int) ([]agents.Artist, error) {
+	args := a.artistArgs(id, name, mbid)
+	args["limit"] = limit
+	text, err := a.callTextTool(ctx, toolGetSimilarArtists, args)
+	if err != nil {
+		return nil, err
+	}
+	var similar []struct {
+		Name string `json:"name"`
+		MBID string `json:"mbid"`
+	}
+	if err := json.Unmarshal([]byte(text), &similar); err != nil {
+		log.Warn(ctx, "MCP server returned malformed similar artists", "artist", name, err)
+		return nil, agents.ErrNotFound
+	}
+	result := make([]agents.Artist, 0, len(similar))
+	for _, s := range similar {
+		if s.Name == "" {
+			continue
+		}
+		result = append(result, agents.Artist{Name: s.Name, MBID: s.MBID})
+	}
+	if len(result) == 0 {
+		return nil, agents.ErrNotFound
+	}
+	return result, nil
+}
+
+// GetArtistTopSongs returns the artist's best-known songs, parsed from the
+// tool's JSON array. Fewer than count entries is fine; zero maps to
+// ErrNotFound so the next agent in the chain is tried.
+func (a *mcpAgent) GetArtistTopSongs(ctx context.Context, id, artistName, mbid string, count int) ([]agents.Song, error) {
+	args := a.artistArgs(id, artistName, mbid)
+	args["count"] = count
+	text, err := a.callTextTool(ctx, toolGetArtistTopSongs, args)
+	if err != nil {
+		return nil, err
+	}
+	var songs []struct {
+		Title string `json:"title"`
+		MBID  string `json:"mbid"`
+	}
+	if err := json.Unmarshal([]byte(text), &songs); err != nil {
+		log.Warn(ctx, "MCP server returned malformed top songs", "artist", artistName, err)
+		return nil, agents.ErrNotFound
+	}
+	result := make([]agents.Song, 0, len(songs))
+	for _, s := range songs {
+		if s.Title == "" {
+			continue
+		}
+		result = append(result, agents.Song{Name: s.Title, MBID: s.MBID})
+	}
+	if len(result) == 0 {
+		return nil, agents.ErrNotFound
+	}
+	return result, nil
+}
+
+// GetArtistImages returns the artist's image candidates in multiple sizes,
+// parsed from the tool's JSON array into the shape the external-info layer
+// expects.
+func (a *mcpAgent) GetArtistImages(ctx context.Context, id, name, mbid string) ([]agents.ExternalImage, error) {
+	text, err := a.callTextTool(ctx, toolGetArtistImages, a.artistArgs(id, name, mbid))
+	if err != nil {
+		return nil, err
+	}
+	var images []struct {
+		URL  string `json:"url"`
+		Size int    `json:"size"`
+	}
+	if err := json.Unmarshal([]byte(text), &images); err != nil {
+		log.Warn(ctx, "MCP server returned malformed artist images", "artist", name, err)
+		return nil, agents.ErrNotFound
+	}
+	result := make([]agents.ExternalImage, 0, len(images))
+	for _, img := range images {
+		if img.URL == "" {
+			continue
+		}
+		result = append(result, agents.ExternalImage{URL: img.URL, Size: img.Size})
+	}
+	if len(result) == 0 {
+		return nil, agents.ErrNotFound
+	}
+	a.recordProvenance(id, "images", toolGetArtistImages)
+	return result, nil
+}
+
+// GetAlbumInfo returns a description and canonical URL for an album, parsed
+// from the tool's JSON object. The server disambiguates same-title albums by
+// the artist relation, so the artist name is always passed along.
+func (a *mcpAgent) GetAlbumInfo(ctx context.Context, name, artist, mbid string) (*agents.AlbumInfo, error) {
+	text, err := a.callTextTool(ctx, toolGetAlbumInfo, map[string]any{"album": name, "artist": artist, "mbid": mbid})
+	if err != nil {
+		return nil, err
+	}
+	var info struct {
+		Description string `json:"description"`
+		URL         string `json:"url"`
+	}
+	if err := json.Unmarshal([]byte(text), &info); err != nil {
+		log.Warn(ctx, "MCP server returned malformed album info", "album", name, err)
+		return nil, agents.ErrNotFound
+	}
+	if info.Description == "" {
+		return nil, agents.ErrNotFound
+	}
+	return &agents.AlbumInfo{Name: name, MBID: mbid, Description: info.Description, URL: info.URL}, nil
+}
+
+// GetArtistMBID resolves an artist's MusicBrainz ID by name, so Navidrome can
+// backfill MBIDs for artists imported from untagged files. A pinned override
+// wins without a lookup, and the server's answer is only accepted when it is a
+// well-formed UUID - anything else is treated as not found rather than risk
+// poisoning the library with junk identifiers.
+func (a *mcpAgent) GetArtistMBID(ctx context.Context, id string, name string) (string, error) {
+	if o, ok := a.overrides[id]; ok && o.MBID != "" {
+		return o.MBID, nil
+	}
+	text, err := a.callTextTool(ctx, toolGetArtistMBID, a.artistArgs(id, name, ""))
+	if err != nil {
+		return "", err
+	}
+	mbid := strings.TrimSpace(text)
+	if _, err := uuid.Parse(mbid); err != nil {
+		log.Warn(ctx, "MCP server returned a malformed MBID", "artist", name, "mbid", mbid)
+		return "", agents.ErrNotFound
+	}
+	return mbid, nil
+}
+
+// artistArgs builds the common tool arguments, applying any pinned override
+// for the artist: a pinned MBID replaces the library one, and a pinned QID is
+// passed along for the server to resolve directly.
+func (a *mcpAgent) artistArgs(id, name, mbid string) map[string]any {
+	args := map[string]any{"id": id, "name": name, "mbid": mbid}
+	if o, ok := a.overrides[id]; ok {
+		if o.MBID != "" {
+			args["mbid"] = o.MBID
+		}
+		if o.QID != "" {
+			args["qid"] = o.QID
+		}
+	}
+	return args
+}
+
+// withTimeoutHint copies the tool arguments, adding the caller's remaining
+// deadline as a timeoutMs hint. The stdio protocol carries no contexts, so
+// the hint is how the server learns when to stop waiting on upstreams. The
+// original map is never mutated: cache keys are derived from the args before
+// the call, and a volatile deadline in them would make every key unique.
+func withTimeoutHint(ctx context.Context, args any) any {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return args
+	}
+	remaining := time.Until(deadline).Milliseconds()
+	if remaining <= 0 {
+		return args
+	}
+	m, ok := args.(map[string]any)
+	if !ok {
+		return args
+	}
+	hinted := make(map[string]any, len(m)+1)
+	for k, v := range m {
+		hinted[k] = v
+	}
+	hinted["timeoutMs"] = remaining
+	return hinted
+}
+
+// callTextTool calls a tool and returns its flattened text, for callers that
+// only want the simple string result (bio, URL).
+func (a *mcpAgent) callTextTool(ctx context.Context, tool string, args any) (string, error) {
+	text, _, err := a.callToolParts(ctx, tool, args)
+	return text, err
+}
+
+// callToolParts calls a tool and splits its content items: all text parts are
+// concatenated into a single string, and non-text parts (images, embedded
+// resources) are returned as-is for callers that can use them. Tool-level
+// errors and fully empty results map to agent errors.
+func (a *mcpAgent) callToolParts(ctx context.Context, tool string, args any) (string, []toolContent, error) {
+	text, nonText, _, err := a.callToolFull(ctx, tool, args)
+	return text, nonText, err
+}
+
+// callToolFull is callToolParts, additionally returning the attribution the
+// server attached to this response (nil when there was none), for callers
+// that carry provenance per result instead of per tool.
+func (a *mcpAgent) callToolFull(ctx context.Context, tool string, args any) (string, []toolContent, *Attribution, error) {
+	// The requesting user is only used for audit logging and metrics; the tool
+	// args carry nothing but artist identifiers.
+	user := auditUser(ctx)
+	cl, err := a.clientFor(tool)
+	if err != nil {
+		log.Trace(ctx, "MCP tool is not routed to any server", "tool", tool)
+		return "", nil, nil, err
+	}
+	if !cl.supportsTool(tool) {
+		// The catalog says the server does not offer this tool; skip the call
+		// (and a possible server start) entirely.
+		log.Trace(ctx, "MCP tool not in the server's catalog", "tool", tool)
+		return "", nil, nil, agents.ErrNotFound
+	}
+	if a.quota != nil && !a.quota.acquire() {
+		log.Debug(ctx, "MCP tool call rejected by request quota", "tool", tool, "user", user)
+		return "", nil, nil, errQuotaExceeded
+	}
+	log.Debug(ctx, "Calling MCP tool", "tool", tool, "user", user)
+	getMCPMetrics().toolCalls.With(prometheus.Labels{"tool": tool, "user": user}).Inc()
+	resp, err := cl.callTool(ctx, tool, withTimeoutHint(ctx, args))
+	if err != nil {
+		if errors.Is(err, errServerDisabled) {
+			// Rejected without even trying the server - remember the lookup, so
+			// it can be replayed once the server is re-enabled.
+			a.pending.add(tool, args)
+		}
+		a.health.recordFailure(tool)
+		log.Debug(ctx, "MCP tool call failed", "tool", tool, "user", user, err)
+		err = sanitizeError(err)
+		log.Warn(ctx, "MCP tool call failed", "tool", tool, err)
+		return "", nil, nil, err
+	}
+	// A not-found is still a healthy round-trip, so any response counts as a
+	// success for degradation tracking.
+	a.health.recordSuccess(tool)
+	attr := a.recordAttribution(tool, resp)
+	var parts []string
+	var nonText []toolContent
+	for _, c := range resp.Content {
+		switch {
+		case c.Type == "text":
+			if c.Text != "" {
+				parts = append(parts, c.Text)
+			}
+		case c.Type == "resource" && c.Resource != nil && c.Resource.Text != "":
+			// Some servers return large results as an embedded resource instead
+			// of inline text; a text or JSON payload stands in for the result,
+			// so the structured decoding paths (similar artists, images) work
+			// the same either way.
+			if !embeddableMimeType(c.Resource.MimeType) {
+				log.Debug(ctx, "Embedded MCP resource has an unsupported mime type", "tool", tool,
+					"uri", c.Resource.URI, "mimeType", c.Resource.MimeType)
+				nonText = append(nonText, c)
+				continue
+			}
+			if len(c.Resource.Text) > maxEmbeddedResourceBytes {
+				// Truncating would corrupt JSON payloads, so oversized
+				// resources are dropped whole.
+				log.Warn(ctx, "Skipping oversized embedded MCP resource", "tool", tool,
+					"uri", c.Resource.URI, "size", len(c.Resource.Text))
+				continue
+			}
+			parts = append(parts, c.Resource.Text)
+		default:
+			nonText = append(nonText, c)
+		}
+	}
+	text := strings.Join(parts, "\n")
+	if resp.IsError {
+		log.Debug(ctx, "MCP tool returned an error", "tool", tool, "message", text)
+		return "", nil, nil, decodeToolError(text)
+	}
+	text = unwrapToolEnvelope(text)
+	// Empty results count too: a server that keeps answering "success" with
+	// nothing in it is exactly what the suspicious-output check is for.
+	a.health.recordOutputSize(tool, len(text))
+	if text == "" && len(nonText) == 0 {
+		return "", nil, nil, agents.ErrNotFound
+	}
+	return text, nonText, attr, nil
+}
+
+// maxEmbeddedResourceBytes caps how much embedded-resource text is accepted as
+// a tool result; anything bigger is more likely runaway output than metadata.
+const maxEmbeddedResourceBytes = 1024 * 1024
+
+// embeddableMimeType reports whether an embedded resource's payload can stand
+// in for inline text: plain text directly, JSON for the structured decoders.
+func embeddableMimeType(mimeType string) bool {
+	mt := strings.ToLower(strings.TrimSpace(mimeType))
+	if i := strings.Index(mt, ";"); i >= 0 {
+		mt = strings.TrimSpace(mt[:i])
+	}
+	return mt == "text/plain" || mt == "application/json"
+}
+
+// recordAttribution keeps the last attribution reported by the server for
+// each tool, so license info is visible in the status endpoint. It returns
+// the parsed attribution, or nil when the response carried none.
+func (a *mcpAgent) recordAttribution(tool string, resp *toolResponse) *Attribution {
+	raw, ok := resp.Meta["attribution"]
+	if !ok {
+		return nil
+	}
+	var attr Attribution
+	if err := json.Unmarshal(raw, &attr); err != nil {
+		return nil
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.attributions == nil {
+		a.attributions = make(map[string]Attribution)
+	}
+	a.attributions[tool] = attr
+	return &attr
+}
+
+// Status reports the agent's transport counters, for the status endpoint. The
+// cumulative totals come from the persisted stats file.
+type Status struct {
+	// State is the lifecycle state (disabled, notConfigured,
+	// configuredButMissing, ready, failed); Reason says why, when not ready.
+	State           AgentState             `json:"state"`
+	StateReason     string                 `json:"stateReason,omitempty"`
+	Transport       TransportStats         `json:"transport"`
+	PersistedTotals TransportStats         `json:"persistedTotals"`
+	RestartPolicy   restartPolicy          `json:"restartPolicy"`
+	Attributions    map[string]Attribution `json:"attributions,omitempty"`
+	Tools           map[string]ToolHealth  `json:"tools,omitempty"`
+	// KnownTools is the server's tool catalog - from the persisted cache or
+	// post-initialize discovery - available even while the plugin is idle.
+	KnownTools []string        `json:"knownTools,omitempty"`
+	SelfTest   *SelfTestResult `json:"selfTest,omitempty"`
+	// Persistence reports whether disk-backed features are active, or why they
+	// were degraded ("disabled (read-only data folder)").
+	Persistence string `json:"persistence,omitempty"`
+	// Disabled is set when the server hit MCP.MaxRestartAttempts consecutive
+	// failed starts; the agent stops trying until re-enabled.
+	Disabled bool `json:"disabled,omitempty"`
+	// Breaker is the start-failure circuit breaker state: "open" while the
+	// agent refuses calls, "closed" in normal operation.
+	Breaker       string `json:"breaker,omitempty"`
+	StartFailures int    `json:"startFailures,omitempty"`
+	// PendingRetries counts lookups rejected while disabled, queued for replay.
+	PendingRetries int `json:"pendingRetries,omitempty"`
+	// EffectiveConfig is the fully resolved MCP configuration, with the source
+	// of each value and secrets masked.
+	EffectiveConfig map[string]conf.EffectiveSetting `json:"effectiveConfig,omitempty"`
+	// QuotaRemaining is the outbound request budget left in the current window
+	// (nil when MCP.MaxRequestsPerHour is not set); QuotaResetAt is when it
+	// refills.
+	QuotaRemaining *int       `json:"quotaRemaining,omitempty"`
+	QuotaResetAt   *time.Time `json:"quotaResetAt,omitempty"`
+	// Summary holds counts over the full lists above; set by the paging layer,
+	// which may have trimmed the lists themselves to a window.
+	Summary *StatusSummary `json:"summary,omitempty"`
+}
+
+func (a *mcpAgent) status() Status {
+	a.mu.Lock()
+	attributions := make(map[string]Attribution, len(a.attributions))
+	for k, v := range a.attributions {
+		attributions[k] = v
+	}
+	selfTest := a.selfTest
+	a.mu.Unlock()
+	disabled, startFailures := a.client.disabledState()
+	state, reason := AgentStateInfo()
+	var quotaRemaining *int
+	var quotaResetAt *time.Time
+	if a.quota != nil {
+		remaining, resetAt := a.quota.snapshot()
+		quotaRemaining, quotaResetAt = &remaining, &resetAt
+	}
+	return Status{
+		State:           state,
+		StateReason:     reason,
+		Transport:       a.client.transport.Stats().snapshot(),
+		PersistedTotals: loadPersistedStats(),
+		RestartPolicy:   a.client.policy,
+		Attributions:    attributions,
+		Tools:           a.health.snapshot(),
+		KnownTools:      a.client.knownToolList(),
+		SelfTest:        selfTest,
+		Persistence:     persistence.statusString(),
+		Disabled:        disabled,
+		Breaker:         breakerState(disabled),
+		StartFailures:   startFailures,
+		PendingRetries:  a.pending.len(),
+		EffectiveConfig: conf.MCPEffectiveConfig(),
+		QuotaRemaining:  quotaRemaining,
+		QuotaResetAt:    quotaResetAt,
+	}
+}
+
+// GetStatus returns the current agent status, or a zero Status if the agent is
+// not active.
+func GetStatus() Status {
+	a := mcpConstructor(nil)
+	if a == nil {
+		state, reason := AgentStateInfo()
+		return Status{
+			State:           state,
+			StateReason:     reason,
+			PersistedTotals: loadPersistedStats(),
+			Persistence:     persistence.statusString(),
+			EffectiveConfig: conf.MCPEffectiveConfig(),
+		}
+	}
+	return a.status()
+}
+
+// previewLookup asks the server which requests a lookup for the artist would
+// issue, via the tools' dryRun argument; field is "biography" or "url". The
+// server's JSON preview is passed through untouched.
+func (a *mcpAgent) previewLookup(ctx context.Context, field, name, mbid string) (json.RawMessage, error) {
+	var tool string
+	switch field {
+	case "biography":
+		tool = toolGetArtistBiography
+	case "url":
+		tool = toolGetArtistURL
+	default:
+		return nil, fmt.Errorf("mcp: unknown preview field %q", field)
+	}
+	args := a.artistArgs("", name, mbid)
+	args["dryRun"] = true
+	text, err := a.callTextTool(ctx, tool, args)
+	if err != nil {
+		return nil, err
+	}
+	return json.RawMessage(text), nil
+}
+
+// PreviewLookup lists the upstream requests a biography or URL lookup for the
+// artist would issue, without any network access. This is the entry point for
+// the admin "preview" action, for auditing external calls before enabling
+// them.
+func PreviewLookup(ctx context.Context, field, name, mbid string) (json.RawMessage, error) {
+	a := mcpConstructor(nil)
+	if a == nil {
+		return nil, errors.New("mcp: agent is not active")
+	}
+	return a.previewLookup(ctx, field, name, mbid)
+}
+
+// breakerState renders the start-failure breaker for the status report: open
+// while the agent refuses calls, closed in normal operation.
+func breakerState(disabled bool) string {
+	if disabled {
+		return "open"
+	}
+	return "closed"
+}
+
+// ReEnable clears the permanently-failed state set after too many failed start
+// attempts, so the next lookup tries a fresh start. Meant for when the server
+// binary was fixed without restarting Navidrome.
+func ReEnable() {
+	a := mcpConstructor(nil)
+	if a != nil {
+		a.reenable()
+	}
+}
+
+// ResetCircuitBreaker immediately closes the start-failure circuit breaker,
+// without waiting for anything: the disabled state and failure count are
+// cleared and the next lookup attempts a fresh server start. This is the
+// entry point for the admin "reset breaker" action.
+func ResetCircuitBreaker() {
+	ReEnable()
+}
+
+// replayDelay is the pause between replayed lookups, keeping the replay at low
+// priority: a freshly re-enabled server should serve live traffic first.
+const replayDelay = time.Second
+
+func (a *mcpAgent) reenable() {
+	for _, c := range a.allClients() {
+		c.reenable()
+	}
+	go a.replayPending()
+}
+
+// replayPending replays lookups queued while the server was disabled, through
+// the normal call path. Lookups that get rejected again (the server is still
+// broken) simply re-queue via callToolParts, ready for the next re-enable.
+func (a *mcpAgent) replayPending() {
+	entries := a.pending.drain()
+	if len(entries) == 0 {
+		return
+	}
+	log.Info("Replaying lookups rejected while the MCP server was disabled", "count", len(entries))
+	ctx := context.Background()
+	for i, e := range entries {
+		if i > 0 {
+			time.Sleep(a.replayDelay)
+		}
+		if _, _, err := a.callToolParts(ctx, e.tool, e.args); err != nil {
+			log.Debug(ctx, "Replayed MCP lookup failed", "tool", e.tool, err)
+		}
+	}
+}
+
+func init() {
+	conf.AddHook(func() {
+		agents.Register(mcpAgentName, func(ds model.DataStore) agents.Interface {
+			// Workaround for a (Interface)(nil) not being the same as a (*mcpAgent)(nil)
+			// See https://go.dev/doc/faq#nil_error
+			a := mcpConstructor(ds)
+			if a != nil {
+				return a
+			}
+			return nil
+		})
+	})
+}
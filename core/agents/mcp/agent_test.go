@@ -0,0 +1,591 @@
+package mcp
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/navidrome/navidrome/conf/configtest"
+	"github.com/navidrome/navidrome/core/agents"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("callToolParts", func() {
+	var transport *fakeTransport
+	var agent *mcpAgent
+
+	BeforeEach(func() {
+		DeferCleanup(configtest.SetupConfig())
+		transport = newFakeTransport()
+		agent = &mcpAgent{
+			client:  newClient(transport, restartPolicy{}),
+			health:  newToolHealth(),
+			pending: newRetryQueue(defaultRetryQueueSize),
+		}
+	})
+
+	It("concatenates all text parts and surfaces non-text parts", func() {
+		transport.handle = func(method string) any {
+			if method == "tools/call" {
+				return map[string]any{"content": []map[string]any{
+					{"type": "text", "text": "part one"},
+					{"type": "image", "data": "aGk=", "mimeType": "image/png"},
+					{"type": "text", "text": "part two"},
+					{"type": "resource", "resource": map[string]any{"uri": "mcp://server/attributions", "mimeType": "application/json"}},
+				}}
+			}
+			return map[string]any{}
+		}
+
+		text, parts, err := agent.callToolParts(context.Background(), "any_tool", nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(text).To(Equal("part one\npart two"))
+		Expect(parts).To(HaveLen(2))
+		Expect(parts[0].Type).To(Equal("image"))
+		Expect(parts[0].Data).To(Equal("aGk="))
+		Expect(parts[0].MimeType).To(Equal("image/png"))
+		Expect(parts[1].Type).To(Equal("resource"))
+		Expect(parts[1].Resource).ToNot(BeNil())
+		Expect(parts[1].Resource.URI).To(Equal("mcp://server/attributions"))
+	})
+
+	It("maps coded error envelopes to typed errors", func() {
+		transport.handle = func(method string) any {
+			if method == "tools/call" {
+				return map[string]any{"isError": true, "content": []map[string]any{{"type": "text",
+					"text": `{"ok":false,"error":{"code":"upstream","message":"unexpected status 503"}}`}}}
+			}
+			return map[string]any{}
+		}
+		_, _, err := agent.callToolParts(context.Background(), "any_tool", nil)
+		Expect(err).ToNot(MatchError(agents.ErrNotFound))
+		Expect(err.Error()).To(ContainSubstring("unexpected status 503"))
+	})
+
+	It("keeps mapping plain-text error responses to ErrNotFound", func() {
+		transport.handle = func(method string) any {
+			if method == "tools/call" {
+				return map[string]any{"isError": true, "content": []map[string]any{{"type": "text",
+					"text": `no results for "Nobody"`}}}
+			}
+			return map[string]any{}
+		}
+		_, _, err := agent.callToolParts(context.Background(), "any_tool", nil)
+		Expect(err).To(MatchError(agents.ErrNotFound))
+	})
+
+	It("unwraps enveloped successes to their payload", func() {
+		transport.handle = func(method string) any {
+			if method == "tools/call" {
+				return map[string]any{"content": []map[string]any{{"type": "text",
+					"text": `{"ok":true,"data":"a biography"}`}}}
+			}
+			return map[string]any{}
+		}
+		text, _, err := agent.callToolParts(context.Background(), "any_tool", nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(text).To(Equal("a biography"))
+	})
+
+	It("still maps fully empty responses to ErrNotFound", func() {
+		transport.handle = func(method string) any {
+			if method == "tools/call" {
+				return map[string]any{"content": []map[string]any{}}
+			}
+			return map[string]any{}
+		}
+		_, _, err := agent.callToolParts(context.Background(), "any_tool", nil)
+		Expect(err).To(MatchError(agents.ErrNotFound))
+	})
+
+	It("extracts embedded text and JSON resources as the result", func() {
+		transport.handle = func(method string) any {
+			if method == "tools/call" {
+				return map[string]any{"content": []map[string]any{
+					{"type": "resource", "resource": map[string]any{
+						"uri": "mcp://server/result", "mimeType": "application/json",
+						"text": `[{"name":"First","mbid":"mbid-1"}]`}},
+					{"type": "resource", "resource": map[string]any{
+						"uri": "mcp://server/note", "mimeType": "text/plain; charset=utf-8",
+						"text": "a note"}},
+				}}
+			}
+			return map[string]any{}
+		}
+
+		text, parts, err := agent.callToolParts(context.Background(), "any_tool", nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(text).To(Equal("[{\"name\":\"First\",\"mbid\":\"mbid-1\"}]\na note"))
+		Expect(parts).To(BeEmpty())
+	})
+
+	It("keeps resources with unsupported mime types as non-text parts", func() {
+		transport.handle = func(method string) any {
+			if method == "tools/call" {
+				return map[string]any{"content": []map[string]any{
+					{"type": "text", "text": "inline"},
+					{"type": "resource", "resource": map[string]any{
+						"uri": "mcp://server/blob", "mimeType": "application/octet-stream",
+						"text": "binary-ish"}},
+				}}
+			}
+			return map[string]any{}
+		}
+
+		text, parts, err := agent.callToolParts(context.Background(), "any_tool", nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(text).To(Equal("inline"))
+		Expect(parts).To(HaveLen(1))
+		Expect(parts[0].Resource.URI).To(Equal("mcp://server/blob"))
+	})
+
+	It("drops oversized embedded resources whole", func() {
+		transport.handle = func(method string) any {
+			if method == "tools/call" {
+				return map[string]any{"content": []map[string]any{
+					{"type": "resource", "resource": map[string]any{
+						"uri": "mcp://server/huge", "mimeType": "text/plain",
+						"text": strings.Repeat("x", maxEmbeddedResourceBytes+1)}},
+				}}
+			}
+			return map[string]any{}
+		}
+
+		_, _, err := agent.callToolParts(context.Background(), "any_tool", nil)
+		Expect(err).To(MatchError(agents.ErrNotFound))
+	})
+
+	Describe("GetSimilarArtists", func() {
+		It("parses the tool's JSON payload into agents.Artist entries with MBIDs", func() {
+			transport.handle = func(method string) any {
+				if method == "tools/call" {
+					return map[string]any{"content": []map[string]any{{"type": "text",
+						"text": `[{"name":"First","mbid":"mbid-1"},{"name":"Second","mbid":"mbid-2"}]`}}}
+				}
+				return map[string]any{}
+			}
+
+			similar, err := agent.GetSimilarArtists(context.Background(), "1", "Artist", "mbid-0", 5)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(similar).To(Equal([]agents.Artist{
+				{Name: "First", MBID: "mbid-1"},
+				{Name: "Second", MBID: "mbid-2"},
+			}))
+		})
+
+		It("maps malformed payloads to ErrNotFound", func() {
+			transport.handle = func(method string) any {
+				if method == "tools/call" {
+					return map[string]any{"content": []map[string]any{{"type": "text", "text": "not json"}}}
+				}
+				return map[string]any{}
+			}
+			_, err := agent.GetSimilarArtists(context.Background(), "1", "Artist", "mbid-0", 5)
+			Expect(err).To(MatchError(agents.ErrNotFound))
+		})
+	})
+
+	Describe("GetArtistTopSongs", func() {
+		It("parses the tool's JSON payload into agents.Song entries", func() {
+			transport.handle = func(method string) any {
+				if method == "tools/call" {
+					return map[string]any{"content": []map[string]any{{"type": "text",
+						"text": `[{"title":"First Song","mbid":"rec-1"},{"title":"Second Song"}]`}}}
+				}
+				return map[string]any{}
+			}
+
+			songs, err := agent.GetArtistTopSongs(context.Background(), "1", "Artist", "mbid-0", 5)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(songs).To(Equal([]agents.Song{
+				{Name: "First Song", MBID: "rec-1"},
+				{Name: "Second Song"},
+			}))
+		})
+
+		It("maps empty and malformed payloads to ErrNotFound", func() {
+			for _, payload := range []string{"[]", "not json"} {
+				transport.handle = func(method string) any {
+					if method == "tools/call" {
+						return map[string]any{"content": []map[string]any{{"type": "text", "text": payload}}}
+					}
+					return map[string]any{}
+				}
+				_, err := agent.GetArtistTopSongs(context.Background(), "1", "Artist", "mbid-0", 5)
+				Expect(err).To(MatchError(agents.ErrNotFound))
+			}
+		})
+	})
+
+	Describe("GetArtistImages", func() {
+		It("parses the tool's JSON payload into agents.ExternalImage entries", func() {
+			transport.handle = func(method string) any {
+				if method == "tools/call" {
+					return map[string]any{"content": []map[string]any{{"type": "text",
+						"text": `[{"url":"https://img/large.jpg?width=600","size":600},{"url":"https://img/small.jpg?width=174","size":174}]`}}}
+				}
+				return map[string]any{}
+			}
+
+			images, err := agent.GetArtistImages(context.Background(), "1", "Artist", "mbid-0")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(images).To(Equal([]agents.ExternalImage{
+				{URL: "https://img/large.jpg?width=600", Size: 600},
+				{URL: "https://img/small.jpg?width=174", Size: 174},
+			}))
+		})
+
+		It("maps malformed payloads and empty arrays to ErrNotFound", func() {
+			for _, payload := range []string{"not json", "[]", `[{"size":600}]`} {
+				transport.handle = func(method string) any {
+					if method == "tools/call" {
+						return map[string]any{"content": []map[string]any{{"type": "text", "text": payload}}}
+					}
+					return map[string]any{}
+				}
+				_, err := agent.GetArtistImages(context.Background(), "1", "Artist", "mbid-0")
+				Expect(err).To(MatchError(agents.ErrNotFound))
+			}
+		})
+	})
+
+	Describe("GetAlbumInfo", func() {
+		It("parses the tool's JSON payload into an agents.AlbumInfo", func() {
+			transport.handle = func(method string) any {
+				if method == "tools/call" {
+					return map[string]any{"content": []map[string]any{{"type": "text",
+						"text": `{"description":"A landmark album.","url":"https://en.wikipedia.org/wiki/The_Album"}`}}}
+				}
+				return map[string]any{}
+			}
+
+			info, err := agent.GetAlbumInfo(context.Background(), "The Album", "Artist", "rg-mbid-0")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(info).To(Equal(&agents.AlbumInfo{
+				Name:        "The Album",
+				MBID:        "rg-mbid-0",
+				Description: "A landmark album.",
+				URL:         "https://en.wikipedia.org/wiki/The_Album",
+			}))
+		})
+
+		It("maps malformed and descriptionless payloads to ErrNotFound", func() {
+			for _, payload := range []string{"not json", "{}", `{"url":"https://example.com"}`} {
+				transport.handle = func(method string) any {
+					if method == "tools/call" {
+						return map[string]any{"content": []map[string]any{{"type": "text", "text": payload}}}
+					}
+					return map[string]any{}
+				}
+				_, err := agent.GetAlbumInfo(context.Background(), "The Album", "Artist", "rg-mbid-0")
+				Expect(err).To(MatchError(agents.ErrNotFound))
+			}
+		})
+	})
+
+	Describe("GetArtistBiographyExt", func() {
+		var calls int
+
+		BeforeEach(func() {
+			calls = 0
+			transport.handle = func(method string) any {
+				if method == "tools/call" {
+					calls++
+					return map[string]any{
+						"content": []map[string]any{{"type": "text", "text": "a bio"}},
+						"_meta": map[string]any{"attribution": map[string]any{
+							"source":      "wikipedia",
+							"license":     "CC BY-SA 4.0",
+							"url":         "https://en.wikipedia.org/wiki/Artist",
+							"retrievedAt": "2024-06-01T12:00:00Z",
+						}},
+					}
+				}
+				return map[string]any{}
+			}
+		})
+
+		It("returns the biography with its provenance", func() {
+			result, err := agent.GetArtistBiographyExt(context.Background(), "1", "Artist", "mbid-0")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.Text).To(Equal("a bio"))
+			Expect(result.Language).To(Equal("en"))
+			Expect(result.Source).To(Equal("wikipedia"))
+			Expect(result.License).To(Equal("CC BY-SA 4.0"))
+			Expect(result.URL).To(Equal("https://en.wikipedia.org/wiki/Artist"))
+			Expect(result.RetrievedAt.IsZero()).To(BeFalse())
+		})
+
+		It("backs the legacy string method, returning the same text", func() {
+			result, err := agent.GetArtistBiographyExt(context.Background(), "1", "Artist", "mbid-0")
+			Expect(err).ToNot(HaveOccurred())
+
+			text, err := agent.GetArtistBiography(context.Background(), "1", "Artist", "mbid-0")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(text).To(Equal(result.Text))
+		})
+
+		It("shares cache entries between the structured and string methods", func() {
+			agent.cache = NewMemoryCache(0, 0)
+			result, err := agent.GetArtistBiographyExt(context.Background(), "1", "Artist", "mbid-0")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(calls).To(Equal(1))
+
+			text, err := agent.GetArtistBiography(context.Background(), "1", "Artist", "mbid-0")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(text).To(Equal(result.Text))
+			Expect(calls).To(Equal(1)) // Served from the shared cache
+
+			cached, err := agent.GetArtistBiographyExt(context.Background(), "1", "Artist", "mbid-0")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(cached).To(Equal(result)) // Provenance survives the cache
+		})
+	})
+
+	Describe("persistent tool cache", func() {
+		var calls int
+
+		BeforeEach(func() {
+			calls = 0
+			transport.handle = func(method string) any {
+				if method == "tools/call" {
+					calls++
+					return map[string]any{"content": []map[string]any{{"type": "text", "text": "a result"}}}
+				}
+				return map[string]any{}
+			}
+		})
+
+		It("serves a pre-populated URL without calling the server", func() {
+			agent.cache = NewMemoryCache(0, 0)
+			agent.storeToolText(toolGetArtistURL, agent.artistArgs("1", "Artist", "mbid-0"),
+				"https://example.com/artist")
+
+			url, err := agent.GetArtistURL(context.Background(), "1", "Artist", "mbid-0")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(url).To(Equal("https://example.com/artist"))
+			Expect(calls).To(BeZero())
+		})
+
+		It("writes URL results back for the next call", func() {
+			agent.cache = NewMemoryCache(0, 0)
+			for i := 0; i < 2; i++ {
+				url, err := agent.GetArtistURL(context.Background(), "1", "Artist", "mbid-0")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(url).To(Equal("a result"))
+			}
+			Expect(calls).To(Equal(1))
+		})
+
+		It("keeps biographies across agent restarts with a disk cache", func() {
+			dir := GinkgoT().TempDir()
+			agent.cache = newDiskCache(dir, time.Hour)
+			_, err := agent.GetArtistBiographyExt(context.Background(), "1", "Artist", "mbid-0")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(calls).To(Equal(1))
+
+			// A restarted agent opens the same directory; its server must not
+			// be consulted at all.
+			restarted := &mcpAgent{
+				client:  newClient(newFakeTransport(), restartPolicy{}),
+				health:  newToolHealth(),
+				pending: newRetryQueue(defaultRetryQueueSize),
+				cache:   newDiskCache(dir, time.Hour),
+			}
+			result, err := restarted.GetArtistBiographyExt(context.Background(), "1", "Artist", "mbid-0")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.Text).To(Equal("a result"))
+			Expect(calls).To(Equal(1))
+		})
+	})
+
+	Describe("previewLookup", func() {
+		It("passes the server's JSON preview through", func() {
+			transport.handle = func(method string) any {
+				if method == "tools/call" {
+					return map[string]any{"content": []map[string]any{{"type": "text",
+						"text": `{"dryRun":true,"requests":[]}`}}}
+				}
+				return map[string]any{}
+			}
+			preview, err := agent.previewLookup(context.Background(), "biography", "Artist", "mbid-0")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(preview)).To(Equal(`{"dryRun":true,"requests":[]}`))
+		})
+
+		It("rejects unknown fields", func() {
+			_, err := agent.previewLookup(context.Background(), "genre", "Artist", "")
+			Expect(err).To(MatchError(ContainSubstring("unknown preview field")))
+		})
+	})
+
+	Describe("GetArtistMBID", func() {
+		It("returns the resolved MBID when it is a well-formed UUID", func() {
+			transport.handle = func(method string) any {
+				if method == "tools/call" {
+					return map[string]any{"content": []map[string]any{{"type": "text",
+						"text": "b10bbbfc-cf9e-42e0-be17-e2c3e1d2600d"}}}
+				}
+				return map[string]any{}
+			}
+
+			mbid, err := agent.GetArtistMBID(context.Background(), "1", "The Beatles")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(mbid).To(Equal("b10bbbfc-cf9e-42e0-be17-e2c3e1d2600d"))
+		})
+
+		It("rejects answers that are not UUIDs", func() {
+			for _, payload := range []string{"not-a-uuid", "The Beatles", "b10bbbfc"} {
+				transport.handle = func(method string) any {
+					if method == "tools/call" {
+						return map[string]any{"content": []map[string]any{{"type": "text", "text": payload}}}
+					}
+					return map[string]any{}
+				}
+				_, err := agent.GetArtistMBID(context.Background(), "1", "The Beatles")
+				Expect(err).To(MatchError(agents.ErrNotFound))
+			}
+		})
+
+		It("returns a pinned override without calling the server", func() {
+			agent.overrides = artistOverrides{"1": {MBID: "f27ec8db-af05-4f36-916e-3d57f91ecf5e"}}
+			calls := 0
+			transport.handle = func(method string) any {
+				if method == "tools/call" {
+					calls++
+				}
+				return map[string]any{}
+			}
+
+			mbid, err := agent.GetArtistMBID(context.Background(), "1", "Michael Jackson")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(mbid).To(Equal("f27ec8db-af05-4f36-916e-3d57f91ecf5e"))
+			Expect(calls).To(BeZero())
+		})
+	})
+
+	It("does not treat a response with only non-text parts as not found", func() {
+		transport.handle = func(method string) any {
+			if method == "tools/call" {
+				return map[string]any{"content": []map[string]any{
+					{"type": "image", "data": "aGk=", "mimeType": "image/png"},
+				}}
+			}
+			return map[string]any{}
+		}
+		text, parts, err := agent.callToolParts(context.Background(), "any_tool", nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(text).To(BeEmpty())
+		Expect(parts).To(HaveLen(1))
+	})
+})
+
+var _ = Describe("per-tool routing", func() {
+	var primaryTransport *fakeTransport
+	var agent *mcpAgent
+
+	BeforeEach(func() {
+		DeferCleanup(configtest.SetupConfig())
+		primaryTransport = newFakeTransport()
+		agent = &mcpAgent{
+			client:  newClient(primaryTransport, restartPolicy{}),
+			health:  newToolHealth(),
+			pending: newRetryQueue(defaultRetryQueueSize),
+		}
+	})
+
+	It("routes everything through the primary client in single-server mode", func() {
+		c, err := agent.clientFor("any_tool")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(c).To(BeIdenticalTo(agent.client))
+	})
+
+	It("creates one client per distinct path and reuses the primary for its own", func() {
+		routes := buildToolRoutes(map[string]string{
+			"get_artist_biography": "/primary/server",
+			"get_artist_url":       "/other/server",
+			"get_artist_awards":    "/other/server",
+		}, "/primary/server", agent.client, nil)
+
+		Expect(routes["get_artist_biography"]).To(BeIdenticalTo(agent.client))
+		Expect(routes["get_artist_url"]).To(BeIdenticalTo(routes["get_artist_awards"]))
+		Expect(routes["get_artist_url"]).ToNot(BeIdenticalTo(agent.client))
+	})
+
+	It("dispatches a mapped tool to its routed client", func() {
+		routedTransport := newFakeTransport()
+		routedTransport.handle = func(method string) any {
+			if method == "tools/call" {
+				return map[string]any{"content": []map[string]any{{"type": "text", "text": "routed"}}}
+			}
+			return map[string]any{}
+		}
+		routed := newClient(routedTransport, restartPolicy{})
+		DeferCleanup(routed.reset)
+		agent.routes = map[string]*client{"get_artist_url": routed}
+
+		text, _, err := agent.callToolParts(context.Background(), "get_artist_url", nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(text).To(Equal("routed"))
+		Expect(routedTransport.sentToolCalls()).To(HaveLen(1))
+		Expect(primaryTransport.sentToolCalls()).To(BeEmpty())
+	})
+
+	It("reports unmapped tools as not found without starting any server", func() {
+		agent.routes = map[string]*client{"get_artist_url": agent.client}
+
+		_, _, err := agent.callToolParts(context.Background(), "get_artist_biography", nil)
+		Expect(err).To(MatchError(agents.ErrNotFound))
+		Expect(primaryTransport.sentToolCalls()).To(BeEmpty())
+	})
+
+	It("includes every distinct routed client in allClients", func() {
+		other := newClient(newFakeTransport(), restartPolicy{})
+		agent.routes = map[string]*client{
+			"get_artist_biography": agent.client,
+			"get_artist_url":       other,
+			"get_artist_awards":    other,
+		}
+		Expect(agent.allClients()).To(ConsistOf(agent.client, other))
+	})
+})
+
+var _ = Describe("withTimeoutHint", func() {
+	It("adds the remaining deadline as timeoutMs", func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+		args := map[string]any{"name": "The Artist"}
+
+		hinted, ok := withTimeoutHint(ctx, args).(map[string]any)
+		Expect(ok).To(BeTrue())
+		Expect(hinted["name"]).To(Equal("The Artist"))
+		Expect(hinted["timeoutMs"]).To(BeNumerically(">", 0))
+		Expect(hinted["timeoutMs"]).To(BeNumerically("<=", int64(60_000)))
+	})
+
+	It("does not mutate the original arguments", func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+		args := map[string]any{"name": "The Artist"}
+
+		withTimeoutHint(ctx, args)
+		Expect(args).ToNot(HaveKey("timeoutMs"))
+	})
+
+	It("passes the arguments through without a deadline", func() {
+		args := map[string]any{"name": "The Artist"}
+		passed, ok := withTimeoutHint(context.Background(), args).(map[string]any)
+		Expect(ok).To(BeTrue())
+		// The same map, not a copy: a write through the returned value must be
+		// visible in the original.
+		passed["probe"] = true
+		Expect(args).To(HaveKey("probe"))
+	})
+
+	It("leaves non-map arguments alone", func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+		Expect(withTimeoutHint(ctx, nil)).To(BeNil())
+	})
+})
@@ -0,0 +1,32 @@
+package mcp
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+
+	"github.com/navidrome/navidrome/conf"
+	"github.com/navidrome/navidrome/model/request"
+)
+
+// auditUser returns the authenticated username from the request context, used
+// for audit logging and metrics labels only - it is never forwarded to the MCP
+// server or upstream services. With MCP.AnonymizeUsers set, the username is
+// replaced by a stable hash, so abuse tracing still works without storing who
+// listens to what in logs and metrics.
+func auditUser(ctx context.Context) string {
+	username, ok := request.UsernameFrom(ctx)
+	if !ok {
+		if user, userOk := request.UserFrom(ctx); userOk {
+			username = user.UserName
+		}
+	}
+	if username == "" {
+		return ""
+	}
+	if conf.Server.MCP.AnonymizeUsers {
+		sum := sha256.Sum256([]byte(username))
+		return hex.EncodeToString(sum[:8])
+	}
+	return username
+}
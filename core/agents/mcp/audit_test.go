@@ -0,0 +1,87 @@
+package mcp
+
+import (
+	"context"
+
+	"github.com/navidrome/navidrome/conf"
+	"github.com/navidrome/navidrome/conf/configtest"
+	"github.com/navidrome/navidrome/log"
+	"github.com/navidrome/navidrome/model/request"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+)
+
+var _ = Describe("audit logging", func() {
+	var transport *fakeTransport
+	var agent *mcpAgent
+	var hook *test.Hook
+	var ctx context.Context
+
+	BeforeEach(func() {
+		DeferCleanup(configtest.SetupConfig())
+		transport = newFakeTransport()
+		transport.handle = func(method string) any {
+			if method == "tools/call" {
+				return map[string]any{"content": []map[string]any{{"type": "text", "text": "a bio"}}}
+			}
+			return map[string]any{}
+		}
+		agent = &mcpAgent{
+			client:  newClient(transport, restartPolicy{}),
+			health:  newToolHealth(),
+			pending: newRetryQueue(defaultRetryQueueSize),
+		}
+
+		var l *logrus.Logger
+		l, hook = test.NewNullLogger()
+		log.SetDefaultLogger(l)
+		log.SetLevel(log.LevelTrace)
+		DeferCleanup(func() {
+			log.SetDefaultLogger(logrus.New())
+			log.SetLevel(log.LevelError)
+		})
+
+		ctx = request.WithUsername(context.Background(), "johndoe")
+	})
+
+	loggedUsers := func() []any {
+		var users []any
+		for _, entry := range hook.AllEntries() {
+			if user, ok := entry.Data["user"]; ok {
+				users = append(users, user)
+			}
+		}
+		return users
+	}
+
+	It("logs the requesting user but never sends it to the server", func() {
+		_, err := agent.GetArtistBiography(ctx, "123", "The Beatles", "mbid-1")
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(loggedUsers()).To(ContainElement("johndoe"))
+		calls := transport.sentToolCalls()
+		Expect(calls).ToNot(BeEmpty())
+		for _, params := range calls {
+			Expect(string(params)).ToNot(ContainSubstring("johndoe"))
+		}
+	})
+
+	It("hashes the username when MCP.AnonymizeUsers is set", func() {
+		conf.Server.MCP.AnonymizeUsers = true
+		_, err := agent.GetArtistURL(ctx, "123", "The Beatles", "mbid-1")
+		Expect(err).ToNot(HaveOccurred())
+
+		users := loggedUsers()
+		Expect(users).ToNot(BeEmpty())
+		Expect(users).ToNot(ContainElement("johndoe"))
+		Expect(users[0]).To(HaveLen(16)) // Truncated sha256, hex-encoded
+	})
+
+	It("logs an empty user for unauthenticated contexts", func() {
+		_, err := agent.GetArtistBiography(context.Background(), "123", "The Beatles", "mbid-1")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(loggedUsers()).To(ContainElement(""))
+	})
+})
@@ -0,0 +1,189 @@
+package mcp
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/navidrome/navidrome/conf"
+	"github.com/navidrome/navidrome/log"
+)
+
+// Cache memoizes raw lookup payloads (fetched bodies, SPARQL responses) under
+// opaque string keys. Implementations must be safe for concurrent use, and a
+// miss is never an error: a broken backend behaves like an empty cache.
+type Cache interface {
+	Get(key string) ([]byte, bool)
+	Set(key string, value []byte)
+}
+
+// Cache modes selectable via MCP.CacheMode.
+const (
+	cacheModeMemory = "memory"
+	cacheModeDisk   = "disk"
+	cacheModeNone   = "none"
+)
+
+const (
+	// defaultCacheEntries bounds the in-memory cache; at a few KB per cached
+	// body this keeps the worst case in the low tens of MB.
+	defaultCacheEntries = 1024
+	defaultCacheTTL     = 24 * time.Hour
+)
+
+// newCacheFromConfig builds the cache selected by MCP.CacheMode: "memory" (the
+// default) keeps a bounded LRU in the process - the right fit for ephemeral
+// containers - "disk" persists entries under the data folder, and "none"
+// disables caching entirely.
+func newCacheFromConfig() Cache {
+	mode := strings.ToLower(conf.Server.MCP.CacheMode)
+	ttl := conf.Server.MCP.CacheTTL
+	if ttl <= 0 {
+		ttl = defaultCacheTTL
+	}
+	switch mode {
+	case "", cacheModeMemory:
+		return NewMemoryCache(defaultCacheEntries, ttl)
+	case cacheModeDisk:
+		return newDiskCache(filepath.Join(conf.Server.DataFolder, diskCacheDir), ttl)
+	case cacheModeNone:
+		return nil
+	default:
+		log.Warn("Unknown MCP.CacheMode, using the in-memory cache", "mode", mode)
+		return NewMemoryCache(defaultCacheEntries, ttl)
+	}
+}
+
+// MemoryCache is a bounded in-memory Cache: the least-recently-used entry is
+// evicted once maxEntries is reached, and entries expire after ttl. Its method
+// set deliberately matches what a SPARQL response cache needs, so the same
+// implementation serves both the host's fetch memoization and server-side use.
+type MemoryCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	ttl        time.Duration
+	entries    map[string]*list.Element
+	order      *list.List // Front is the most recently used
+	now        func() time.Time
+}
+
+type memoryCacheEntry struct {
+	key     string
+	value   []byte
+	expires time.Time
+}
+
+// NewMemoryCache returns a MemoryCache evicting beyond maxEntries and expiring
+// entries after ttl; non-positive arguments fall back to the defaults.
+func NewMemoryCache(maxEntries int, ttl time.Duration) *MemoryCache {
+	if maxEntries <= 0 {
+		maxEntries = defaultCacheEntries
+	}
+	if ttl <= 0 {
+		ttl = defaultCacheTTL
+	}
+	return &MemoryCache{
+		maxEntries: maxEntries,
+		ttl:        ttl,
+		entries:    make(map[string]*list.Element),
+		order:      list.New(),
+		now:        time.Now,
+	}
+}
+
+func (c *MemoryCache) Get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := el.Value.(*memoryCacheEntry)
+	if c.now().After(entry.expires) {
+		c.order.Remove(el)
+		delete(c.entries, key)
+		return nil, false
+	}
+	c.order.MoveToFront(el)
+	return entry.value, true
+}
+
+func (c *MemoryCache) Set(key string, value []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[key]; ok {
+		entry := el.Value.(*memoryCacheEntry)
+		entry.value = value
+		entry.expires = c.now().Add(c.ttl)
+		c.order.MoveToFront(el)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&memoryCacheEntry{key: key, value: value, expires: c.now().Add(c.ttl)})
+	if c.order.Len() > c.maxEntries {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*memoryCacheEntry).key)
+	}
+}
+
+// diskCacheDir is the subdirectory of the data folder holding persisted cache
+// entries, one file per key.
+const diskCacheDir = "mcp-cache"
+
+// diskCache persists each entry as a file named by the key's hash, expiring by
+// file age. Writes are best-effort, like the other disk-backed features: a
+// read-only data folder degrades to no caching, never to an error.
+type diskCache struct {
+	dir string
+	ttl time.Duration
+}
+
+// newDiskCache returns the persistent cache, or nil when its directory cannot
+// be created (the callers treat a nil Cache as caching disabled).
+func newDiskCache(dir string, ttl time.Duration) Cache {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		log.Warn("Could not create MCP cache directory - persistent caching disabled", "dir", dir, err)
+		return nil
+	}
+	return &diskCache{dir: dir, ttl: ttl}
+}
+
+func (c *diskCache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:16]))
+}
+
+func (c *diskCache) Get(key string) ([]byte, bool) {
+	path := c.path(key)
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, false
+	}
+	if time.Since(info.ModTime()) > c.ttl {
+		_ = os.Remove(path)
+		return nil, false
+	}
+	value, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	return value, true
+}
+
+func (c *diskCache) Set(key string, value []byte) {
+	if !persistence.enabled() {
+		return
+	}
+	err := os.WriteFile(c.path(key), value, 0600)
+	if persistence.disableIfReadOnly(err) {
+		return
+	}
+	if err != nil {
+		log.Warn("Could not persist MCP cache entry", "path", c.path(key), err)
+	}
+}
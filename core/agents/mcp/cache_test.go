@@ -0,0 +1,119 @@
+package mcp
+
+import (
+	"path/filepath"
+	"time"
+
+	"github.com/navidrome/navidrome/conf"
+	"github.com/navidrome/navidrome/conf/configtest"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// behavesLikeACache asserts the Cache contract every implementation must
+// honor: get/set round-trips, overwrites, and misses for unknown keys.
+func behavesLikeACache(newCache func() Cache) {
+	var cache Cache
+
+	BeforeEach(func() {
+		cache = newCache()
+	})
+
+	It("misses for unknown keys", func() {
+		_, ok := cache.Get("unknown")
+		Expect(ok).To(BeFalse())
+	})
+
+	It("round-trips entries", func() {
+		cache.Set("key", []byte("a body"))
+		value, ok := cache.Get("key")
+		Expect(ok).To(BeTrue())
+		Expect(string(value)).To(Equal("a body"))
+	})
+
+	It("overwrites existing entries", func() {
+		cache.Set("key", []byte("old"))
+		cache.Set("key", []byte("new"))
+		value, ok := cache.Get("key")
+		Expect(ok).To(BeTrue())
+		Expect(string(value)).To(Equal("new"))
+	})
+}
+
+var _ = Describe("MemoryCache", func() {
+	behavesLikeACache(func() Cache { return NewMemoryCache(8, time.Minute) })
+
+	It("evicts the least recently used entry beyond maxEntries", func() {
+		cache := NewMemoryCache(2, time.Minute)
+		cache.Set("a", []byte("1"))
+		cache.Set("b", []byte("2"))
+		// Touch "a" so "b" becomes the eviction candidate.
+		_, _ = cache.Get("a")
+		cache.Set("c", []byte("3"))
+
+		_, ok := cache.Get("b")
+		Expect(ok).To(BeFalse())
+		_, ok = cache.Get("a")
+		Expect(ok).To(BeTrue())
+		_, ok = cache.Get("c")
+		Expect(ok).To(BeTrue())
+	})
+
+	It("expires entries after the TTL", func() {
+		cache := NewMemoryCache(8, time.Minute)
+		now := time.Now()
+		cache.now = func() time.Time { return now }
+		cache.Set("key", []byte("a body"))
+
+		now = now.Add(2 * time.Minute)
+		_, ok := cache.Get("key")
+		Expect(ok).To(BeFalse())
+	})
+})
+
+var _ = Describe("diskCache", func() {
+	BeforeEach(func() {
+		DeferCleanup(configtest.SetupConfig())
+		conf.Server.DataFolder = GinkgoT().TempDir()
+	})
+
+	behavesLikeACache(func() Cache {
+		return newDiskCache(filepath.Join(conf.Server.DataFolder, diskCacheDir), time.Minute)
+	})
+
+	It("survives reconstruction, reading entries persisted by an earlier instance", func() {
+		dir := filepath.Join(conf.Server.DataFolder, diskCacheDir)
+		newDiskCache(dir, time.Minute).Set("key", []byte("persisted"))
+
+		value, ok := newDiskCache(dir, time.Minute).Get("key")
+		Expect(ok).To(BeTrue())
+		Expect(string(value)).To(Equal("persisted"))
+	})
+})
+
+var _ = Describe("newCacheFromConfig", func() {
+	BeforeEach(func() {
+		DeferCleanup(configtest.SetupConfig())
+		conf.Server.DataFolder = GinkgoT().TempDir()
+	})
+
+	It("defaults to the in-memory cache", func() {
+		conf.Server.MCP.CacheMode = ""
+		Expect(newCacheFromConfig()).To(BeAssignableToTypeOf(&MemoryCache{}))
+	})
+
+	It("selects the persistent cache for disk mode", func() {
+		conf.Server.MCP.CacheMode = "disk"
+		Expect(newCacheFromConfig()).To(BeAssignableToTypeOf(&diskCache{}))
+	})
+
+	It("disables caching for none", func() {
+		conf.Server.MCP.CacheMode = "none"
+		Expect(newCacheFromConfig()).To(BeNil())
+	})
+
+	It("falls back to the in-memory cache for unknown modes", func() {
+		conf.Server.MCP.CacheMode = "redis"
+		Expect(newCacheFromConfig()).To(BeAssignableToTypeOf(&MemoryCache{}))
+	})
+})
@@ -0,0 +1,726 @@
+package mcp
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/navidrome/navidrome/conf"
+	"github.com/navidrome/navidrome/consts"
+	"github.com/navidrome/navidrome/log"
+)
+
+const protocolVersion = "2024-11-05"
+
+// Default budgets for bringing a server up. Startup (spawn, or wasm compile
+// plus instantiation - easily tens of seconds on small ARM boxes) is budgeted
+// separately from the JSON-RPC initialize exchange, so a slow cold start does
+// not burn the compile work, and neither budget extends per-call timeouts.
+// The initialize budget depends on the execution model: a wasm module may
+// still be warming up (JIT, first GC) when the handshake starts, while a
+// native process is immediately at full speed.
+const (
+	defaultStartupTimeout    = 60 * time.Second
+	defaultInitTimeoutNative = 10 * time.Second
+	defaultInitTimeoutWasm   = 30 * time.Second
+)
+
+// defaultMaxStartAttempts caps consecutive failed starts before the client
+// disables itself, so a fundamentally broken server (bad binary, missing
+// dependency) does not crash-loop forever. MCP.MaxRestartAttempts overrides
+// it: a negative value disables the cap, zero (unset) picks this default.
+const defaultMaxStartAttempts = 5
+
+// Restart backoff: after a failed start, further attempts are refused until a
+// growing interval has elapsed, so a server that crashes on startup is not
+// re-spawned by every metadata request in a scan. A successful initialize
+// resets the interval.
+const (
+	startBackoffBase = time.Second
+	startBackoffMax  = 5 * time.Minute
+)
+
+var errTransportClosed = errors.New("mcp: transport closed")
+
+// errServerDisabled is returned once the start-failure cap is hit, until a
+// config reload or an explicit re-enable.
+var errServerDisabled = errors.New("mcp: server disabled after repeated start failures")
+
+// errStartBackoff is returned while the restart backoff is in effect, without
+// touching the transport at all.
+var errStartBackoff = errors.New("mcp: server start backed off after a failed start")
+
+// errCallTimeout is returned when a tool call exceeds MCP.CallTimeout, so
+// callers (e.g. the scanner) can tell a slow upstream from a broken one and
+// move on.
+var errCallTimeout = errors.New("mcp: tool call timed out")
+
+// jsonRPCMessage is the wire representation of a JSON-RPC 2.0 frame, covering
+// requests, responses and notifications.
+type jsonRPCMessage struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      *int64          `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *jsonRPCError   `json:"error,omitempty"`
+}
+
+type jsonRPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *jsonRPCError) Error() string {
+	return fmt.Sprintf("mcp: server error %d: %s", e.Code, e.Message)
+}
+
+// toolContent is a single content item in a tool response. MCP servers may
+// return multiple items, and each can be text, an image, or an embedded
+// resource.
+type toolContent struct {
+	Type     string            `json:"type"`
+	Text     string            `json:"text,omitempty"`
+	Data     string            `json:"data,omitempty"` // Base64 payload for images
+	MimeType string            `json:"mimeType,omitempty"`
+	Resource *embeddedResource `json:"resource,omitempty"`
+}
+
+// embeddedResource is the resource payload of a "resource" content item.
+type embeddedResource struct {
+	URI      string `json:"uri"`
+	MimeType string `json:"mimeType,omitempty"`
+	Text     string `json:"text,omitempty"`
+}
+
+type toolResponse struct {
+	Content []toolContent              `json:"content"`
+	IsError bool                       `json:"isError,omitempty"`
+	Meta    map[string]json.RawMessage `json:"_meta,omitempty"`
+}
+
+// client implements the client side of the MCP stdio protocol: newline-delimited
+// JSON-RPC 2.0 frames, with an initialize handshake before any tool calls.
+type client struct {
+	transport transport
+	policy    restartPolicy
+
+	startupTimeout     time.Duration
+	initTimeout        time.Duration
+	callTimeout        time.Duration
+	maxStartAttempts   int
+	healthCheckOnReuse bool
+	healthCheckTimeout time.Duration
+
+	// onReady, when set, is called in the background after the first
+	// successful initialize handshake (restarts do not trigger it again).
+	onReady   func()
+	readyOnce sync.Once
+
+	// startMu serializes the whole start-and-handshake sequence in ensureReady:
+	// a caller arriving while a start is in flight waits for its outcome
+	// instead of racing a second transport.Start and recording the inevitable
+	// "already running" error as a spurious start failure.
+	startMu sync.Mutex
+
+	mu    sync.Mutex
+	stdin io.Writer
+	// generation identifies the current transport session; every teardown
+	// bumps it, making teardown callbacks left over from a previous session
+	// no-ops (see resetGen).
+	generation       int
+	pending          map[int64]chan *jsonRPCMessage
+	nextID           atomic.Int64
+	ready            bool
+	calls            int
+	startedAt        time.Time
+	idleTimer        *time.Timer
+	startFailures    int
+	lastStartFailure time.Time
+	lastStartError   error
+	disabled         bool
+
+	orphanCount       int
+	orphanWindowStart time.Time
+
+	// knownTools, when non-empty, is the server's tool catalog: seeded from
+	// the hash-keyed persisted cache at construction and refreshed after the
+	// first successful initialize. Empty means unknown, which disables gating.
+	knownTools []string
+}
+
+func newClient(t transport, policy restartPolicy) *client {
+	return &client{
+		transport:          t,
+		policy:             policy,
+		startupTimeout:     cmp.Or(conf.Server.MCP.StartupTimeout, defaultStartupTimeout),
+		initTimeout:        initTimeoutFor(t),
+		callTimeout:        conf.Server.MCP.CallTimeout,
+		maxStartAttempts:   cmp.Or(conf.Server.MCP.MaxRestartAttempts, defaultMaxStartAttempts),
+		healthCheckOnReuse: conf.Server.MCP.HealthCheckOnReuse,
+		healthCheckTimeout: defaultHealthCheckTimeout,
+		pending:            make(map[int64]chan *jsonRPCMessage),
+	}
+}
+
+// initTimeoutFor resolves the initialize budget for a transport: an explicit
+// per-transport setting wins, then the global MCP.InitTimeout, then the
+// default suited to the execution model.
+func initTimeoutFor(t transport) time.Duration {
+	if t.kind() == "wasm" {
+		return cmp.Or(conf.Server.MCP.InitTimeoutWasm, conf.Server.MCP.InitTimeout, defaultInitTimeoutWasm)
+	}
+	return cmp.Or(conf.Server.MCP.InitTimeoutNative, conf.Server.MCP.InitTimeout, defaultInitTimeoutNative)
+}
+
+// ensureReady starts the transport and performs the MCP initialize handshake,
+// if that has not happened yet.
+func (c *client) ensureReady(ctx context.Context) error {
+	c.startMu.Lock()
+	defer c.startMu.Unlock()
+	c.mu.Lock()
+	if c.ready {
+		c.mu.Unlock()
+		return nil
+	}
+	if c.disabled {
+		c.mu.Unlock()
+		return errServerDisabled
+	}
+	if wait := c.startBackoffRemaining(); wait > 0 {
+		failures, lastErr := c.startFailures, c.lastStartError
+		c.mu.Unlock()
+		// Rejected instantly, with the cached cause: the transport is not
+		// touched at all while the backoff holds.
+		log.Debug("MCP server start rejected by backoff", "failures", failures,
+			"retryIn", wait.Round(time.Second), lastErr)
+		return fmt.Errorf("%w (next attempt in %s): %w", errStartBackoff, wait.Round(time.Second), lastErr)
+	}
+	// The startup and initialize budgets are detached from the caller's
+	// context: a short per-call deadline must not abort an otherwise healthy
+	// (but slow) cold start.
+	startCtx, cancelStart := context.WithTimeout(context.WithoutCancel(ctx), c.startupTimeout)
+	stdin, stdout, err := c.transport.Start(startCtx)
+	cancelStart()
+	if err != nil {
+		c.mu.Unlock()
+		err = fmt.Errorf("mcp: starting transport: %w", err)
+		c.recordStartFailure(err)
+		return err
+	}
+	gen := c.generation
+	c.stdin = newWriteGuard(stdin, func() { c.resetGen(gen) })
+	c.mu.Unlock()
+
+	go c.readLoop(stdout, gen)
+
+	initCtx, cancelInit := context.WithTimeout(context.WithoutCancel(ctx), c.initTimeout)
+	defer cancelInit()
+
+	params := map[string]any{
+		"protocolVersion": protocolVersion,
+		"clientInfo": map[string]string{
+			"name":    consts.AppName,
+			"version": consts.Version,
+		},
+		"capabilities": map[string]any{},
+	}
+	if _, err := c.call(initCtx, "initialize", params); err != nil {
+		c.resetGen(gen)
+		err = fmt.Errorf("mcp: initialize handshake: %w", err)
+		c.recordStartFailure(err)
+		return err
+	}
+	if err := c.notify("notifications/initialized", nil); err != nil {
+		c.resetGen(gen)
+		err = fmt.Errorf("mcp: initialized notification: %w", err)
+		c.recordStartFailure(err)
+		return err
+	}
+	c.mu.Lock()
+	if c.generation != gen {
+		// The session was torn down while the handshake was still in flight
+		// (e.g. an application shutdown); do not mark its replacement ready.
+		c.mu.Unlock()
+		return errTransportClosed
+	}
+	c.ready = true
+	c.startFailures = 0
+	c.lastStartFailure = time.Time{}
+	c.lastStartError = nil
+	c.calls = 0
+	c.startedAt = time.Now()
+	if c.policy.IdleTimeout > 0 {
+		c.idleTimer = time.AfterFunc(c.policy.IdleTimeout, func() {
+			log.Debug("Shutting down idle MCP server", "idleTimeout", c.policy.IdleTimeout)
+			c.resetGen(gen)
+		})
+	}
+	c.mu.Unlock()
+	if c.onReady != nil {
+		c.readyOnce.Do(func() { go c.onReady() })
+	}
+	return nil
+}
+
+// setKnownTools replaces the known tool catalog, kept sorted for stable
+// comparison and status reporting.
+func (c *client) setKnownTools(tools []string) {
+	sorted := slices.Clone(tools)
+	slices.Sort(sorted)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.knownTools = sorted
+}
+
+// knownToolList returns the known tool catalog, or nil when never discovered.
+func (c *client) knownToolList() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return slices.Clone(c.knownTools)
+}
+
+// supportsTool reports whether the server offers the named tool. An unknown
+// catalog supports everything: gating must never reject a call the server
+// could have answered.
+func (c *client) supportsTool(name string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.knownTools) == 0 || slices.Contains(c.knownTools, name)
+}
+
+// listTools asks the server for its tool catalog, sorted by name.
+func (c *client) listTools(ctx context.Context) ([]string, error) {
+	if err := c.ensureReady(ctx); err != nil {
+		return nil, err
+	}
+	result, err := c.call(ctx, "tools/list", nil)
+	if err != nil {
+		return nil, err
+	}
+	var list struct {
+		Tools []struct {
+			Name string `json:"name"`
+		} `json:"tools"`
+	}
+	if err := json.Unmarshal(result, &list); err != nil {
+		return nil, fmt.Errorf("mcp: decoding tool list: %w", err)
+	}
+	names := make([]string, 0, len(list.Tools))
+	for _, t := range list.Tools {
+		names = append(names, t.Name)
+	}
+	slices.Sort(names)
+	return names, nil
+}
+
+// callTool invokes a tool on the server and returns its response. With
+// MCP.CallTimeout set, each call is additionally bounded by that budget; a
+// caller deadline that is already shorter simply wins, as context.WithTimeout
+// never extends the parent.
+func (c *client) callTool(ctx context.Context, name string, args any) (*toolResponse, error) {
+	c.mu.Lock()
+	wasReady := c.ready
+	c.mu.Unlock()
+	if err := c.ensureReady(ctx); err != nil {
+		return nil, err
+	}
+	if c.healthCheckOnReuse && wasReady {
+		// The server was started by an earlier call; make sure it still
+		// answers before trusting it with this one.
+		if err := c.verifyAlive(ctx); err != nil {
+			return nil, err
+		}
+	}
+	callerCtx := ctx
+	if c.callTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.callTimeout)
+		defer cancel()
+	}
+	params := map[string]any{"name": name, "arguments": args}
+	result, err := c.call(ctx, "tools/call", params)
+	if isCommError(err) {
+		// The server died under this call (broken pipe, EOF). One transparent
+		// restart-and-retry turns a user-visible failure into a slow success;
+		// exactly one, because a server that dies again is genuinely broken.
+		log.Debug("MCP server died mid-call, restarting and retrying once", "tool", name, err)
+		c.reset()
+		if err := c.ensureReady(ctx); err != nil {
+			return nil, err
+		}
+		result, err = c.call(ctx, "tools/call", params)
+	}
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) && callerCtx.Err() == nil {
+			// Our budget expired, not the caller's: surface the typed timeout.
+			return nil, fmt.Errorf("%w after %s (tool %s)", errCallTimeout, c.callTimeout, name)
+		}
+		return nil, err
+	}
+	var resp toolResponse
+	if err := json.Unmarshal(result, &resp); err != nil {
+		return nil, fmt.Errorf("mcp: decoding tool response: %w", err)
+	}
+	c.afterCall()
+	return &resp, nil
+}
+
+// defaultHealthCheckTimeout bounds the pre-call liveness probe; a healthy
+// server answers tools/list from memory, so anything slower is as good as dead.
+const defaultHealthCheckTimeout = 2 * time.Second
+
+// verifyAlive probes a server that is believed ready with a lightweight
+// tools/list. A process can be half-dead - pipe still open, but no longer
+// responding - which the ready flag cannot see; when the probe fails, the
+// server is torn down and the handshake re-run once, so the caller gets a
+// fresh instance instead of a hung call. Opt-in via MCP.HealthCheckOnReuse.
+func (c *client) verifyAlive(ctx context.Context) error {
+	probeCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), c.healthCheckTimeout)
+	defer cancel()
+	_, err := c.call(probeCtx, "tools/list", nil)
+	if err == nil {
+		return nil
+	}
+	log.Debug("MCP server failed the pre-call health check, restarting it", err)
+	c.reset()
+	return c.ensureReady(ctx)
+}
+
+// isCommError reports whether a call failed because the transport died
+// underneath it - a closed pipe or EOF - as opposed to the server answering
+// with an error or the context expiring.
+func isCommError(err error) bool {
+	return errors.Is(err, errTransportClosed) || errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrClosedPipe) || errors.Is(err, syscall.EPIPE)
+}
+
+// afterCall applies the restart policy after a successful tool call: it
+// resets the idle timer and recycles the server instance when it exceeds its
+// call budget, age, or memory limit.
+func (c *client) afterCall() {
+	c.mu.Lock()
+	c.calls++
+	calls := c.calls
+	age := time.Since(c.startedAt)
+	if c.idleTimer != nil {
+		c.idleTimer.Reset(c.policy.IdleTimeout)
+	}
+	c.mu.Unlock()
+
+	var reason string
+	switch {
+	case c.policy.MaxCallsPerInstance > 0 && calls >= c.policy.MaxCallsPerInstance:
+		reason = "max calls per instance reached"
+	case c.policy.MaxInstanceAge > 0 && age >= c.policy.MaxInstanceAge:
+		reason = "max instance age reached"
+	case c.policy.RecycleOnMemoryMB > 0 && c.transport.memoryMB() >= c.policy.RecycleOnMemoryMB:
+		reason = "memory limit reached"
+	default:
+		return
+	}
+	log.Debug("Recycling MCP server instance", "reason", reason, "calls", calls, "age", age)
+	c.reset()
+}
+
+func (c *client) call(ctx context.Context, method string, params any) (json.RawMessage, error) {
+	id := c.nextID.Add(1)
+	ch := make(chan *jsonRPCMessage, 1)
+	c.mu.Lock()
+	c.pending[id] = ch
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		delete(c.pending, id)
+		c.mu.Unlock()
+	}()
+
+	if err := c.send(&jsonRPCMessage{JSONRPC: "2.0", ID: &id, Method: method, Params: mustMarshal(params)}); err != nil {
+		return nil, err
+	}
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case msg, ok := <-ch:
+		if !ok {
+			return nil, errTransportClosed
+		}
+		if msg.Error != nil {
+			return nil, msg.Error
+		}
+		return msg.Result, nil
+	}
+}
+
+func (c *client) notify(method string, params any) error {
+	return c.send(&jsonRPCMessage{JSONRPC: "2.0", Method: method, Params: mustMarshal(params)})
+}
+
+func (c *client) send(msg *jsonRPCMessage) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	// The write happens outside c.mu: the guard serializes writers itself, and
+	// a broken-pipe detection must be able to reset the client.
+	c.mu.Lock()
+	stdin := c.stdin
+	c.mu.Unlock()
+	if stdin == nil {
+		return errTransportClosed
+	}
+	_, err = stdin.Write(append(data, '\n'))
+	return err
+}
+
+// writeGuard serializes writes to the server's stdin and detects a half-closed
+// pipe on the spot: the first EPIPE (or closed-pipe) failure triggers the
+// teardown callback immediately, instead of waiting for the read loop to see
+// EOF - which can lag when the child is a zombie behind a wrapper script.
+type writeGuard struct {
+	mu       sync.Mutex
+	w        io.Writer
+	broken   bool
+	onBroken func()
+}
+
+func newWriteGuard(w io.Writer, onBroken func()) *writeGuard {
+	return &writeGuard{w: w, onBroken: onBroken}
+}
+
+func (g *writeGuard) Write(p []byte) (int, error) {
+	g.mu.Lock()
+	if g.broken {
+		g.mu.Unlock()
+		return 0, errTransportClosed
+	}
+	n, err := g.w.Write(p)
+	brokenNow := err != nil && isBrokenPipe(err)
+	if brokenNow {
+		g.broken = true
+	}
+	g.mu.Unlock()
+	if brokenNow {
+		log.Warn("MCP server closed its stdin - resetting transport now", err)
+		if g.onBroken != nil {
+			g.onBroken()
+		}
+	}
+	return n, err
+}
+
+func isBrokenPipe(err error) bool {
+	return errors.Is(err, syscall.EPIPE) || errors.Is(err, io.ErrClosedPipe)
+}
+
+// readLoop dispatches responses coming from the server to their pending calls.
+// It exits when the framed stream ends, which also resets the client - but
+// only its own session (gen): a loop draining the pipe of an already-replaced
+// transport must not tear down the replacement.
+func (c *client) readLoop(stdout *framingReader, gen int) {
+	for {
+		frame, err := stdout.nextFrame()
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				log.Warn("MCP transport read failed", err)
+			}
+			c.resetGen(gen)
+			return
+		}
+		var msg jsonRPCMessage
+		if err := json.Unmarshal(frame, &msg); err != nil {
+			log.Trace("Discarding malformed MCP frame", err)
+			continue
+		}
+		if msg.ID == nil {
+			continue // Server notification, nothing to dispatch
+		}
+		c.mu.Lock()
+		ch := c.pending[*msg.ID]
+		c.mu.Unlock()
+		if ch == nil {
+			// A response matching no outstanding request: a late answer to a
+			// timed-out call, or interleaved-write corruption on the server.
+			if msg.Method == "" && c.noteOrphanResponse(*msg.ID, gen) {
+				return
+			}
+			continue
+		}
+		ch <- &msg
+	}
+}
+
+// Orphan-response accounting: a response whose ID matches no outstanding
+// request is tolerated in isolation, but a spike within the window points at
+// interleaved stdout writes on the server, and the transport is reset rather
+// than risk delivering corrupt frames to callers.
+const (
+	orphanResetThreshold = 5
+	orphanWindow         = time.Minute
+)
+
+// noteOrphanResponse counts an orphan response and reports whether the spike
+// threshold was hit, in which case the transport has been reset. Only the
+// window's first orphan is logged at Warn; the rest go to Trace.
+func (c *client) noteOrphanResponse(id int64, gen int) bool {
+	c.mu.Lock()
+	now := time.Now()
+	if now.Sub(c.orphanWindowStart) > orphanWindow {
+		c.orphanWindowStart = now
+		c.orphanCount = 0
+	}
+	c.orphanCount++
+	count := c.orphanCount
+	c.mu.Unlock()
+	getMCPMetrics().orphanResponses.Inc()
+	if count == 1 {
+		log.Warn("MCP server sent a response matching no outstanding request", "id", id)
+	} else {
+		log.Trace("MCP server sent a response matching no outstanding request", "id", id, "count", count)
+	}
+	if count >= orphanResetThreshold {
+		log.Warn("Orphan MCP response spike - resetting transport", "count", count, "window", orphanWindow)
+		c.resetGen(gen)
+		return true
+	}
+	return false
+}
+
+// recordStartFailure counts consecutive failed starts (and failed initialize
+// handshakes), keeps the cause for the backoff rejections, and disables the
+// client once the cap is hit. Successful starts reset the count; a disabled
+// client stays down until reenable is called. The backoff applies even with
+// the cap off: an uncapped client may retry forever, just not in a tight loop.
+func (c *client) recordStartFailure(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.startFailures++
+	c.lastStartFailure = time.Now()
+	c.lastStartError = err
+	if c.maxStartAttempts <= 0 {
+		return
+	}
+	if c.startFailures >= c.maxStartAttempts && !c.disabled {
+		c.disabled = true
+		setAgentState(StateFailed, fmt.Sprintf("server disabled after %d failed start attempts", c.startFailures))
+		log.Error("Disabling MCP server after repeated start failures", "attempts", c.startFailures,
+			"maxRestartAttempts", c.maxStartAttempts)
+	}
+}
+
+// startBackoffRemaining reports how long the restart backoff still holds the
+// next start attempt off, zero when a start may proceed. Callers hold c.mu.
+func (c *client) startBackoffRemaining() time.Duration {
+	if c.startFailures == 0 {
+		return 0
+	}
+	return startBackoff(c.startFailures) - time.Since(c.lastStartFailure)
+}
+
+// startBackoff returns the interval after the given number of consecutive
+// failed starts: the base doubled per failure, capped at startBackoffMax.
+func startBackoff(failures int) time.Duration {
+	d := startBackoffBase
+	for i := 1; i < failures && d < startBackoffMax; i++ {
+		d *= 2
+	}
+	return min(d, startBackoffMax)
+}
+
+// reenable clears the permanently-failed state, so the next call attempts a
+// fresh start.
+func (c *client) reenable() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.disabled {
+		log.Info("Re-enabling MCP server", "previousFailures", c.startFailures)
+		setAgentState(StateReady, "")
+	}
+	c.disabled = false
+	c.startFailures = 0
+}
+
+// disabledState reports whether the client disabled itself, and how many
+// consecutive starts have failed.
+func (c *client) disabledState() (bool, int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.disabled, c.startFailures
+}
+
+// reset tears down the current transport session and fails all in-flight
+// calls, so the next call starts a fresh server.
+func (c *client) reset() {
+	c.mu.Lock()
+	gen := c.generation
+	c.mu.Unlock()
+	c.resetGen(gen)
+}
+
+// resetGen is reset scoped to a single transport session: it acts only while
+// gen is still the live session. A read loop or write guard left over from a
+// session that was already torn down can wake up on its dead pipe long after
+// a replacement started, and must not kill that replacement mid-handshake.
+func (c *client) resetGen(gen int) {
+	if !c.dropSession(gen) {
+		return
+	}
+	_ = c.transport.Close()
+}
+
+// shutdown is reset for application exit: transports that know how to stop
+// their server gracefully (a native child gets SIGTERM and a grace period)
+// do so instead of the instant kill the restart path uses.
+func (c *client) shutdown() {
+	c.mu.Lock()
+	gen := c.generation
+	c.mu.Unlock()
+	if !c.dropSession(gen) {
+		return
+	}
+	if t, ok := c.transport.(interface{ shutdown() error }); ok {
+		_ = t.shutdown()
+		return
+	}
+	_ = c.transport.Close()
+}
+
+// dropSession fails all in-flight calls and forgets session gen's transport
+// wiring, bumping the generation so later teardown callbacks from that
+// session are no-ops. It reports whether the caller won the teardown and now
+// owns closing the transport; false means someone else already did.
+func (c *client) dropSession(gen int) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if gen != c.generation {
+		return false
+	}
+	c.generation++
+	if c.idleTimer != nil {
+		c.idleTimer.Stop()
+		c.idleTimer = nil
+	}
+	for id, ch := range c.pending {
+		close(ch)
+		delete(c.pending, id)
+	}
+	c.stdin = nil
+	c.ready = false
+	return true
+}
+
+func mustMarshal(v any) json.RawMessage {
+	if v == nil {
+		return nil
+	}
+	data, _ := json.Marshal(v)
+	return data
+}
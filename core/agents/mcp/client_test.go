@@ -0,0 +1,600 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/navidrome/navidrome/conf"
+	"github.com/navidrome/navidrome/conf/configtest"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// fakeTransport runs an in-memory MCP server over io.Pipes, with an optional
+// artificial startup delay, to exercise the client without real processes.
+type fakeTransport struct {
+	startDelay time.Duration
+	// handle returns the result for a request, or nil to not answer at all
+	handle func(method string) any
+	// respondID, when set, rewrites the ID a response is sent under, to
+	// simulate a server answering with corrupt or mismatched IDs
+	respondID func(method string, id int64) int64
+	// kindName overrides the reported execution model ("native" by default)
+	kindName string
+	stats    transportStats
+	closed   chan struct{}
+	// serverStdin is the server's read end of the client's stdin pipe; tests
+	// close it to simulate a child that half-closed its pipes.
+	serverStdin *io.PipeReader
+
+	mu        sync.Mutex
+	toolCalls []json.RawMessage
+	// pipes are the ends created by the last Start; Close closes them so the
+	// read loops parked on them (serve, the client's readLoop) exit instead
+	// of leaking.
+	pipes []io.Closer
+}
+
+func newFakeTransport() *fakeTransport {
+	return &fakeTransport{
+		closed: make(chan struct{}),
+		handle: func(method string) any { return map[string]any{} },
+	}
+}
+
+func (t *fakeTransport) Start(ctx context.Context) (io.WriteCloser, *framingReader, error) {
+	select {
+	case <-time.After(t.startDelay):
+	case <-ctx.Done():
+		return nil, nil, ctx.Err()
+	}
+	serverIn, clientOut := io.Pipe()
+	clientIn, serverOut := io.Pipe()
+	t.serverStdin = serverIn
+	t.mu.Lock()
+	t.pipes = []io.Closer{serverIn, clientOut, clientIn, serverOut}
+	t.mu.Unlock()
+	go t.serve(serverIn, serverOut)
+	return clientOut, newFramingReader(clientIn, &t.stats), nil
+}
+
+func (t *fakeTransport) serve(in io.Reader, out io.WriteCloser) {
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		var msg jsonRPCMessage
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil || msg.ID == nil {
+			continue
+		}
+		if msg.Method == "tools/call" {
+			t.mu.Lock()
+			t.toolCalls = append(t.toolCalls, msg.Params)
+			t.mu.Unlock()
+		}
+		result := t.handle(msg.Method)
+		if result == nil {
+			continue
+		}
+		id := *msg.ID
+		if t.respondID != nil {
+			id = t.respondID(msg.Method, id)
+		}
+		resp, _ := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": id, "result": result})
+		_, _ = out.Write(append(resp, '\n'))
+	}
+}
+
+func (t *fakeTransport) Close() error {
+	t.mu.Lock()
+	pipes := t.pipes
+	t.pipes = nil
+	t.mu.Unlock()
+	for _, p := range pipes {
+		_ = p.Close()
+	}
+	select {
+	case <-t.closed:
+	default:
+		close(t.closed)
+	}
+	return nil
+}
+
+func (t *fakeTransport) Stats() *transportStats { return &t.stats }
+
+// sentToolCalls returns the raw params of every tools/call request received.
+func (t *fakeTransport) sentToolCalls() []json.RawMessage {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]json.RawMessage(nil), t.toolCalls...)
+}
+
+func (t *fakeTransport) memoryMB() int { return 0 }
+
+func (t *fakeTransport) kind() string {
+	if t.kindName != "" {
+		return t.kindName
+	}
+	return "native"
+}
+
+// brokenTransport fails every Start, simulating a fundamentally broken server
+// (bad binary, missing dependency).
+type brokenTransport struct {
+	starts int
+	stats  transportStats
+}
+
+func (t *brokenTransport) Start(context.Context) (io.WriteCloser, *framingReader, error) {
+	t.starts++
+	return nil, nil, errors.New("exec: no such file")
+}
+
+func (t *brokenTransport) Close() error { return nil }
+
+func (t *brokenTransport) Stats() *transportStats { return &t.stats }
+
+func (t *brokenTransport) memoryMB() int { return 0 }
+
+func (t *brokenTransport) kind() string { return "native" }
+
+var _ = Describe("client start-failure cap", func() {
+	var transport *brokenTransport
+	var c *client
+
+	BeforeEach(func() {
+		transport = &brokenTransport{}
+		c = newClient(transport, restartPolicy{})
+		c.maxStartAttempts = 3
+	})
+
+	// The restart backoff would hold off the immediate retries these tests
+	// make; they exercise the failure cap, so rewind it between attempts.
+	expireBackoff := func() {
+		c.mu.Lock()
+		c.lastStartFailure = time.Now().Add(-startBackoffMax)
+		c.mu.Unlock()
+	}
+
+	It("disables itself after the configured number of failed starts", func() {
+		for range 3 {
+			err := c.ensureReady(context.Background())
+			Expect(err).To(MatchError(ContainSubstring("starting transport")))
+			expireBackoff()
+		}
+		Expect(transport.starts).To(Equal(3))
+
+		// Once disabled, further calls fail fast without touching the transport.
+		err := c.ensureReady(context.Background())
+		Expect(err).To(MatchError(errServerDisabled))
+		Expect(transport.starts).To(Equal(3))
+
+		disabled, failures := c.disabledState()
+		Expect(disabled).To(BeTrue())
+		Expect(failures).To(Equal(3))
+	})
+
+	It("tries again after an explicit re-enable", func() {
+		for range 3 {
+			_ = c.ensureReady(context.Background())
+			expireBackoff()
+		}
+		Expect(c.ensureReady(context.Background())).To(MatchError(errServerDisabled))
+
+		c.reenable()
+		err := c.ensureReady(context.Background())
+		Expect(err).To(MatchError(ContainSubstring("starting transport")))
+		Expect(transport.starts).To(Equal(4))
+	})
+
+	It("never disables itself when the cap is off", func() {
+		c.maxStartAttempts = 0
+		for range 10 {
+			err := c.ensureReady(context.Background())
+			Expect(err).To(MatchError(ContainSubstring("starting transport")))
+			expireBackoff()
+		}
+		Expect(transport.starts).To(Equal(10))
+	})
+})
+
+var _ = Describe("client restart backoff", func() {
+	var transport *brokenTransport
+	var c *client
+
+	BeforeEach(func() {
+		transport = &brokenTransport{}
+		c = newClient(transport, restartPolicy{})
+		c.maxStartAttempts = 0
+	})
+
+	rewind := func(d time.Duration) {
+		c.mu.Lock()
+		c.lastStartFailure = c.lastStartFailure.Add(-d)
+		c.mu.Unlock()
+	}
+
+	It("refuses an immediate retry after a failed start", func() {
+		Expect(c.ensureReady(context.Background())).To(MatchError(ContainSubstring("starting transport")))
+		Expect(transport.starts).To(Equal(1))
+
+		err := c.ensureReady(context.Background())
+		Expect(err).To(MatchError(errStartBackoff))
+		Expect(err.Error()).To(ContainSubstring("next attempt in"))
+		Expect(transport.starts).To(Equal(1))
+	})
+
+	It("tries again once the interval has elapsed, then backs off longer", func() {
+		_ = c.ensureReady(context.Background())
+		rewind(startBackoffBase)
+		Expect(c.ensureReady(context.Background())).To(MatchError(ContainSubstring("starting transport")))
+		Expect(transport.starts).To(Equal(2))
+
+		// Two consecutive failures double the interval: the base is no longer
+		// enough.
+		rewind(startBackoffBase)
+		Expect(c.ensureReady(context.Background())).To(MatchError(errStartBackoff))
+		rewind(startBackoffBase)
+		Expect(c.ensureReady(context.Background())).To(MatchError(ContainSubstring("starting transport")))
+		Expect(transport.starts).To(Equal(3))
+	})
+
+	It("carries the cached failure cause in the instant rejection", func() {
+		Expect(c.ensureReady(context.Background())).To(MatchError(ContainSubstring("no such file")))
+
+		err := c.ensureReady(context.Background())
+		Expect(err).To(MatchError(errStartBackoff))
+		Expect(err).To(MatchError(ContainSubstring("no such file")))
+		Expect(transport.starts).To(Equal(1))
+	})
+
+	It("grows the interval up to the cap", func() {
+		Expect(startBackoff(1)).To(Equal(startBackoffBase))
+		Expect(startBackoff(2)).To(Equal(2 * startBackoffBase))
+		Expect(startBackoff(3)).To(Equal(4 * startBackoffBase))
+		Expect(startBackoff(100)).To(Equal(startBackoffMax))
+	})
+
+	It("resets after a successful initialize", func() {
+		c = newClient(newFakeTransport(), restartPolicy{})
+		DeferCleanup(c.reset)
+		c.mu.Lock()
+		c.startFailures = 3
+		c.lastStartFailure = time.Now().Add(-startBackoffMax)
+		c.mu.Unlock()
+
+		Expect(c.ensureReady(context.Background())).To(Succeed())
+		_, failures := c.disabledState()
+		Expect(failures).To(BeZero())
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		Expect(c.startBackoffRemaining()).To(BeZero())
+	})
+})
+
+var _ = Describe("mid-call death retry", func() {
+	var transport *switchTransport
+	var c *client
+
+	BeforeEach(func() {
+		// Resets persist transport stats; keep them out of the repo tree.
+		DeferCleanup(configtest.SetupConfig())
+		conf.Server.DataFolder = GinkgoT().TempDir()
+		transport = &switchTransport{fakeTransport: newFakeTransport()}
+		c = newClient(transport, restartPolicy{})
+		DeferCleanup(c.reset)
+	})
+
+	It("restarts and retries exactly once when the pipe breaks", func() {
+		_, err := c.callTool(context.Background(), "get_artist_url", nil)
+		Expect(err).ToNot(HaveOccurred())
+
+		// Half-close the child's pipes: the next write fails on the spot.
+		Expect(transport.serverStdin.Close()).To(Succeed())
+
+		_, err = c.callTool(context.Background(), "get_artist_url", nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(transport.sentToolCalls()).To(HaveLen(2))
+	})
+
+	It("gives up when the restart itself fails", func() {
+		_, err := c.callTool(context.Background(), "get_artist_url", nil)
+		Expect(err).ToNot(HaveOccurred())
+
+		transport.broken = true
+		Expect(transport.serverStdin.Close()).To(Succeed())
+
+		_, err = c.callTool(context.Background(), "get_artist_url", nil)
+		Expect(err).To(MatchError(ContainSubstring("starting transport")))
+		Expect(transport.sentToolCalls()).To(HaveLen(1))
+	})
+})
+
+var _ = Describe("pre-call health check", func() {
+	var transport *fakeTransport
+	var c *client
+	var mu sync.Mutex
+	var probes int
+	var failNextProbe bool
+
+	BeforeEach(func() {
+		transport = newFakeTransport()
+		transport.handle = func(method string) any {
+			if method == "tools/list" {
+				mu.Lock()
+				defer mu.Unlock()
+				probes++
+				if failNextProbe {
+					failNextProbe = false
+					return nil // No answer: the server is half-dead
+				}
+			}
+			return map[string]any{}
+		}
+		probes, failNextProbe = 0, false
+		DeferCleanup(configtest.SetupConfig())
+		conf.Server.DataFolder = GinkgoT().TempDir()
+		c = newClient(transport, restartPolicy{})
+		c.healthCheckOnReuse = true
+		c.healthCheckTimeout = 50 * time.Millisecond
+		DeferCleanup(c.reset)
+	})
+
+	probeCount := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return probes
+	}
+
+	It("skips the probe on the call that started the server", func() {
+		_, err := c.callTool(context.Background(), "get_artist_url", nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(probeCount()).To(BeZero())
+	})
+
+	It("probes before reusing a running server", func() {
+		_, err := c.callTool(context.Background(), "get_artist_url", nil)
+		Expect(err).ToNot(HaveOccurred())
+		_, err = c.callTool(context.Background(), "get_artist_url", nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(probeCount()).To(Equal(1))
+	})
+
+	It("restarts a half-dead server and still serves the call", func() {
+		_, err := c.callTool(context.Background(), "get_artist_url", nil)
+		Expect(err).ToNot(HaveOccurred())
+		mu.Lock()
+		failNextProbe = true
+		mu.Unlock()
+
+		_, err = c.callTool(context.Background(), "get_artist_url", nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(transport.sentToolCalls()).To(HaveLen(2))
+		Expect(probeCount()).To(Equal(1))
+	})
+
+	It("sends no probe when the check is disabled", func() {
+		c.healthCheckOnReuse = false
+		for range 2 {
+			_, err := c.callTool(context.Background(), "get_artist_url", nil)
+			Expect(err).ToNot(HaveOccurred())
+		}
+		Expect(probeCount()).To(BeZero())
+	})
+})
+
+var _ = Describe("init timeout per transport", func() {
+	BeforeEach(func() {
+		DeferCleanup(configtest.SetupConfig())
+	})
+
+	It("gives wasm modules a larger default than native processes", func() {
+		native := newClient(newFakeTransport(), restartPolicy{})
+		Expect(native.initTimeout).To(Equal(defaultInitTimeoutNative))
+
+		wasm := newFakeTransport()
+		wasm.kindName = "wasm"
+		Expect(newClient(wasm, restartPolicy{}).initTimeout).To(Equal(defaultInitTimeoutWasm))
+	})
+
+	It("applies the global init timeout to both transports", func() {
+		conf.Server.MCP.InitTimeout = 42 * time.Second
+		wasm := newFakeTransport()
+		wasm.kindName = "wasm"
+		Expect(newClient(newFakeTransport(), restartPolicy{}).initTimeout).To(Equal(42 * time.Second))
+		Expect(newClient(wasm, restartPolicy{}).initTimeout).To(Equal(42 * time.Second))
+	})
+
+	It("lets a per-transport setting win over the global one", func() {
+		conf.Server.MCP.InitTimeout = 42 * time.Second
+		conf.Server.MCP.InitTimeoutWasm = 90 * time.Second
+		wasm := newFakeTransport()
+		wasm.kindName = "wasm"
+		Expect(newClient(wasm, restartPolicy{}).initTimeout).To(Equal(90 * time.Second))
+		Expect(newClient(newFakeTransport(), restartPolicy{}).initTimeout).To(Equal(42 * time.Second))
+	})
+})
+
+var _ = Describe("call timeout", func() {
+	var transport *fakeTransport
+
+	BeforeEach(func() {
+		DeferCleanup(configtest.SetupConfig())
+		transport = newFakeTransport()
+		// The handshake answers normally; tool calls are never answered.
+		transport.handle = func(method string) any {
+			if method == "tools/call" {
+				return nil
+			}
+			return map[string]any{}
+		}
+	})
+
+	It("is disabled by default, leaving calls bounded by the caller's context", func() {
+		Expect(newClient(transport, restartPolicy{}).callTimeout).To(BeZero())
+	})
+
+	It("gives up on a stalled call with a typed timeout error", func() {
+		conf.Server.MCP.CallTimeout = 50 * time.Millisecond
+		c := newClient(transport, restartPolicy{})
+		DeferCleanup(c.reset)
+
+		_, err := c.callTool(context.Background(), "get_artist_biography", nil)
+		Expect(err).To(MatchError(errCallTimeout))
+	})
+
+	It("lets a shorter caller deadline win", func() {
+		conf.Server.MCP.CallTimeout = time.Minute
+		c := newClient(transport, restartPolicy{})
+		DeferCleanup(c.reset)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		_, err := c.callTool(ctx, "get_artist_biography", nil)
+		Expect(err).To(MatchError(context.DeadlineExceeded))
+		Expect(errors.Is(err, errCallTimeout)).To(BeFalse())
+	})
+})
+
+var _ = Describe("client orphan responses", func() {
+	It("tolerates stray orphans but resets the transport on a spike", func() {
+		DeferCleanup(configtest.SetupConfig())
+		conf.Server.DataFolder = GinkgoT().TempDir()
+		transport := newFakeTransport()
+		transport.respondID = func(method string, id int64) int64 {
+			if method == "tools/call" {
+				return id + 1000 // Matches no outstanding request
+			}
+			return id
+		}
+		c := newClient(transport, restartPolicy{})
+
+		for i := 0; i < orphanResetThreshold-1; i++ {
+			ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+			_, err := c.callTool(ctx, "any_tool", nil)
+			cancel()
+			Expect(err).To(HaveOccurred())
+			Expect(transport.closed).ToNot(BeClosed())
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+		defer cancel()
+		_, _ = c.callTool(ctx, "any_tool", nil)
+		Eventually(transport.closed).Should(BeClosed())
+	})
+})
+
+var _ = Describe("client startup budgets", func() {
+	newTestClient := func(t transport) *client {
+		c := newClient(t, restartPolicy{})
+		c.startupTimeout = 2 * time.Second
+		c.initTimeout = 500 * time.Millisecond
+		return c
+	}
+
+	It("honors the startup budget even when it exceeds the init timeout", func() {
+		transport := newFakeTransport()
+		transport.startDelay = 700 * time.Millisecond // Longer than initTimeout
+		c := newTestClient(transport)
+
+		Expect(c.ensureReady(context.Background())).To(Succeed())
+	})
+
+	It("fails when startup exceeds the startup budget", func() {
+		transport := newFakeTransport()
+		transport.startDelay = 500 * time.Millisecond
+		c := newTestClient(transport)
+		c.startupTimeout = 100 * time.Millisecond
+
+		err := c.ensureReady(context.Background())
+		Expect(err).To(MatchError(ContainSubstring("starting transport")))
+	})
+
+	It("does not let a short caller deadline abort a slow cold start", func() {
+		transport := newFakeTransport()
+		transport.startDelay = 300 * time.Millisecond
+		c := newTestClient(transport)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		Expect(c.ensureReady(ctx)).To(Succeed())
+	})
+
+	It("keeps per-call timeouts independent of the startup budget", func() {
+		transport := newFakeTransport()
+		slowTool := transport.handle
+		transport.handle = func(method string) any {
+			if method == "tools/call" {
+				return nil // Never answer tool calls
+			}
+			return slowTool(method)
+		}
+		c := newTestClient(transport)
+		Expect(c.ensureReady(context.Background())).To(Succeed())
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		_, err := c.callTool(ctx, "any_tool", nil)
+		Expect(err).To(MatchError(context.DeadlineExceeded))
+	})
+
+	It("fails initialization when the server never answers within the init budget", func() {
+		transport := newFakeTransport()
+		transport.handle = func(method string) any { return nil }
+		c := newTestClient(transport)
+		c.initTimeout = 100 * time.Millisecond
+
+		err := c.ensureReady(context.Background())
+		Expect(err).To(MatchError(ContainSubstring("initialize handshake")))
+	})
+})
+
+var _ = Describe("stdin write guard", func() {
+	BeforeEach(func() {
+		DeferCleanup(configtest.SetupConfig())
+		conf.Server.DataFolder = GinkgoT().TempDir()
+	})
+
+	It("detects a closed pipe on the first write and fails fast afterwards", func() {
+		r, w := io.Pipe()
+		_ = r.Close()
+		fired := 0
+		g := newWriteGuard(w, func() { fired++ })
+
+		_, err := g.Write([]byte("hello\n"))
+		Expect(err).To(MatchError(io.ErrClosedPipe))
+		Expect(fired).To(Equal(1))
+
+		// Subsequent writes fail fast without touching the dead pipe again.
+		_, err = g.Write([]byte("again\n"))
+		Expect(err).To(MatchError(errTransportClosed))
+		Expect(fired).To(Equal(1))
+	})
+
+	It("restarts transparently when the child closes its stdin mid-session", func() {
+		transport := newFakeTransport()
+		c := newClient(transport, restartPolicy{})
+		DeferCleanup(c.reset)
+		Expect(c.ensureReady(context.Background())).To(Succeed())
+
+		_ = transport.serverStdin.Close() // The child's read end goes away mid-session
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		// The write surfaces the dead pipe on the spot - no timeout needed -
+		// and the client restarts the server and retries the call once, so
+		// the caller still gets an answer.
+		_, err := c.callTool(ctx, "any_tool", nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(transport.sentToolCalls()).To(HaveLen(1))
+
+		c.mu.Lock()
+		ready := c.ready
+		c.mu.Unlock()
+		Expect(ready).To(BeTrue())
+	})
+})
@@ -0,0 +1,98 @@
+package mcp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+
+	"github.com/navidrome/navidrome/core/agents"
+)
+
+var urlInErrorRe = regexp.MustCompile(`https?://[^\s"'<>]+`)
+
+// sanitizeError redacts URLs embedded in an error before it crosses the agent
+// boundary: query strings (which can carry whole SPARQL queries) and fragments
+// are stripped, keeping scheme, host and path. The original error should be
+// Debug-logged before sanitizing, as the redacted form is what callers and
+// API responses will see.
+func sanitizeError(err error) error {
+	if err == nil {
+		return nil
+	}
+	text := err.Error()
+	sanitized := sanitizeErrorText(text)
+	if sanitized == text {
+		return err
+	}
+	return errors.New(sanitized)
+}
+
+// toolEnvelope mirrors the structured {"ok": ..., "data": ..., "error": ...}
+// payload newer servers put in a tool response's text, so failures carry a
+// machine-readable code instead of prose to be sniffed.
+type toolEnvelope struct {
+	OK    bool            `json:"ok"`
+	Data  json.RawMessage `json:"data,omitempty"`
+	Error *toolErrorBody  `json:"error,omitempty"`
+}
+
+type toolErrorBody struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Error codes the server uses in its envelope.
+const (
+	toolErrNotFound = "not_found"
+	toolErrTimeout  = "timeout"
+)
+
+// decodeToolError maps an error response's text to a Go error. Structured
+// envelopes yield typed errors: "not_found" becomes agents.ErrNotFound, other
+// codes become wrapped failures the caller can log and surface. Plain text -
+// an older server, or one not speaking the envelope - keeps the historical
+// mapping to ErrNotFound.
+func decodeToolError(text string) error {
+	var env toolEnvelope
+	if err := json.Unmarshal([]byte(text), &env); err != nil || env.OK || env.Error == nil {
+		return agents.ErrNotFound
+	}
+	switch env.Error.Code {
+	case toolErrNotFound:
+		return agents.ErrNotFound
+	case toolErrTimeout:
+		return fmt.Errorf("mcp: tool timed out upstream: %s", sanitizeErrorText(env.Error.Message))
+	default:
+		return fmt.Errorf("mcp: tool failed upstream (%s): %s", env.Error.Code, sanitizeErrorText(env.Error.Message))
+	}
+}
+
+// unwrapToolEnvelope extracts the data payload when a successful response's
+// text carries the structured envelope; anything else - including ordinary
+// JSON results without one - passes through unchanged.
+func unwrapToolEnvelope(text string) string {
+	var env toolEnvelope
+	if err := json.Unmarshal([]byte(text), &env); err != nil || !env.OK || env.Data == nil {
+		return text
+	}
+	var s string
+	if err := json.Unmarshal(env.Data, &s); err == nil {
+		return s // String payloads come back as the bare text.
+	}
+	return string(env.Data)
+}
+
+func sanitizeErrorText(text string) string {
+	return urlInErrorRe.ReplaceAllStringFunc(text, func(raw string) string {
+		u, err := url.Parse(raw)
+		if err != nil {
+			return "[redacted-url]"
+		}
+		u.RawQuery = ""
+		u.Fragment = ""
+		u.User = nil
+		return u.String()
+	})
+}
@@ -0,0 +1,183 @@
+package mcp
+
+import (
+	"cmp"
+	"sync"
+	"time"
+
+	"github.com/navidrome/navidrome/conf"
+	"github.com/navidrome/navidrome/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Defaults for degradation detection, overridable via MCP.DegradedStreak and
+// MCP.DegradedAge.
+const (
+	defaultDegradedStreak = 5
+	defaultDegradedAge    = 24 * time.Hour
+)
+
+// Suspicious-output detection: a broken plugin or a misconfigured language can
+// return "success" with empty or junk data that passes the not-found checks.
+// Once a full window of successful calls has been seen, the tool is flagged
+// when at least suspectRatio of them returned fewer than suspectMinChars.
+const (
+	defaultSuspectWindow   = 20
+	defaultSuspectMinChars = 40
+	suspectRatio           = 0.5
+)
+
+// shortOutputExempt lists tools whose legitimate output is short (a URL, a
+// sort name, a 36-char MBID), where a tiny result says nothing about data
+// quality.
+var shortOutputExempt = map[string]bool{
+	toolGetArtistURL:       true,
+	toolGetArtistThumbnail: true,
+	toolGetArtistSortName:  true,
+	toolGetArtistMBID:      true,
+}
+
+// toolHealth tracks, per tool, when the last successful call happened and the
+// current consecutive-failure streak, so silent upstream degradation (e.g.
+// Wikidata rejecting us) is flagged instead of discovered by users.
+type toolHealth struct {
+	degradedStreak  int
+	degradedAge     time.Duration
+	suspectWindow   int
+	suspectMinChars int
+	now             func() time.Time // Injectable for tests
+
+	mu    sync.Mutex
+	tools map[string]*toolState
+}
+
+type toolState struct {
+	lastSuccess time.Time
+	failStreak  int
+	attempts    int64
+	degraded    bool
+	// outputSizes is the rolling window of result sizes from successful calls,
+	// feeding the suspicious-output check.
+	outputSizes []int
+	suspicious  bool
+}
+
+// ToolHealth is the JSON representation of a tool's health, for the status
+// endpoint.
+type ToolHealth struct {
+	LastSuccess      time.Time `json:"lastSuccess,omitzero"`
+	SinceLastSuccess string    `json:"sinceLastSuccess,omitempty"`
+	FailStreak       int       `json:"failStreak"`
+	Attempts         int64     `json:"attempts"`
+	Degraded         bool      `json:"degraded"`
+	// Suspicious is set when calls succeed but most of them return almost
+	// nothing - a data-quality problem the failure streak cannot see.
+	Suspicious bool `json:"suspicious,omitempty"`
+}
+
+func newToolHealth() *toolHealth {
+	return &toolHealth{
+		degradedStreak:  cmp.Or(conf.Server.MCP.DegradedStreak, defaultDegradedStreak),
+		degradedAge:     cmp.Or(conf.Server.MCP.DegradedAge, defaultDegradedAge),
+		suspectWindow:   defaultSuspectWindow,
+		suspectMinChars: defaultSuspectMinChars,
+		now:             time.Now,
+		tools:           make(map[string]*toolState),
+	}
+}
+
+func (h *toolHealth) recordSuccess(tool string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	state := h.state(tool)
+	state.attempts++
+	state.failStreak = 0
+	state.lastSuccess = h.now()
+	if state.degraded {
+		state.degraded = false
+		getMCPMetrics().toolDegraded.With(prometheus.Labels{"tool": tool}).Set(0)
+		log.Info("MCP tool recovered", "tool", tool)
+	}
+}
+
+func (h *toolHealth) recordFailure(tool string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	state := h.state(tool)
+	state.attempts++
+	state.failStreak++
+	if state.degraded {
+		return
+	}
+	tooStale := !state.lastSuccess.IsZero() && h.now().Sub(state.lastSuccess) > h.degradedAge
+	if state.failStreak > h.degradedStreak || tooStale {
+		state.degraded = true
+		getMCPMetrics().toolDegraded.With(prometheus.Labels{"tool": tool}).Set(1)
+		log.Warn("MCP tool is degraded - calls keep failing", "tool", tool,
+			"failStreak", state.failStreak, "lastSuccess", state.lastSuccess)
+	}
+}
+
+// recordOutputSize feeds the size of a successful result into the rolling
+// window and re-evaluates the suspicious-output flag once the window is full.
+// Tools with legitimately short output are exempt.
+func (h *toolHealth) recordOutputSize(tool string, size int) {
+	if shortOutputExempt[tool] {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	state := h.state(tool)
+	state.outputSizes = append(state.outputSizes, size)
+	if len(state.outputSizes) > h.suspectWindow {
+		state.outputSizes = state.outputSizes[len(state.outputSizes)-h.suspectWindow:]
+	}
+	if len(state.outputSizes) < h.suspectWindow {
+		return
+	}
+	short := 0
+	for _, s := range state.outputSizes {
+		if s < h.suspectMinChars {
+			short++
+		}
+	}
+	suspicious := float64(short) >= suspectRatio*float64(len(state.outputSizes))
+	if suspicious && !state.suspicious {
+		log.Warn("MCP tool output looks suspicious - calls succeed but return almost nothing",
+			"tool", tool, "shortResults", short, "window", len(state.outputSizes))
+	}
+	if !suspicious && state.suspicious {
+		log.Info("MCP tool output is back to normal", "tool", tool)
+	}
+	state.suspicious = suspicious
+}
+
+func (h *toolHealth) state(tool string) *toolState {
+	if s, ok := h.tools[tool]; ok {
+		return s
+	}
+	s := &toolState{}
+	h.tools[tool] = s
+	return s
+}
+
+// snapshot returns the current health of every tool seen so far.
+func (h *toolHealth) snapshot() map[string]ToolHealth {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	result := make(map[string]ToolHealth, len(h.tools))
+	for tool, state := range h.tools {
+		th := ToolHealth{
+			LastSuccess: state.lastSuccess,
+			FailStreak:  state.failStreak,
+			Attempts:    state.attempts,
+			Degraded:    state.degraded,
+			Suspicious:  state.suspicious,
+		}
+		if !state.lastSuccess.IsZero() {
+			th.SinceLastSuccess = h.now().Sub(state.lastSuccess).Round(time.Second).String()
+		}
+		result[tool] = th
+	}
+	return result
+}
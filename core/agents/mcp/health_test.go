@@ -0,0 +1,126 @@
+package mcp
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("toolHealth", func() {
+	var health *toolHealth
+	var now time.Time
+
+	BeforeEach(func() {
+		now = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+		health = &toolHealth{
+			degradedStreak: 3,
+			degradedAge:    time.Hour,
+			now:            func() time.Time { return now },
+			tools:          map[string]*toolState{},
+		}
+	})
+
+	It("stays healthy while failures are below the streak threshold", func() {
+		for i := 0; i < 3; i++ {
+			health.recordFailure("get_artist_biography")
+		}
+		Expect(health.snapshot()["get_artist_biography"].Degraded).To(BeFalse())
+	})
+
+	It("flags the tool once the failure streak exceeds the threshold", func() {
+		for i := 0; i < 4; i++ {
+			health.recordFailure("get_artist_biography")
+		}
+		snap := health.snapshot()["get_artist_biography"]
+		Expect(snap.Degraded).To(BeTrue())
+		Expect(snap.FailStreak).To(Equal(4))
+	})
+
+	It("flags the tool when the last success is too old and attempts continue", func() {
+		health.recordSuccess("get_artist_biography")
+		now = now.Add(2 * time.Hour)
+		health.recordFailure("get_artist_biography")
+		Expect(health.snapshot()["get_artist_biography"].Degraded).To(BeTrue())
+	})
+
+	It("does not treat a tool that never succeeded as stale", func() {
+		now = now.Add(48 * time.Hour)
+		health.recordFailure("get_artist_biography")
+		Expect(health.snapshot()["get_artist_biography"].Degraded).To(BeFalse())
+	})
+
+	It("clears the degraded flag on recovery and resets the streak", func() {
+		for i := 0; i < 4; i++ {
+			health.recordFailure("get_artist_biography")
+		}
+		health.recordSuccess("get_artist_biography")
+		snap := health.snapshot()["get_artist_biography"]
+		Expect(snap.Degraded).To(BeFalse())
+		Expect(snap.FailStreak).To(BeZero())
+		Expect(snap.LastSuccess).To(Equal(now))
+	})
+
+	It("tracks tools independently", func() {
+		for i := 0; i < 4; i++ {
+			health.recordFailure("get_artist_biography")
+		}
+		health.recordSuccess("get_artist_details")
+		Expect(health.snapshot()["get_artist_biography"].Degraded).To(BeTrue())
+		Expect(health.snapshot()["get_artist_details"].Degraded).To(BeFalse())
+	})
+
+	It("reports the elapsed time since the last success", func() {
+		health.recordSuccess("get_artist_biography")
+		now = now.Add(90 * time.Second)
+		Expect(health.snapshot()["get_artist_biography"].SinceLastSuccess).To(Equal("1m30s"))
+	})
+})
+
+var _ = Describe("suspicious output detection", func() {
+	var health *toolHealth
+
+	BeforeEach(func() {
+		health = &toolHealth{
+			suspectWindow:   4,
+			suspectMinChars: 40,
+			now:             time.Now,
+			tools:           map[string]*toolState{},
+		}
+	})
+
+	feed := func(tool string, sizes ...int) {
+		for _, s := range sizes {
+			health.recordOutputSize(tool, s)
+		}
+	}
+
+	It("stays quiet until the window is full", func() {
+		feed("get_artist_biography", 0, 0, 0)
+		Expect(health.snapshot()["get_artist_biography"].Suspicious).To(BeFalse())
+	})
+
+	It("flags a tool when most results in the window are nearly empty", func() {
+		feed("get_artist_biography", 500, 0, 2, 10)
+		Expect(health.snapshot()["get_artist_biography"].Suspicious).To(BeTrue())
+	})
+
+	It("does not flag a tool with mostly substantial results", func() {
+		feed("get_artist_biography", 500, 350, 0, 420)
+		Expect(health.snapshot()["get_artist_biography"].Suspicious).To(BeFalse())
+	})
+
+	It("clears the flag once results recover", func() {
+		feed("get_artist_biography", 0, 0, 0, 0)
+		Expect(health.snapshot()["get_artist_biography"].Suspicious).To(BeTrue())
+		feed("get_artist_biography", 500, 350, 420, 600)
+		Expect(health.snapshot()["get_artist_biography"].Suspicious).To(BeFalse())
+	})
+
+	It("ignores tools with legitimately short output", func() {
+		feed(toolGetArtistURL, 0, 0, 0, 0, 0)
+		feed(toolGetArtistMBID, 36, 36, 36, 36, 36) // A UUID is always 36 chars
+		Expect(health.snapshot()).ToNot(HaveKey(toolGetArtistURL))
+		Expect(health.snapshot()).ToNot(HaveKey(toolGetArtistMBID))
+	})
+})
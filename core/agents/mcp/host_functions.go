@@ -0,0 +1,349 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/navidrome/navidrome/conf"
+	"github.com/navidrome/navidrome/consts"
+	"github.com/navidrome/navidrome/log"
+	"github.com/tetratelabs/wazero"
+	wazeroapi "github.com/tetratelabs/wazero/api"
+)
+
+// hostModuleName is the import namespace the wasm MCP server uses for host
+// functions. WASI has no sockets, so outbound HTTP goes through http_fetch.
+const hostModuleName = "navidrome"
+
+// Error codes returned by http_fetch to the guest.
+const (
+	hostFetchErrInvalid   = -1 // Malformed URL or arguments
+	hostFetchErrDenied    = -2 // URL host not in the allowlist
+	hostFetchErrTransport = -3 // Network or HTTP failure
+)
+
+// logBodyLimit caps how much of an upstream response body is embedded in a
+// log line; enough to diagnose, without hundreds of KB of error-page HTML.
+const logBodyLimit = 2 * 1024
+
+// truncateForLog renders a response body for logging, cut at n bytes
+// (logBodyLimit when n is zero) with an indication of how much was omitted.
+func truncateForLog(b []byte, n int) string {
+	if n <= 0 {
+		n = logBodyLimit
+	}
+	if len(b) <= n {
+		return string(b)
+	}
+	return fmt.Sprintf("%s... (%d bytes omitted)", b[:n], len(b)-n)
+}
+
+// Host fetch retry policy, mirroring the server-side fetcher: transient
+// failures (5xx and transport errors) are retried with exponential backoff,
+// bounded by the caller's context; client errors are not. Variables, so tests
+// can shrink the delay.
+var (
+	hostFetchMaxRetries = 2
+	hostFetchRetryDelay = 500 * time.Millisecond
+)
+
+// mcpUserAgent is the User-Agent sent with every outbound request made on a
+// guest's behalf. Wikimedia's API policy requires a real product and contact;
+// MCP.UserAgent overrides the default built from the Navidrome version.
+func mcpUserAgent() string {
+	if ua := conf.Server.MCP.UserAgent; ua != "" {
+		return ua
+	}
+	return fmt.Sprintf("Navidrome/%s (+https://navidrome.org)", consts.Version)
+}
+
+// defaultHTTPClient is used when no client is injected. It is a package-level
+// default only; tests and per-agent configuration pass their own via
+// hostFunctionOptions.
+var defaultHTTPClient = &http.Client{Timeout: 30 * time.Second}
+
+// hostFunctionOptions carries the dependencies captured by the host function
+// closures. The zero value is usable: the default client, no allowlist
+// restriction, and no cache.
+type hostFunctionOptions struct {
+	client    *http.Client
+	allowlist []string // Allowed hosts; empty allows any
+	// cache memoizes successful fetches, keyed by URL. Which implementation
+	// (or none) is selected by MCP.CacheMode.
+	cache Cache
+}
+
+func (o *hostFunctionOptions) httpClient() *http.Client {
+	if o == nil || o.client == nil {
+		return defaultHTTPClient
+	}
+	return o.client
+}
+
+func (o *hostFunctionOptions) allowed(host string) bool {
+	if o == nil || len(o.allowlist) == 0 {
+		return true
+	}
+	return slices.Contains(o.allowlist, host)
+}
+
+// registerHostFunctions instantiates the "navidrome" host module on the given
+// runtime. Options are per-runtime, so independent agents (and tests) can use
+// different clients without touching package state.
+func registerHostFunctions(ctx context.Context, r wazero.Runtime, opts *hostFunctionOptions) error {
+	_, err := r.NewHostModuleBuilder(hostModuleName).
+		NewFunctionBuilder().
+		WithFunc(func(ctx context.Context, m wazeroapi.Module, urlPtr, urlLen, bodyPtr, bodyCap uint32) int32 {
+			return hostHTTPFetch(ctx, m, opts, urlPtr, urlLen, bodyPtr, bodyCap)
+		}).
+		Export("http_fetch").
+		NewFunctionBuilder().
+		WithFunc(func(ctx context.Context, m wazeroapi.Module, urlPtr, urlLen, hdrPtr, hdrLen, bodyPtr, bodyCap uint32) int32 {
+			return hostHTTPFetchHdrs(ctx, m, opts, urlPtr, urlLen, hdrPtr, hdrLen, bodyPtr, bodyCap)
+		}).
+		Export("http_fetch_hdrs").
+		NewFunctionBuilder().
+		WithFunc(func(ctx context.Context, m wazeroapi.Module, urlPtr, urlLen uint32) int32 {
+			return hostHTTPFetchLen(ctx, m, opts, urlPtr, urlLen)
+		}).
+		Export("http_fetch_len").
+		NewFunctionBuilder().
+		WithFunc(func(ctx context.Context, m wazeroapi.Module, urlPtr, urlLen uint32) int32 {
+			return hostHTTPHead(ctx, m, opts, urlPtr, urlLen)
+		}).
+		Export("http_head").
+		Instantiate(ctx)
+	return err
+}
+
+// hostHTTPFetch reads the URL from guest memory, performs the fetch and
+// writes the body back, returning the number of bytes written or a negative
+// error code. Bodies larger than the guest's buffer are truncated.
+func hostHTTPFetch(ctx context.Context, m wazeroapi.Module, opts *hostFunctionOptions, urlPtr, urlLen, bodyPtr, bodyCap uint32) int32 {
+	rawURL, ok := m.Memory().Read(urlPtr, urlLen)
+	if !ok {
+		return hostFetchErrInvalid
+	}
+	body, _, code := doHostFetch(ctx, opts, string(rawURL))
+	if code != 0 {
+		return code
+	}
+	if uint32(len(body)) > bodyCap {
+		body = body[:bodyCap]
+	}
+	if !m.Memory().Write(bodyPtr, body) {
+		return hostFetchErrInvalid
+	}
+	return int32(len(body))
+}
+
+// hostHTTPFetchHdrs is http_fetch with an extra header blob: newline-delimited
+// "Name: value" pairs read from guest memory and applied to the outgoing
+// request, so guests can negotiate content types or identify themselves
+// upstream. A zero-length blob means no extra headers, making it a drop-in
+// superset of http_fetch. It is a separate export because wazero matches host
+// imports by signature: extending http_fetch itself would break every ABI v1
+// module.
+func hostHTTPFetchHdrs(ctx context.Context, m wazeroapi.Module, opts *hostFunctionOptions, urlPtr, urlLen, hdrPtr, hdrLen, bodyPtr, bodyCap uint32) int32 {
+	rawURL, ok := m.Memory().Read(urlPtr, urlLen)
+	if !ok {
+		return hostFetchErrInvalid
+	}
+	var headers http.Header
+	if hdrLen > 0 {
+		blob, ok := m.Memory().Read(hdrPtr, hdrLen)
+		if !ok {
+			return hostFetchErrInvalid
+		}
+		headers = parseHeaderBlob(string(blob))
+	}
+	body, _, code := doHostFetchHeaders(ctx, opts, string(rawURL), headers)
+	if code != 0 {
+		return code
+	}
+	if uint32(len(body)) > bodyCap {
+		body = body[:bodyCap]
+	}
+	if !m.Memory().Write(bodyPtr, body) {
+		return hostFetchErrInvalid
+	}
+	return int32(len(body))
+}
+
+// parseHeaderBlob parses the guest's serialized headers: one "Name: value"
+// pair per line. Malformed or empty lines are skipped rather than failing the
+// fetch; a blob with no usable pairs yields nil.
+func parseHeaderBlob(blob string) http.Header {
+	var h http.Header
+	for _, line := range strings.Split(blob, "\n") {
+		name, value, ok := strings.Cut(line, ":")
+		name, value = strings.TrimSpace(name), strings.TrimSpace(value)
+		if !ok || name == "" || value == "" {
+			continue
+		}
+		if h == nil {
+			h = http.Header{}
+		}
+		h.Add(name, value)
+	}
+	return h
+}
+
+// hostHTTPFetchLen reads the URL from guest memory and returns the length of
+// the response body, or a negative error code. It exists so guests can size
+// their buffer before calling http_fetch: learn the length, allocate, fetch -
+// instead of guessing a capacity and losing the tail of large bodies to
+// truncation. Cacheable responses are served from the cache on the follow-up
+// http_fetch, so the two-call protocol does not hit the upstream twice.
+func hostHTTPFetchLen(ctx context.Context, m wazeroapi.Module, opts *hostFunctionOptions, urlPtr, urlLen uint32) int32 {
+	rawURL, ok := m.Memory().Read(urlPtr, urlLen)
+	if !ok {
+		return hostFetchErrInvalid
+	}
+	body, _, code := doHostFetch(ctx, opts, string(rawURL))
+	if code != 0 {
+		return code
+	}
+	return int32(len(body))
+}
+
+// doHostFetch is the transport-independent part of http_fetch, split out so
+// it can be exercised without a wasm runtime. Besides the body, it returns the
+// final URL after any redirects; the stdio ABI cannot carry it to the guest,
+// but the cache is keyed by both URLs so canonical fetches hit it too.
+func doHostFetch(ctx context.Context, opts *hostFunctionOptions, rawURL string) ([]byte, string, int32) {
+	return doHostFetchHeaders(ctx, opts, rawURL, nil)
+}
+
+// doHostFetchHeaders is doHostFetch with extra request headers. Fetches that
+// carry headers bypass the cache entirely: it is keyed by URL alone, and must
+// not hand a representation negotiated under one Accept value to a request
+// made under another.
+func doHostFetchHeaders(ctx context.Context, opts *hostFunctionOptions, rawURL string, headers http.Header) ([]byte, string, int32) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil, rawURL, hostFetchErrInvalid
+	}
+	if !opts.allowed(u.Hostname()) {
+		log.Debug(ctx, "MCP host fetch denied by allowlist", "url", loggedURL(rawURL))
+		return nil, rawURL, hostFetchErrDenied
+	}
+	if opts != nil && opts.cache != nil && len(headers) == 0 {
+		if body, ok := opts.cache.Get(rawURL); ok {
+			return body, rawURL, 0
+		}
+	}
+	delay := hostFetchRetryDelay
+	for attempt := 0; ; attempt++ {
+		body, finalURL, header, code, transient := hostFetchOnce(ctx, opts, rawURL, headers)
+		if transient && attempt < hostFetchMaxRetries {
+			log.Debug(ctx, "Transient MCP host fetch failure - retrying", "url", loggedURL(rawURL),
+				"attempt", attempt+1, "delay", delay)
+			select {
+			case <-ctx.Done():
+				return nil, rawURL, hostFetchErrTransport
+			case <-time.After(delay):
+			}
+			delay *= 2
+			continue
+		}
+		// The final answer - success or not - is written back exactly once.
+		if code != 0 {
+			return nil, finalURL, code
+		}
+		if opts != nil && opts.cache != nil && len(headers) == 0 && cacheableResponse(header) {
+			opts.cache.Set(rawURL, body)
+			if finalURL != rawURL {
+				log.Trace(ctx, "MCP host fetch followed redirect", "url", loggedURL(rawURL), "finalUrl", loggedURL(finalURL))
+				opts.cache.Set(finalURL, body)
+			}
+		}
+		return body, finalURL, 0
+	}
+}
+
+// hostFetchOnce performs a single GET attempt, additionally reporting whether
+// a failure is transient (5xx or a transport error) and worth retrying.
+func hostFetchOnce(ctx context.Context, opts *hostFunctionOptions, rawURL string, headers http.Header) (body []byte, finalURL string, header http.Header, code int32, transient bool) {
+	finalURL = rawURL
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, finalURL, nil, hostFetchErrInvalid, false
+	}
+	// Set before the guest's headers, so http_fetch_hdrs callers can override.
+	req.Header.Set("User-Agent", mcpUserAgent())
+	for name, values := range headers {
+		req.Header[name] = values
+	}
+	resp, err := opts.httpClient().Do(req)
+	if err != nil {
+		log.Debug(ctx, "MCP host fetch failed", "url", loggedURL(rawURL), err)
+		return nil, finalURL, nil, hostFetchErrTransport, true
+	}
+	defer resp.Body.Close()
+	if resp.Request != nil && resp.Request.URL != nil {
+		finalURL = resp.Request.URL.String()
+	}
+	if resp.StatusCode != http.StatusOK {
+		if log.IsGreaterOrEqualTo(log.LevelDebug) {
+			b, _ := io.ReadAll(io.LimitReader(resp.Body, maxFrameSize))
+			log.Debug(ctx, "MCP host fetch failed with status", "url", loggedURL(rawURL),
+				"status", resp.StatusCode, "body", truncateForLog(b, 0))
+		}
+		return nil, finalURL, resp.Header, hostFetchErrTransport, resp.StatusCode >= http.StatusInternalServerError
+	}
+	body, err = io.ReadAll(io.LimitReader(resp.Body, maxFrameSize))
+	if err != nil {
+		return nil, finalURL, resp.Header, hostFetchErrTransport, true
+	}
+	return body, finalURL, resp.Header, 0, false
+}
+
+// hostHTTPHead reads the URL from guest memory and performs a HEAD request,
+// returning the HTTP status code or a negative error code. No body is ever
+// read, making it a cheap existence check (e.g. validating an image URL).
+func hostHTTPHead(ctx context.Context, m wazeroapi.Module, opts *hostFunctionOptions, urlPtr, urlLen uint32) int32 {
+	rawURL, ok := m.Memory().Read(urlPtr, urlLen)
+	if !ok {
+		return hostFetchErrInvalid
+	}
+	return doHostHead(ctx, opts, string(rawURL))
+}
+
+// doHostHead is the transport-independent part of http_head, split out so it
+// can be exercised without a wasm runtime.
+func doHostHead(ctx context.Context, opts *hostFunctionOptions, rawURL string) int32 {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return hostFetchErrInvalid
+	}
+	if !opts.allowed(u.Hostname()) {
+		log.Debug(ctx, "MCP host head denied by allowlist", "url", loggedURL(rawURL))
+		return hostFetchErrDenied
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, rawURL, nil)
+	if err != nil {
+		return hostFetchErrInvalid
+	}
+	req.Header.Set("User-Agent", mcpUserAgent())
+	resp, err := opts.httpClient().Do(req)
+	if err != nil {
+		log.Debug(ctx, "MCP host head failed", "url", loggedURL(rawURL), err)
+		return hostFetchErrTransport
+	}
+	resp.Body.Close()
+	return int32(resp.StatusCode)
+}
+
+// cacheableResponse reports whether the upstream allows the response to be
+// stored; no-store and private responses must be fetched fresh every time.
+func cacheableResponse(h http.Header) bool {
+	cc := strings.ToLower(h.Get("Cache-Control"))
+	return !strings.Contains(cc, "no-store") && !strings.Contains(cc, "private")
+}
@@ -0,0 +1,323 @@
+package mcp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/navidrome/navidrome/conf"
+	"github.com/navidrome/navidrome/conf/configtest"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("doHostFetch", func() {
+	var ctx context.Context
+
+	BeforeEach(func() {
+		ctx = context.Background()
+	})
+
+	It("uses the injected client, so independent options hit independent servers", func() {
+		srvA := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = w.Write([]byte("from A"))
+		}))
+		defer srvA.Close()
+		srvB := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = w.Write([]byte("from B"))
+		}))
+		defer srvB.Close()
+
+		optsA := &hostFunctionOptions{client: srvA.Client()}
+		optsB := &hostFunctionOptions{client: srvB.Client()}
+
+		bodyA, _, code := doHostFetch(ctx, optsA, srvA.URL)
+		Expect(code).To(BeZero())
+		Expect(string(bodyA)).To(Equal("from A"))
+
+		bodyB, _, code := doHostFetch(ctx, optsB, srvB.URL)
+		Expect(code).To(BeZero())
+		Expect(string(bodyB)).To(Equal("from B"))
+	})
+
+	It("identifies itself with the configured User-Agent", func() {
+		DeferCleanup(configtest.SetupConfig())
+		var agent string
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			agent = r.Header.Get("User-Agent")
+			_, _ = w.Write([]byte("ok"))
+		}))
+		defer srv.Close()
+		opts := &hostFunctionOptions{client: srv.Client()}
+
+		_, _, code := doHostFetch(ctx, opts, srv.URL)
+		Expect(code).To(BeZero())
+		Expect(agent).To(HavePrefix("Navidrome/"))
+
+		conf.Server.MCP.UserAgent = "Custom/1.0 (admin@example.com)"
+		_, _, code = doHostFetch(ctx, opts, srv.URL)
+		Expect(code).To(BeZero())
+		Expect(agent).To(Equal("Custom/1.0 (admin@example.com)"))
+	})
+
+	It("denies hosts outside the allowlist", func() {
+		opts := &hostFunctionOptions{allowlist: []string{"allowed.example"}}
+		_, _, code := doHostFetch(ctx, opts, "https://denied.example/path")
+		Expect(code).To(Equal(int32(hostFetchErrDenied)))
+	})
+
+	It("rejects non-http URLs", func() {
+		_, _, code := doHostFetch(ctx, nil, "file:///etc/passwd")
+		Expect(code).To(Equal(int32(hostFetchErrInvalid)))
+	})
+
+	It("serves repeated fetches from the cache", func() {
+		hits := 0
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			hits++
+			_, _ = w.Write([]byte("body"))
+		}))
+		defer srv.Close()
+
+		opts := &hostFunctionOptions{client: srv.Client(), cache: NewMemoryCache(0, 0)}
+		for i := 0; i < 3; i++ {
+			body, _, code := doHostFetch(ctx, opts, srv.URL)
+			Expect(code).To(BeZero())
+			Expect(string(body)).To(Equal("body"))
+		}
+		Expect(hits).To(Equal(1))
+	})
+
+	It("does not cache responses marked Cache-Control no-store", func() {
+		hits := 0
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			hits++
+			w.Header().Set("Cache-Control", "no-store")
+			_, _ = w.Write([]byte("fresh"))
+		}))
+		defer srv.Close()
+
+		opts := &hostFunctionOptions{client: srv.Client(), cache: NewMemoryCache(0, 0)}
+		for i := 0; i < 3; i++ {
+			body, _, code := doHostFetch(ctx, opts, srv.URL)
+			Expect(code).To(BeZero())
+			Expect(string(body)).To(Equal("fresh"))
+		}
+		Expect(hits).To(Equal(3))
+	})
+
+	It("follows relative redirects and reports the final URL", func() {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/old" {
+				http.Redirect(w, r, "/new", http.StatusFound)
+				return
+			}
+			_, _ = w.Write([]byte("canonical"))
+		}))
+		defer srv.Close()
+
+		cache := NewMemoryCache(0, 0)
+		opts := &hostFunctionOptions{client: srv.Client(), cache: cache}
+		body, finalURL, code := doHostFetch(ctx, opts, srv.URL+"/old")
+		Expect(code).To(BeZero())
+		Expect(string(body)).To(Equal("canonical"))
+		Expect(finalURL).To(Equal(srv.URL + "/new"))
+
+		// The body is cached under both the requested and the final URL, so a
+		// later canonical fetch does not hit the upstream again.
+		_, ok := cache.Get(srv.URL + "/old")
+		Expect(ok).To(BeTrue())
+		_, ok = cache.Get(srv.URL + "/new")
+		Expect(ok).To(BeTrue())
+	})
+
+	It("reports transport errors for failing upstreams", func() {
+		retries := hostFetchMaxRetries
+		hostFetchMaxRetries = 0 // Retries are covered separately
+		DeferCleanup(func() { hostFetchMaxRetries = retries })
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer srv.Close()
+
+		_, _, code := doHostFetch(ctx, &hostFunctionOptions{client: srv.Client()}, srv.URL)
+		Expect(code).To(Equal(int32(hostFetchErrTransport)))
+	})
+
+	Describe("retries", func() {
+		BeforeEach(func() {
+			retries, delay := hostFetchMaxRetries, hostFetchRetryDelay
+			hostFetchRetryDelay = time.Millisecond
+			DeferCleanup(func() { hostFetchMaxRetries, hostFetchRetryDelay = retries, delay })
+		})
+
+		It("retries 5xx answers and returns the eventual success", func() {
+			hits := 0
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				hits++
+				if hits == 1 {
+					w.WriteHeader(http.StatusInternalServerError)
+					return
+				}
+				_, _ = w.Write([]byte("recovered"))
+			}))
+			defer srv.Close()
+
+			body, _, code := doHostFetch(ctx, &hostFunctionOptions{client: srv.Client()}, srv.URL)
+			Expect(code).To(BeZero())
+			Expect(string(body)).To(Equal("recovered"))
+			Expect(hits).To(Equal(2))
+		})
+
+		It("does not retry client errors", func() {
+			hits := 0
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				hits++
+				w.WriteHeader(http.StatusNotFound)
+			}))
+			defer srv.Close()
+
+			_, _, code := doHostFetch(ctx, &hostFunctionOptions{client: srv.Client()}, srv.URL)
+			Expect(code).To(Equal(int32(hostFetchErrTransport)))
+			Expect(hits).To(Equal(1))
+		})
+
+		It("gives up after the configured retries", func() {
+			hits := 0
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				hits++
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}))
+			defer srv.Close()
+
+			_, _, code := doHostFetch(ctx, &hostFunctionOptions{client: srv.Client()}, srv.URL)
+			Expect(code).To(Equal(int32(hostFetchErrTransport)))
+			Expect(hits).To(Equal(hostFetchMaxRetries + 1))
+		})
+
+		It("stops retrying when the caller's context expires", func() {
+			hostFetchRetryDelay = time.Minute
+			hits := 0
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				hits++
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}))
+			defer srv.Close()
+
+			shortCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+			defer cancel()
+			_, _, code := doHostFetch(shortCtx, &hostFunctionOptions{client: srv.Client()}, srv.URL)
+			Expect(code).To(Equal(int32(hostFetchErrTransport)))
+			Expect(hits).To(Equal(1))
+		})
+	})
+})
+
+var _ = Describe("doHostFetchHeaders", func() {
+	var ctx context.Context
+
+	BeforeEach(func() {
+		ctx = context.Background()
+	})
+
+	It("applies the extra headers to the outgoing request", func() {
+		var accept, agent string
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			accept, agent = r.Header.Get("Accept"), r.Header.Get("User-Agent")
+			_, _ = w.Write([]byte("negotiated"))
+		}))
+		defer srv.Close()
+
+		headers := parseHeaderBlob("Accept: application/json\nUser-Agent: testplugin/0.0.1")
+		body, _, code := doHostFetchHeaders(ctx, &hostFunctionOptions{client: srv.Client()}, srv.URL, headers)
+		Expect(code).To(BeZero())
+		Expect(string(body)).To(Equal("negotiated"))
+		Expect(accept).To(Equal("application/json"))
+		Expect(agent).To(Equal("testplugin/0.0.1"))
+	})
+
+	It("bypasses the cache, which is keyed by URL alone", func() {
+		hits := 0
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			hits++
+			_, _ = w.Write([]byte("body"))
+		}))
+		defer srv.Close()
+
+		cache := NewMemoryCache(0, 0)
+		cache.Set(srv.URL, []byte("stale representation"))
+		headers := parseHeaderBlob("Accept: application/json")
+		opts := &hostFunctionOptions{client: srv.Client(), cache: cache}
+
+		body, _, code := doHostFetchHeaders(ctx, opts, srv.URL, headers)
+		Expect(code).To(BeZero())
+		Expect(string(body)).To(Equal("body"))
+		Expect(hits).To(Equal(1))
+
+		// Nor does it overwrite the plain entry with a negotiated body.
+		value, ok := cache.Get(srv.URL)
+		Expect(ok).To(BeTrue())
+		Expect(string(value)).To(Equal("stale representation"))
+	})
+})
+
+var _ = Describe("parseHeaderBlob", func() {
+	It("parses newline-delimited pairs, trimming whitespace", func() {
+		h := parseHeaderBlob("Accept: application/json\n  User-Agent :  lookup/1.0  ")
+		Expect(h.Get("Accept")).To(Equal("application/json"))
+		Expect(h.Get("User-Agent")).To(Equal("lookup/1.0"))
+	})
+
+	It("keeps repeated headers as multiple values", func() {
+		h := parseHeaderBlob("Accept: text/html\nAccept: application/json")
+		Expect(h.Values("Accept")).To(Equal([]string{"text/html", "application/json"}))
+	})
+
+	It("skips malformed lines instead of failing the fetch", func() {
+		h := parseHeaderBlob("no-colon-here\n: value-without-name\nname-without-value:\n\nAccept: text/html")
+		Expect(h).To(HaveLen(1))
+		Expect(h.Get("Accept")).To(Equal("text/html"))
+	})
+
+	It("yields nil for an empty blob", func() {
+		Expect(parseHeaderBlob("")).To(BeNil())
+	})
+})
+
+var _ = Describe("doHostHead", func() {
+	var ctx context.Context
+
+	BeforeEach(func() {
+		ctx = context.Background()
+	})
+
+	It("returns the status without fetching the body", func() {
+		var methods []string
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			methods = append(methods, r.Method)
+			_, _ = w.Write([]byte("a large body that a HEAD must not download"))
+		}))
+		defer srv.Close()
+
+		code := doHostHead(ctx, &hostFunctionOptions{client: srv.Client()}, srv.URL)
+		Expect(code).To(Equal(int32(http.StatusOK)))
+		Expect(methods).To(Equal([]string{http.MethodHead}))
+	})
+
+	It("passes non-200 statuses through, so callers can tell gone from broken", func() {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+		}))
+		defer srv.Close()
+
+		code := doHostHead(ctx, &hostFunctionOptions{client: srv.Client()}, srv.URL)
+		Expect(code).To(Equal(int32(http.StatusNotFound)))
+	})
+
+	It("applies the same allowlist and scheme checks as http_fetch", func() {
+		opts := &hostFunctionOptions{allowlist: []string{"allowed.example"}}
+		Expect(doHostHead(ctx, opts, "https://denied.example/img.jpg")).To(Equal(int32(hostFetchErrDenied)))
+		Expect(doHostHead(ctx, nil, "file:///etc/passwd")).To(Equal(int32(hostFetchErrInvalid)))
+	})
+})
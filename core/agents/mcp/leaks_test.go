@@ -0,0 +1,99 @@
+package mcp
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// These tests hammer the transports through repeated
+// start/crash/restart/Close cycles and assert nothing is left behind: no read
+// loops parked on dead pipes, no instantiation goroutines waiting on channels
+// nobody drains. The suite-level goleak check in TestMCP backs them up.
+var _ = Describe("transport goroutine hygiene", Label("slow"), func() {
+	const cycles = 100
+
+	// drainFrames stands in for the client read loop, returning a channel that
+	// closes when the framed stream ends.
+	drainFrames := func(stdout *framingReader) chan struct{} {
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for {
+				if _, err := stdout.nextFrame(); err != nil {
+					return
+				}
+			}
+		}()
+		return done
+	}
+
+	writeScript := func(dir, name, body string) string {
+		path := filepath.Join(dir, name)
+		Expect(os.WriteFile(path, []byte("#!/bin/sh\n"+body+"\n"), 0700)).To(Succeed())
+		return path
+	}
+
+	It("leaves nothing behind after native start/crash/restart/Close cycles", func() {
+		dir := GinkgoT().TempDir()
+		// server stays up until killed, like the real thing; crasher dies
+		// immediately, like a crash-looping one.
+		server := writeScript(dir, "server", "while read line; do :; done")
+		crasher := writeScript(dir, "crasher", "exit 1")
+
+		before := runtime.NumGoroutine()
+		for range cycles {
+			for _, path := range []string{crasher, server} {
+				tr := newStdioTransport(path)
+				stdin, stdout, err := tr.Start(context.Background())
+				Expect(err).ToNot(HaveOccurred())
+				done := drainFrames(stdout)
+				_, _ = stdin.Write([]byte("{\"jsonrpc\":\"2.0\",\"method\":\"ping\"}\n"))
+				Expect(tr.Close()).To(Succeed())
+				Eventually(done).Should(BeClosed())
+			}
+		}
+		Eventually(runtime.NumGoroutine).Should(BeNumerically("<=", before+2))
+	})
+
+	It("leaves nothing behind after wasm start/Close cycles", func() {
+		// An empty but valid module: instantiation returns immediately, which
+		// is the same exit path as a module that ran and stopped.
+		path := filepath.Join(GinkgoT().TempDir(), "noop.wasm")
+		Expect(os.WriteFile(path, []byte("\x00asm\x01\x00\x00\x00"), 0600)).To(Succeed())
+
+		before := runtime.NumGoroutine()
+		for range cycles {
+			tr := newWasmTransport(path)
+			_, stdout, err := tr.Start(context.Background())
+			Expect(err).ToNot(HaveOccurred())
+			done := drainFrames(stdout)
+			Expect(tr.Close()).To(Succeed())
+			Eventually(done).Should(BeClosed())
+		}
+		Eventually(runtime.NumGoroutine).Should(BeNumerically("<=", before+2))
+	})
+
+	It("fails writes to an exited wasm module instead of blocking", func() {
+		path := filepath.Join(GinkgoT().TempDir(), "noop.wasm")
+		Expect(os.WriteFile(path, []byte("\x00asm\x01\x00\x00\x00"), 0600)).To(Succeed())
+
+		tr := newWasmTransport(path)
+		stdin, stdout, err := tr.Start(context.Background())
+		Expect(err).ToNot(HaveOccurred())
+		// The module has exited once its stdout reports EOF.
+		Eventually(drainFrames(stdout)).Should(BeClosed())
+
+		written := make(chan error, 1)
+		go func() {
+			_, err := stdin.Write([]byte("{\"jsonrpc\":\"2.0\"}\n"))
+			written <- err
+		}()
+		Eventually(written).Should(Receive(HaveOccurred()))
+		Expect(tr.Close()).To(Succeed())
+	})
+})
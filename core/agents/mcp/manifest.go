@@ -0,0 +1,115 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/navidrome/navidrome/conf"
+	"github.com/navidrome/navidrome/log"
+)
+
+// manifestSuffix is appended to the configured server path to locate its
+// optional manifest, e.g. /path/mcp-server -> /path/mcp-server.manifest.json
+const manifestSuffix = ".manifest.json"
+
+// Host-enforced maximums for manifest-declared restart policies. Values above
+// these are clamped, so a plugin cannot opt out of recycling entirely.
+const (
+	maxIdleTimeout       = 24 * time.Hour
+	maxMaxInstanceAge    = 7 * 24 * time.Hour
+	minRecycleOnMemoryMB = 64
+)
+
+// serverManifest is the optional per-server manifest, placed next to the
+// server binary or wasm module.
+type serverManifest struct {
+	RestartPolicy *restartPolicyConf `json:"restartPolicy,omitempty"`
+}
+
+// restartPolicyConf mirrors the manifest's restartPolicy block. Durations are
+// given in Go duration syntax (e.g. "30m"). Zero/omitted fields fall back to
+// the global MCP settings.
+type restartPolicyConf struct {
+	IdleTimeout         string `json:"idleTimeout,omitempty"`
+	MaxCallsPerInstance int    `json:"maxCallsPerInstance,omitempty"`
+	MaxInstanceAge      string `json:"maxInstanceAge,omitempty"`
+	RecycleOnMemoryMB   int    `json:"recycleOnMemoryMB,omitempty"`
+}
+
+// restartPolicy is the effective, validated policy applied to a server
+// instance. Zero values disable the corresponding trigger.
+type restartPolicy struct {
+	IdleTimeout         time.Duration `json:"idleTimeout,omitzero"`
+	MaxCallsPerInstance int           `json:"maxCallsPerInstance,omitempty"`
+	MaxInstanceAge      time.Duration `json:"maxInstanceAge,omitzero"`
+	RecycleOnMemoryMB   int           `json:"recycleOnMemoryMB,omitempty"`
+}
+
+// loadServerManifest reads the manifest next to the given server path. A
+// missing manifest is not an error - everything in it is optional.
+func loadServerManifest(serverPath string) (*serverManifest, error) {
+	data, err := os.ReadFile(serverPath + manifestSuffix)
+	if os.IsNotExist(err) {
+		return &serverManifest{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var m serverManifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("mcp: parsing manifest %s: %w", serverPath+manifestSuffix, err)
+	}
+	return &m, nil
+}
+
+// globalRestartPolicy builds the default policy from the MCP config options.
+func globalRestartPolicy() restartPolicy {
+	return restartPolicy{
+		IdleTimeout:         conf.Server.MCP.IdleTimeout,
+		MaxCallsPerInstance: conf.Server.MCP.MaxCallsPerInstance,
+		MaxInstanceAge:      conf.Server.MCP.MaxInstanceAge,
+		RecycleOnMemoryMB:   conf.Server.MCP.RecycleOnMemoryMB,
+	}
+}
+
+// resolveRestartPolicy merges a manifest's restartPolicy over the global
+// defaults, clamping the result to the host-enforced maximums.
+func resolveRestartPolicy(m *serverManifest, defaults restartPolicy) restartPolicy {
+	policy := defaults
+	if m != nil && m.RestartPolicy != nil {
+		rp := m.RestartPolicy
+		if rp.IdleTimeout != "" {
+			policy.IdleTimeout = parsePolicyDuration(rp.IdleTimeout, "idleTimeout", defaults.IdleTimeout)
+		}
+		if rp.MaxCallsPerInstance > 0 {
+			policy.MaxCallsPerInstance = rp.MaxCallsPerInstance
+		}
+		if rp.MaxInstanceAge != "" {
+			policy.MaxInstanceAge = parsePolicyDuration(rp.MaxInstanceAge, "maxInstanceAge", defaults.MaxInstanceAge)
+		}
+		if rp.RecycleOnMemoryMB > 0 {
+			policy.RecycleOnMemoryMB = rp.RecycleOnMemoryMB
+		}
+	}
+	if policy.IdleTimeout > maxIdleTimeout {
+		policy.IdleTimeout = maxIdleTimeout
+	}
+	if policy.MaxInstanceAge > maxMaxInstanceAge {
+		policy.MaxInstanceAge = maxMaxInstanceAge
+	}
+	if policy.RecycleOnMemoryMB > 0 && policy.RecycleOnMemoryMB < minRecycleOnMemoryMB {
+		policy.RecycleOnMemoryMB = minRecycleOnMemoryMB
+	}
+	return policy
+}
+
+func parsePolicyDuration(value, field string, fallback time.Duration) time.Duration {
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		log.Warn("Invalid duration in MCP server manifest, using default", "field", field, "value", value)
+		return fallback
+	}
+	return d
+}
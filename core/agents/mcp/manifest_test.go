@@ -0,0 +1,92 @@
+package mcp
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("resolveRestartPolicy", func() {
+	defaults := restartPolicy{
+		IdleTimeout:         30 * time.Minute,
+		MaxCallsPerInstance: 0,
+		MaxInstanceAge:      0,
+		RecycleOnMemoryMB:   0,
+	}
+
+	It("returns the global defaults when the manifest omits the block", func() {
+		Expect(resolveRestartPolicy(&serverManifest{}, defaults)).To(Equal(defaults))
+		Expect(resolveRestartPolicy(nil, defaults)).To(Equal(defaults))
+	})
+
+	It("overrides the defaults with manifest values", func() {
+		m := &serverManifest{RestartPolicy: &restartPolicyConf{
+			IdleTimeout:         "2h",
+			MaxCallsPerInstance: 100,
+			MaxInstanceAge:      "12h",
+			RecycleOnMemoryMB:   512,
+		}}
+		policy := resolveRestartPolicy(m, defaults)
+		Expect(policy).To(Equal(restartPolicy{
+			IdleTimeout:         2 * time.Hour,
+			MaxCallsPerInstance: 100,
+			MaxInstanceAge:      12 * time.Hour,
+			RecycleOnMemoryMB:   512,
+		}))
+	})
+
+	It("keeps defaults for fields the manifest omits", func() {
+		m := &serverManifest{RestartPolicy: &restartPolicyConf{MaxCallsPerInstance: 10}}
+		policy := resolveRestartPolicy(m, defaults)
+		Expect(policy.IdleTimeout).To(Equal(defaults.IdleTimeout))
+		Expect(policy.MaxCallsPerInstance).To(Equal(10))
+	})
+
+	It("clamps values to the host-enforced maximums", func() {
+		m := &serverManifest{RestartPolicy: &restartPolicyConf{
+			IdleTimeout:       "1000h",
+			MaxInstanceAge:    "1000h",
+			RecycleOnMemoryMB: 1,
+		}}
+		policy := resolveRestartPolicy(m, defaults)
+		Expect(policy.IdleTimeout).To(Equal(maxIdleTimeout))
+		Expect(policy.MaxInstanceAge).To(Equal(maxMaxInstanceAge))
+		Expect(policy.RecycleOnMemoryMB).To(Equal(minRecycleOnMemoryMB))
+	})
+
+	It("falls back to the default when a duration is invalid", func() {
+		m := &serverManifest{RestartPolicy: &restartPolicyConf{IdleTimeout: "soon"}}
+		policy := resolveRestartPolicy(m, defaults)
+		Expect(policy.IdleTimeout).To(Equal(defaults.IdleTimeout))
+	})
+})
+
+var _ = Describe("loadServerManifest", func() {
+	It("returns an empty manifest when the file does not exist", func() {
+		m, err := loadServerManifest(filepath.Join(GinkgoT().TempDir(), "mcp-server"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(m.RestartPolicy).To(BeNil())
+	})
+
+	It("loads the restartPolicy block", func() {
+		serverPath := filepath.Join(GinkgoT().TempDir(), "mcp-server")
+		manifest := `{"restartPolicy":{"idleTimeout":"1h","maxCallsPerInstance":5}}`
+		Expect(os.WriteFile(serverPath+manifestSuffix, []byte(manifest), 0600)).To(Succeed())
+
+		m, err := loadServerManifest(serverPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(m.RestartPolicy.IdleTimeout).To(Equal("1h"))
+		Expect(m.RestartPolicy.MaxCallsPerInstance).To(Equal(5))
+	})
+
+	It("fails on a malformed manifest", func() {
+		serverPath := filepath.Join(GinkgoT().TempDir(), "mcp-server")
+		Expect(os.WriteFile(serverPath+manifestSuffix, []byte("{"), 0600)).To(Succeed())
+
+		_, err := loadServerManifest(serverPath)
+		Expect(err).To(MatchError(ContainSubstring("parsing manifest")))
+	})
+})
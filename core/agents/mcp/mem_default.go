@@ -0,0 +1,9 @@
+//go:build !linux
+
+package mcp
+
+// processMemoryMB is only implemented on Linux. Other platforms report 0,
+// which disables memory-based recycling.
+func processMemoryMB(_ int) int {
+	return 0
+}
@@ -0,0 +1,26 @@
+package mcp
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// processMemoryMB reads the resident set size of the given process from
+// /proc, in megabytes. It returns 0 when the process cannot be inspected.
+func processMemoryMB(pid int) int {
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/statm", pid))
+	if err != nil {
+		return 0
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) < 2 {
+		return 0
+	}
+	pages, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil {
+		return 0
+	}
+	return int(pages * int64(os.Getpagesize()) / (1024 * 1024))
+}
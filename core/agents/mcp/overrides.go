@@ -0,0 +1,46 @@
+package mcp
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/navidrome/navidrome/conf"
+	"github.com/navidrome/navidrome/log"
+)
+
+// overridesFileName is the admin-curated pin file in the data folder, mapping
+// library artist IDs to forced external identities.
+const overridesFileName = "mcp-artist-overrides.json"
+
+// artistOverride pins the external identity of a library artist whose lookups
+// keep matching the wrong entity: a MusicBrainz ID and/or Wikidata QID used
+// instead of whatever the library has, and optionally a canonical URL returned
+// directly without any lookup.
+type artistOverride struct {
+	MBID string `json:"mbid,omitempty"`
+	QID  string `json:"qid,omitempty"`
+	URL  string `json:"url,omitempty"`
+}
+
+// artistOverrides maps library artist IDs to their pinned identities.
+type artistOverrides map[string]artistOverride
+
+// loadArtistOverrides reads the pin file. A missing file means no overrides;
+// a corrupt one is logged and ignored, so a bad edit never disables the agent.
+// The file is read once at construction: it is admin-curated and rarely
+// changes, and a restart after editing it is acceptable.
+func loadArtistOverrides() artistOverrides {
+	path := filepath.Join(conf.Server.DataFolder, overridesFileName)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var overrides artistOverrides
+	if err := json.Unmarshal(data, &overrides); err != nil {
+		log.Warn("Could not parse MCP artist overrides, ignoring them", "path", path, err)
+		return nil
+	}
+	log.Info("Loaded MCP artist overrides", "path", path, "count", len(overrides))
+	return overrides
+}
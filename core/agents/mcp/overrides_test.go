@@ -0,0 +1,96 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/navidrome/navidrome/conf"
+	"github.com/navidrome/navidrome/conf/configtest"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("artist overrides", func() {
+	BeforeEach(func() {
+		DeferCleanup(configtest.SetupConfig())
+		conf.Server.DataFolder = GinkgoT().TempDir()
+	})
+
+	writeOverrides := func(content string) {
+		path := filepath.Join(conf.Server.DataFolder, overridesFileName)
+		Expect(os.WriteFile(path, []byte(content), 0o600)).To(Succeed())
+	}
+
+	It("loads the pin file from the data folder", func() {
+		writeOverrides(`{"ar-1":{"mbid":"correct-mbid","url":"https://en.wikipedia.org/wiki/Right_Artist"}}`)
+		overrides := loadArtistOverrides()
+		Expect(overrides).To(HaveLen(1))
+		Expect(overrides["ar-1"].MBID).To(Equal("correct-mbid"))
+	})
+
+	It("ignores a missing or corrupt pin file", func() {
+		Expect(loadArtistOverrides()).To(BeNil())
+		writeOverrides(`{"ar-1":`)
+		Expect(loadArtistOverrides()).To(BeNil())
+	})
+
+	Describe("pinned lookups", func() {
+		var transport *fakeTransport
+		var agent *mcpAgent
+
+		BeforeEach(func() {
+			transport = newFakeTransport()
+			transport.handle = func(method string) any {
+				if method == "tools/call" {
+					return map[string]any{"content": []map[string]any{{"type": "text", "text": "a bio"}}}
+				}
+				return map[string]any{}
+			}
+			agent = &mcpAgent{
+				client:  newClient(transport, restartPolicy{}),
+				health:  newToolHealth(),
+				pending: newRetryQueue(defaultRetryQueueSize),
+				overrides: artistOverrides{
+					"ar-1": {MBID: "pinned-mbid", QID: "Q42", URL: "https://en.wikipedia.org/wiki/Right_Artist"},
+				},
+			}
+		})
+
+		It("returns a pinned URL without any lookup", func() {
+			url, err := agent.GetArtistURL(context.Background(), "ar-1", "Some Artist", "wrong-mbid")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(url).To(Equal("https://en.wikipedia.org/wiki/Right_Artist"))
+			Expect(transport.sentToolCalls()).To(BeEmpty())
+		})
+
+		It("forces the pinned MBID and QID on lookups", func() {
+			_, err := agent.GetArtistBiography(context.Background(), "ar-1", "Some Artist", "wrong-mbid")
+			Expect(err).ToNot(HaveOccurred())
+
+			calls := transport.sentToolCalls()
+			Expect(calls).To(HaveLen(1))
+			var params struct {
+				Arguments map[string]string `json:"arguments"`
+			}
+			Expect(json.Unmarshal(calls[0], &params)).To(Succeed())
+			Expect(params.Arguments["mbid"]).To(Equal("pinned-mbid"))
+			Expect(params.Arguments["qid"]).To(Equal("Q42"))
+		})
+
+		It("leaves artists without an override untouched", func() {
+			_, err := agent.GetArtistBiography(context.Background(), "ar-2", "Other Artist", "lib-mbid")
+			Expect(err).ToNot(HaveOccurred())
+
+			calls := transport.sentToolCalls()
+			Expect(calls).To(HaveLen(1))
+			var params struct {
+				Arguments map[string]string `json:"arguments"`
+			}
+			Expect(json.Unmarshal(calls[0], &params)).To(Succeed())
+			Expect(params.Arguments["mbid"]).To(Equal("lib-mbid"))
+			Expect(params.Arguments).ToNot(HaveKey("qid"))
+		})
+	})
+})
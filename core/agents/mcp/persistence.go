@@ -0,0 +1,74 @@
+package mcp
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+
+	"github.com/navidrome/navidrome/conf"
+	"github.com/navidrome/navidrome/log"
+)
+
+// persistence gates every disk-backed MCP feature (persisted transport stats,
+// prefetch checkpoints) behind a single writability state. Some deployments
+// mount the data folder read-only except for the DB path; instead of a write
+// error on every attempt, the agent detects that once, logs one Info line and
+// continues purely in memory.
+var persistence = &persistenceState{}
+
+// persistenceDisabledReason is what the status endpoint reports while
+// disk-backed features are off.
+const persistenceDisabledReason = "disabled (read-only data folder)"
+
+type persistenceState struct {
+	mu       sync.Mutex
+	disabled bool
+}
+
+// enabled reports whether disk-backed features may write to the data folder.
+func (p *persistenceState) enabled() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return !p.disabled
+}
+
+// disableIfReadOnly inspects a data-folder write error. A read-only filesystem
+// or permission error disables all disk-backed features (with a single Info
+// log) and reports true; any other error - disk full, transient - leaves
+// persistence enabled so the next write can retry.
+func (p *persistenceState) disableIfReadOnly(err error) bool {
+	if !errors.Is(err, fs.ErrPermission) && !errors.Is(err, syscall.EROFS) {
+		return false
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.disabled {
+		p.disabled = true
+		log.Info("MCP data folder is not writable - disk-backed features disabled, continuing in memory",
+			"folder", conf.Server.DataFolder, err)
+	}
+	return true
+}
+
+// statusString renders the persistence state for the status endpoint.
+func (p *persistenceState) statusString() string {
+	if p.enabled() {
+		return "enabled"
+	}
+	return persistenceDisabledReason
+}
+
+// checkDataFolderWritable probes the data folder when the agent is built, so a
+// read-only volume is reported immediately instead of on the first write.
+func checkDataFolderWritable() {
+	probe := filepath.Join(conf.Server.DataFolder, ".mcp-write-probe")
+	err := os.WriteFile(probe, nil, 0600)
+	if err != nil {
+		_ = persistence.disableIfReadOnly(err)
+		return
+	}
+	_ = os.Remove(probe)
+}
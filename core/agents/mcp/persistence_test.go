@@ -0,0 +1,56 @@
+package mcp
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"github.com/navidrome/navidrome/conf"
+	"github.com/navidrome/navidrome/conf/configtest"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("persistence", func() {
+	BeforeEach(func() {
+		DeferCleanup(configtest.SetupConfig())
+		conf.Server.DataFolder = GinkgoT().TempDir()
+		persistence = &persistenceState{}
+		DeferCleanup(func() { persistence = &persistenceState{} })
+	})
+
+	It("stays enabled when the data folder is writable", func() {
+		checkDataFolderWritable()
+		Expect(persistence.enabled()).To(BeTrue())
+		Expect(persistence.statusString()).To(Equal("enabled"))
+	})
+
+	It("disables disk-backed features when the data folder is read-only", func() {
+		Expect(os.Chmod(conf.Server.DataFolder, 0o500)).To(Succeed())
+		DeferCleanup(func() { _ = os.Chmod(conf.Server.DataFolder, 0o700) })
+		if os.WriteFile(filepath.Join(conf.Server.DataFolder, "probe"), nil, 0o600) == nil {
+			Skip("running as root - read-only directories are not enforced")
+		}
+
+		checkDataFolderWritable()
+		Expect(persistence.enabled()).To(BeFalse())
+		Expect(persistence.statusString()).To(Equal(persistenceDisabledReason))
+
+		// Disabled persistence swallows writes instead of warning every time.
+		persistStats(TransportStats{Resets: 1})
+		Expect(filepath.Join(conf.Server.DataFolder, statsFileName)).ToNot(BeAnExistingFile())
+		savePrefetchCheckpoint(prefetchCheckpoint{Order: "name", Position: 1})
+		Expect(prefetchCheckpointPath()).ToNot(BeAnExistingFile())
+	})
+
+	It("treats a read-only filesystem error as a degradation, not a failure", func() {
+		Expect(persistence.disableIfReadOnly(syscall.EROFS)).To(BeTrue())
+		Expect(persistence.enabled()).To(BeFalse())
+	})
+
+	It("leaves persistence enabled on transient write errors", func() {
+		Expect(persistence.disableIfReadOnly(syscall.ENOSPC)).To(BeFalse())
+		Expect(persistence.disableIfReadOnly(nil)).To(BeFalse())
+		Expect(persistence.enabled()).To(BeTrue())
+	})
+})
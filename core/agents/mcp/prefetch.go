@@ -0,0 +1,190 @@
+package mcp
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/navidrome/navidrome/conf"
+	"github.com/navidrome/navidrome/core/agents"
+	"github.com/navidrome/navidrome/log"
+	"github.com/navidrome/navidrome/model"
+)
+
+// prefetchCheckpointFile is where the prefetch job records its position in the
+// data folder, so a restarted job resumes instead of re-walking from the top.
+const prefetchCheckpointFile = "mcp-prefetch-checkpoint.json"
+
+// prefetchRecentWindow is how recently an artist must have been added to land
+// in the front of the smart ordering.
+const prefetchRecentWindow = 30 * 24 * time.Hour
+
+// Orderings selectable via MCP.PrefetchOrder. "smart" (the default) warms the
+// artists users are most likely to look at first: recently added, then by play
+// count; "name" walks alphabetically.
+const (
+	prefetchOrderSmart = "smart"
+	prefetchOrderName  = "name"
+)
+
+// prefetchCheckpoint records how far a walk got, and under which ordering: a
+// checkpoint from a different ordering does not resume, as the positions would
+// not line up.
+type prefetchCheckpoint struct {
+	Order     string    `json:"order"`
+	Position  int       `json:"position"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// prefetchJob walks the library's artists warming the agent's caches, in an
+// order that favors the artists users actually look at.
+type prefetchJob struct {
+	ds    model.DataStore
+	warm  func(ctx context.Context, artist model.Artist) error
+	order string
+	now   func() time.Time
+	// quota, when set, pauses the walk until the next window instead of
+	// burning through lookups that would all be rejected.
+	quota *requestQuota
+}
+
+func newPrefetchJob(ds model.DataStore, agent *mcpAgent) *prefetchJob {
+	return &prefetchJob{
+		ds: ds,
+		warm: func(ctx context.Context, artist model.Artist) error {
+			_, err := agent.GetArtistBiography(ctx, artist.ID, artist.Name, artist.MbzArtistID)
+			return err
+		},
+		order: cmp.Or(strings.ToLower(conf.Server.MCP.PrefetchOrder), prefetchOrderSmart),
+		now:   time.Now,
+		quota: agent.quota,
+	}
+}
+
+func (j *prefetchJob) run(ctx context.Context) error {
+	artists, err := j.ds.Artist(ctx).GetAll()
+	if err != nil {
+		return err
+	}
+	ordered := orderArtistsForPrefetch(artists, j.order, j.now())
+	start := 0
+	if cp := loadPrefetchCheckpoint(); cp.Order == j.order && cp.Position > 0 && cp.Position <= len(ordered) {
+		log.Info(ctx, "Resuming MCP prefetch from checkpoint", "position", cp.Position, "total", len(ordered))
+		start = cp.Position
+	}
+	for i := start; i < len(ordered); i++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		artist := ordered[i]
+		err := j.warm(ctx, artist)
+		if errors.Is(err, errQuotaExceeded) && j.quota != nil {
+			// Never attempted: pause until the window resets and retry, without
+			// advancing the checkpoint past this artist.
+			log.Info(ctx, "MCP prefetch paused by request quota", "position", i, "total", len(ordered))
+			if err := j.quota.waitUntilReset(ctx); err != nil {
+				return err
+			}
+			i--
+			continue
+		}
+		if err != nil && !errors.Is(err, agents.ErrNotFound) {
+			log.Debug(ctx, "MCP prefetch lookup failed", "artist", artist.Name, err)
+		}
+		savePrefetchCheckpoint(prefetchCheckpoint{Order: j.order, Position: i + 1, UpdatedAt: j.now()})
+	}
+	clearPrefetchCheckpoint()
+	return nil
+}
+
+// orderArtistsForPrefetch sorts the work queue. The smart ordering has three
+// tiers: artists added within prefetchRecentWindow (newest first), then played
+// artists by play count descending, then everything else by name.
+func orderArtistsForPrefetch(artists model.Artists, order string, now time.Time) model.Artists {
+	ordered := slices.Clone(artists)
+	if order == prefetchOrderName {
+		slices.SortStableFunc(ordered, func(a, b model.Artist) int {
+			return cmp.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+		})
+		return ordered
+	}
+	tier := func(a model.Artist) int {
+		switch {
+		case a.CreatedAt != nil && now.Sub(*a.CreatedAt) <= prefetchRecentWindow:
+			return 0
+		case a.PlayCount > 0:
+			return 1
+		default:
+			return 2
+		}
+	}
+	slices.SortStableFunc(ordered, func(a, b model.Artist) int {
+		if c := cmp.Compare(tier(a), tier(b)); c != 0 {
+			return c
+		}
+		switch tier(a) {
+		case 0: // Newest first
+			return b.CreatedAt.Compare(*a.CreatedAt)
+		case 1: // Most played first
+			return cmp.Compare(b.PlayCount, a.PlayCount)
+		default:
+			return cmp.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+		}
+	})
+	return ordered
+}
+
+func prefetchCheckpointPath() string {
+	return filepath.Join(conf.Server.DataFolder, prefetchCheckpointFile)
+}
+
+func loadPrefetchCheckpoint() prefetchCheckpoint {
+	var cp prefetchCheckpoint
+	data, err := os.ReadFile(prefetchCheckpointPath())
+	if err == nil {
+		_ = json.Unmarshal(data, &cp)
+	}
+	return cp
+}
+
+// savePrefetchCheckpoint persists the position after every artist. Failures
+// are only logged: at worst, a restart re-warms a few artists. A read-only
+// data folder disables checkpointing along with the other disk-backed
+// features.
+func savePrefetchCheckpoint(cp prefetchCheckpoint) {
+	if !persistence.enabled() {
+		return
+	}
+	data, err := json.Marshal(cp)
+	if err == nil {
+		err = os.WriteFile(prefetchCheckpointPath(), data, 0600)
+	}
+	if persistence.disableIfReadOnly(err) {
+		return
+	}
+	if err != nil {
+		log.Warn("Could not persist MCP prefetch checkpoint", "path", prefetchCheckpointPath(), err)
+	}
+}
+
+func clearPrefetchCheckpoint() {
+	_ = os.Remove(prefetchCheckpointPath())
+}
+
+// RunPrefetch walks the whole library once, warming the agent's caches. It is
+// the entry point for the `navidrome mcp prefetch` command.
+func RunPrefetch(ctx context.Context, ds model.DataStore) error {
+	agent := mcpConstructor(ds)
+	if agent == nil {
+		state, reason := AgentStateInfo()
+		return fmt.Errorf("mcp: agent is not active (state %s: %s)", state, reason)
+	}
+	return newPrefetchJob(ds, agent).run(ctx)
+}
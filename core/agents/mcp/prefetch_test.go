@@ -0,0 +1,132 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"time"
+
+	"github.com/navidrome/navidrome/conf"
+	"github.com/navidrome/navidrome/conf/configtest"
+	"github.com/navidrome/navidrome/model"
+	"github.com/navidrome/navidrome/tests"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("orderArtistsForPrefetch", func() {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	at := func(t time.Time) *time.Time { return &t }
+
+	It("puts recently added first, then most played, then the rest by name", func() {
+		artists := model.Artists{
+			{Name: "Backlog", CreatedAt: at(now.Add(-90 * 24 * time.Hour))},
+			{Name: "Favorite", CreatedAt: at(now.Add(-90 * 24 * time.Hour)),
+				Annotations: model.Annotations{PlayCount: 50}},
+			{Name: "New Find", CreatedAt: at(now.Add(-24 * time.Hour))},
+			{Name: "Also New", CreatedAt: at(now.Add(-48 * time.Hour))},
+			{Name: "Sometimes", CreatedAt: at(now.Add(-90 * 24 * time.Hour)),
+				Annotations: model.Annotations{PlayCount: 5}},
+			{Name: "Archive", CreatedAt: at(now.Add(-90 * 24 * time.Hour))},
+		}
+		ordered := orderArtistsForPrefetch(artists, prefetchOrderSmart, now)
+		names := make([]string, len(ordered))
+		for i, a := range ordered {
+			names[i] = a.Name
+		}
+		Expect(names).To(Equal([]string{"New Find", "Also New", "Favorite", "Sometimes", "Archive", "Backlog"}))
+	})
+
+	It("sorts alphabetically when the name ordering is selected", func() {
+		artists := model.Artists{
+			{Name: "zeta", Annotations: model.Annotations{PlayCount: 50}},
+			{Name: "Alpha"},
+		}
+		ordered := orderArtistsForPrefetch(artists, prefetchOrderName, now)
+		Expect(ordered[0].Name).To(Equal("Alpha"))
+		Expect(ordered[1].Name).To(Equal("zeta"))
+	})
+})
+
+var _ = Describe("prefetchJob", func() {
+	var ds *tests.MockDataStore
+	var job *prefetchJob
+	var warmed []string
+
+	BeforeEach(func() {
+		DeferCleanup(configtest.SetupConfig())
+		conf.Server.DataFolder = GinkgoT().TempDir()
+		repo := tests.CreateMockArtistRepo()
+		repo.SetData(model.Artists{
+			{ID: "1", Name: "Alpha"},
+			{ID: "2", Name: "Beta"},
+			{ID: "3", Name: "Gamma"},
+		})
+		ds = &tests.MockDataStore{MockedArtist: repo}
+		warmed = nil
+		job = &prefetchJob{
+			ds:    ds,
+			order: prefetchOrderName,
+			now:   time.Now,
+			warm: func(_ context.Context, artist model.Artist) error {
+				warmed = append(warmed, artist.Name)
+				return nil
+			},
+		}
+	})
+
+	It("walks all artists in order and clears the checkpoint when done", func() {
+		Expect(job.run(context.Background())).To(Succeed())
+		Expect(warmed).To(Equal([]string{"Alpha", "Beta", "Gamma"}))
+		Expect(prefetchCheckpointPath()).ToNot(BeAnExistingFile())
+	})
+
+	It("checkpoints on cancellation and resumes from there", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		job.warm = func(_ context.Context, artist model.Artist) error {
+			warmed = append(warmed, artist.Name)
+			cancel() // Shut down after the first artist
+			return nil
+		}
+		Expect(job.run(ctx)).To(MatchError(context.Canceled))
+		Expect(warmed).To(Equal([]string{"Alpha"}))
+
+		warmed = nil
+		job.warm = func(_ context.Context, artist model.Artist) error {
+			warmed = append(warmed, artist.Name)
+			return nil
+		}
+		Expect(job.run(context.Background())).To(Succeed())
+		Expect(warmed).To(Equal([]string{"Beta", "Gamma"}))
+	})
+
+	It("pauses on quota exhaustion and retries the same artist after the reset", func() {
+		now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+		quota := newRequestQuota(1)
+		quota.now = func() time.Time { return now }
+		job.quota = quota
+
+		rejected := false
+		job.warm = func(_ context.Context, artist model.Artist) error {
+			if artist.Name == "Beta" && !rejected {
+				rejected = true
+				now = now.Add(quotaWindow) // The window passes while paused
+				return errQuotaExceeded
+			}
+			warmed = append(warmed, artist.Name)
+			return nil
+		}
+
+		Expect(job.run(context.Background())).To(Succeed())
+		Expect(warmed).To(Equal([]string{"Alpha", "Beta", "Gamma"}))
+	})
+
+	It("ignores a checkpoint taken under a different ordering", func() {
+		data, err := json.Marshal(prefetchCheckpoint{Order: prefetchOrderSmart, Position: 2})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(os.WriteFile(prefetchCheckpointPath(), data, 0600)).To(Succeed())
+
+		Expect(job.run(context.Background())).To(Succeed())
+		Expect(warmed).To(Equal([]string{"Alpha", "Beta", "Gamma"}))
+	})
+})
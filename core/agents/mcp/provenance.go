@@ -0,0 +1,97 @@
+package mcp
+
+import (
+	"sync"
+	"time"
+)
+
+// maxProvenanceArtists bounds the in-memory provenance log; beyond it, an
+// arbitrary artist is dropped. Provenance is debugging metadata, not a record
+// of truth, so losing old entries is fine.
+const maxProvenanceArtists = 1000
+
+// FieldProvenance records which agent and upstream source supplied one
+// external-metadata field of an artist, and when, so an admin can tell where
+// the bio they are reading came from.
+type FieldProvenance struct {
+	Agent       string    `json:"agent"`
+	Plugin      string    `json:"plugin,omitempty"`
+	Source      string    `json:"source,omitempty"`
+	License     string    `json:"license,omitempty"`
+	RetrievedAt time.Time `json:"retrievedAt,omitzero"`
+}
+
+// provenanceLog keeps per-artist provenance for the fields this agent filled,
+// keyed by artist ID and field name (biography, url, thumbnail, ...).
+type provenanceLog struct {
+	mu      sync.Mutex
+	entries map[string]map[string]FieldProvenance
+}
+
+func newProvenanceLog() *provenanceLog {
+	return &provenanceLog{entries: map[string]map[string]FieldProvenance{}}
+}
+
+func (p *provenanceLog) record(artistID, field string, fp FieldProvenance) {
+	if artistID == "" {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, ok := p.entries[artistID]; !ok {
+		for len(p.entries) >= maxProvenanceArtists {
+			for id := range p.entries {
+				delete(p.entries, id)
+				break
+			}
+		}
+		p.entries[artistID] = map[string]FieldProvenance{}
+	}
+	p.entries[artistID][field] = fp
+}
+
+// forArtist returns a copy of the artist's field provenance; an empty map when
+// nothing was recorded.
+func (p *provenanceLog) forArtist(artistID string) map[string]FieldProvenance {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	fields := make(map[string]FieldProvenance, len(p.entries[artistID]))
+	for field, fp := range p.entries[artistID] {
+		fields[field] = fp
+	}
+	return fields
+}
+
+// recordProvenance notes that this agent supplied the given field, using the
+// attribution the server attached to the tool response (recorded just before
+// by recordAttribution).
+func (a *mcpAgent) recordProvenance(id, field, tool string) {
+	if a.provenance == nil {
+		return
+	}
+	a.mu.Lock()
+	attr := a.attributions[tool]
+	a.mu.Unlock()
+	retrievedAt := attr.RetrievedAt
+	if retrievedAt.IsZero() {
+		retrievedAt = time.Now()
+	}
+	a.provenance.record(id, field, FieldProvenance{
+		Agent:       mcpAgentName,
+		Plugin:      a.plugin,
+		Source:      attr.Source,
+		License:     attr.License,
+		RetrievedAt: retrievedAt,
+	})
+}
+
+// GetArtistProvenance reports which sources supplied the artist's external
+// metadata, keyed by field, for the admin provenance endpoint. An empty map
+// when the agent is not active or has not looked the artist up.
+func GetArtistProvenance(artistID string) map[string]FieldProvenance {
+	a := mcpConstructor(nil)
+	if a == nil {
+		return map[string]FieldProvenance{}
+	}
+	return a.provenance.forArtist(artistID)
+}
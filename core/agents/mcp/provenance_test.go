@@ -0,0 +1,90 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/navidrome/navidrome/conf/configtest"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("provenanceLog", func() {
+	It("keeps the latest provenance per artist and field", func() {
+		p := newProvenanceLog()
+		p.record("ar-1", "biography", FieldProvenance{Agent: "mcp", Source: "dbpedia"})
+		p.record("ar-1", "biography", FieldProvenance{Agent: "mcp", Source: "wikipedia"})
+		p.record("ar-1", "url", FieldProvenance{Agent: "mcp", Source: "wikipedia"})
+
+		fields := p.forArtist("ar-1")
+		Expect(fields).To(HaveLen(2))
+		Expect(fields["biography"].Source).To(Equal("wikipedia"))
+	})
+
+	It("returns an empty map for unknown artists", func() {
+		Expect(newProvenanceLog().forArtist("nope")).To(BeEmpty())
+	})
+
+	It("bounds the number of tracked artists", func() {
+		p := newProvenanceLog()
+		for i := 0; i < maxProvenanceArtists+10; i++ {
+			p.record(fmt.Sprintf("ar-%d", i), "biography", FieldProvenance{Agent: "mcp"})
+		}
+		Expect(len(p.entries)).To(BeNumerically("<=", maxProvenanceArtists))
+	})
+})
+
+var _ = Describe("provenance recording", func() {
+	var transport *fakeTransport
+	var agent *mcpAgent
+
+	BeforeEach(func() {
+		DeferCleanup(configtest.SetupConfig())
+		transport = newFakeTransport()
+		agent = &mcpAgent{
+			client:     newClient(transport, restartPolicy{}),
+			health:     newToolHealth(),
+			pending:    newRetryQueue(defaultRetryQueueSize),
+			provenance: newProvenanceLog(),
+			plugin:     "mcp-server",
+		}
+	})
+
+	It("records which source supplied the biography, with the retrieval time", func() {
+		transport.handle = func(method string) any {
+			if method == "tools/call" {
+				return map[string]any{
+					"content": []map[string]any{{"type": "text", "text": "a bio"}},
+					"_meta": map[string]any{"attribution": map[string]any{
+						"source":      "wikipedia",
+						"license":     "CC BY-SA 4.0",
+						"retrievedAt": "2024-06-01T12:00:00Z",
+					}},
+				}
+			}
+			return map[string]any{}
+		}
+
+		_, err := agent.GetArtistBiography(context.Background(), "ar-1", "Artist", "mbid-1")
+		Expect(err).ToNot(HaveOccurred())
+
+		fields := agent.provenance.forArtist("ar-1")
+		Expect(fields).To(HaveKey("biography"))
+		Expect(fields["biography"].Agent).To(Equal("mcp"))
+		Expect(fields["biography"].Plugin).To(Equal("mcp-server"))
+		Expect(fields["biography"].Source).To(Equal("wikipedia"))
+		Expect(fields["biography"].RetrievedAt.IsZero()).To(BeFalse())
+	})
+
+	It("records nothing for failed lookups", func() {
+		transport.handle = func(method string) any {
+			if method == "tools/call" {
+				return map[string]any{"content": []map[string]any{}}
+			}
+			return map[string]any{}
+		}
+		_, err := agent.GetArtistBiography(context.Background(), "ar-1", "Artist", "mbid-1")
+		Expect(err).To(HaveOccurred())
+		Expect(agent.provenance.forArtist("ar-1")).To(BeEmpty())
+	})
+})
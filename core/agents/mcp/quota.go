@@ -0,0 +1,88 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// errQuotaExceeded is returned when MCP.MaxRequestsPerHour is exhausted. It is
+// transient: the lookup was never attempted, so the result must not be cached
+// as not-found, and callers may retry once the window resets.
+var errQuotaExceeded = errors.New("mcp: outbound request quota exceeded")
+
+// quotaWindow is the refill period for the outbound request quota.
+const quotaWindow = time.Hour
+
+// requestQuota is a token bucket shared by every tool call and the prefetch
+// job, protecting shared IPs (CGNAT, institutional networks) from tripping
+// upstream rate limits during a first full-library scan. The whole budget
+// refills at once each window: upstream bans count requests per period, not
+// sustained rates.
+type requestQuota struct {
+	now func() time.Time
+
+	mu        sync.Mutex
+	limit     int
+	remaining int
+	resetAt   time.Time
+}
+
+// newRequestQuota builds a quota allowing limit requests per hour, or nil when
+// the limit is zero (unlimited), so callers can skip the check entirely.
+func newRequestQuota(limit int) *requestQuota {
+	if limit <= 0 {
+		return nil
+	}
+	return &requestQuota{limit: limit, now: time.Now}
+}
+
+// acquire takes one token, reporting whether the request may proceed.
+func (q *requestQuota) acquire() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.refill()
+	if q.remaining == 0 {
+		return false
+	}
+	q.remaining--
+	return true
+}
+
+// refill resets the bucket when the window has passed. Callers must hold the
+// lock.
+func (q *requestQuota) refill() {
+	if now := q.now(); !now.Before(q.resetAt) {
+		q.remaining = q.limit
+		q.resetAt = now.Add(quotaWindow)
+	}
+}
+
+// snapshot reports the remaining budget and when it refills, for the status
+// endpoint.
+func (q *requestQuota) snapshot() (int, time.Time) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.refill()
+	return q.remaining, q.resetAt
+}
+
+// waitUntilReset blocks until the current window ends (or the context is
+// cancelled), for callers like the prefetch job that pause rather than fail.
+func (q *requestQuota) waitUntilReset(ctx context.Context) error {
+	q.mu.Lock()
+	d := q.resetAt.Sub(q.now())
+	q.mu.Unlock()
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,58 @@
+package mcp
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("requestQuota", func() {
+	var now time.Time
+	var quota *requestQuota
+
+	BeforeEach(func() {
+		now = time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+		quota = newRequestQuota(2)
+		quota.now = func() time.Time { return now }
+	})
+
+	It("is nil when no limit is configured", func() {
+		Expect(newRequestQuota(0)).To(BeNil())
+	})
+
+	It("refuses requests once the budget is exhausted", func() {
+		Expect(quota.acquire()).To(BeTrue())
+		Expect(quota.acquire()).To(BeTrue())
+		Expect(quota.acquire()).To(BeFalse())
+
+		remaining, resetAt := quota.snapshot()
+		Expect(remaining).To(Equal(0))
+		Expect(resetAt).To(Equal(now.Add(quotaWindow)))
+	})
+
+	It("refills the whole budget when the window passes", func() {
+		Expect(quota.acquire()).To(BeTrue())
+		Expect(quota.acquire()).To(BeTrue())
+		Expect(quota.acquire()).To(BeFalse())
+
+		now = now.Add(quotaWindow)
+		Expect(quota.acquire()).To(BeTrue())
+		remaining, _ := quota.snapshot()
+		Expect(remaining).To(Equal(1))
+	})
+
+	It("does not block waiting for an already-passed window", func() {
+		Expect(quota.acquire()).To(BeTrue())
+		now = now.Add(quotaWindow)
+		Expect(quota.waitUntilReset(context.Background())).To(Succeed())
+	})
+
+	It("stops waiting when the context is cancelled", func() {
+		Expect(quota.acquire()).To(BeTrue())
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		Expect(quota.waitUntilReset(ctx)).To(MatchError(context.Canceled))
+	})
+})
@@ -0,0 +1,39 @@
+package mcp
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/navidrome/navidrome/conf"
+)
+
+// Request-log modes for outbound URLs, set via MCP.RequestLog. Privacy-minded
+// deployments pick hosts-only (which hosts were contacted, but not which
+// artists were looked up) or off.
+const (
+	requestLogOff   = "off"
+	requestLogHosts = "hosts-only"
+	requestLogFull  = "full-url"
+)
+
+// redactedURL stands in for a URL the configured mode does not allow in logs.
+const redactedURL = "[redacted]"
+
+// loggedURL renders an outbound URL for logging according to MCP.RequestLog.
+// The mode is read on every call, so a settings reload applies without a
+// restart. Unparseable URLs are redacted in hosts-only mode rather than risk
+// leaking a query through a malformed value.
+func loggedURL(rawURL string) string {
+	switch strings.ToLower(conf.Server.MCP.RequestLog) {
+	case requestLogOff:
+		return redactedURL
+	case requestLogHosts:
+		u, err := url.Parse(rawURL)
+		if err != nil || u.Host == "" {
+			return redactedURL
+		}
+		return u.Scheme + "://" + u.Host
+	default:
+		return rawURL
+	}
+}
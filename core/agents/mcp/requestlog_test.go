@@ -0,0 +1,42 @@
+package mcp
+
+import (
+	"github.com/navidrome/navidrome/conf"
+	"github.com/navidrome/navidrome/conf/configtest"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("loggedURL", func() {
+	BeforeEach(func() {
+		DeferCleanup(configtest.SetupConfig())
+	})
+
+	It("logs the full URL by default", func() {
+		Expect(loggedURL("https://en.wikipedia.org/wiki/Artist?action=raw")).To(
+			Equal("https://en.wikipedia.org/wiki/Artist?action=raw"))
+	})
+
+	It("redacts paths and queries in hosts-only mode", func() {
+		conf.Server.MCP.RequestLog = "hosts-only"
+		Expect(loggedURL("https://en.wikipedia.org/wiki/Artist?action=raw")).To(
+			Equal("https://en.wikipedia.org"))
+	})
+
+	It("redacts everything in off mode", func() {
+		conf.Server.MCP.RequestLog = "off"
+		Expect(loggedURL("https://en.wikipedia.org/wiki/Artist")).To(Equal(redactedURL))
+	})
+
+	It("redacts values without a host in hosts-only mode", func() {
+		conf.Server.MCP.RequestLog = "hosts-only"
+		Expect(loggedURL("not a url")).To(Equal(redactedURL))
+	})
+
+	It("picks up a mode change without a restart", func() {
+		url := "https://query.wikidata.org/sparql?query=SELECT"
+		Expect(loggedURL(url)).To(Equal(url))
+		conf.Server.MCP.RequestLog = "off"
+		Expect(loggedURL(url)).To(Equal(redactedURL))
+	})
+})
@@ -0,0 +1,68 @@
+package mcp
+
+import (
+	"sync"
+
+	"github.com/navidrome/navidrome/log"
+)
+
+// defaultRetryQueueSize bounds how many rejected lookups are remembered while
+// the server is disabled. MCP.RetryQueueSize overrides it.
+const defaultRetryQueueSize = 100
+
+// retryQueue records lookups that were rejected only because the server
+// disabled itself after repeated start failures. Without it, artists browsed
+// during the outage would permanently miss their metadata: the upper-layer
+// agent cache records the failure and never asks again. Entries are
+// deduplicated and capped; once full, new lookups are dropped.
+type retryQueue struct {
+	max int
+
+	mu      sync.Mutex
+	seen    map[string]struct{}
+	entries []retryEntry
+}
+
+type retryEntry struct {
+	tool string
+	args any
+}
+
+func newRetryQueue(max int) *retryQueue {
+	return &retryQueue{max: max, seen: make(map[string]struct{})}
+}
+
+// add queues a rejected lookup, suppressing duplicates. The dedup key is the
+// tool name plus the marshalled args, so the same artist asked through
+// different tools still queues once per tool.
+func (q *retryQueue) add(tool string, args any) {
+	key := tool + ":" + string(mustMarshal(args))
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if _, ok := q.seen[key]; ok {
+		return
+	}
+	if len(q.entries) >= q.max {
+		log.Trace("MCP retry queue full, dropping lookup", "tool", tool, "max", q.max)
+		return
+	}
+	q.seen[key] = struct{}{}
+	q.entries = append(q.entries, retryEntry{tool: tool, args: args})
+}
+
+// drain removes and returns all queued entries, resetting dedup state so a
+// lookup rejected again after the drain can re-queue.
+func (q *retryQueue) drain() []retryEntry {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	entries := q.entries
+	q.entries = nil
+	q.seen = make(map[string]struct{})
+	return entries
+}
+
+func (q *retryQueue) len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.entries)
+}
@@ -0,0 +1,147 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+
+	"github.com/navidrome/navidrome/conf/configtest"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// switchTransport fails Start while broken, and delegates to a fakeTransport
+// once flipped, simulating a server that gets fixed during an outage.
+type switchTransport struct {
+	*fakeTransport
+	broken bool
+}
+
+func (t *switchTransport) Start(ctx context.Context) (io.WriteCloser, *framingReader, error) {
+	if t.broken {
+		return nil, nil, errors.New("exec: no such file")
+	}
+	return t.fakeTransport.Start(ctx)
+}
+
+var _ = Describe("retryQueue", func() {
+	It("suppresses duplicate lookups", func() {
+		q := newRetryQueue(10)
+		q.add(toolGetArtistBiography, map[string]string{"name": "a"})
+		q.add(toolGetArtistBiography, map[string]string{"name": "a"})
+		q.add(toolGetArtistURL, map[string]string{"name": "a"})
+		Expect(q.len()).To(Equal(2))
+	})
+
+	It("drops new lookups once full", func() {
+		q := newRetryQueue(1)
+		q.add(toolGetArtistBiography, map[string]string{"name": "a"})
+		q.add(toolGetArtistBiography, map[string]string{"name": "b"})
+		Expect(q.len()).To(Equal(1))
+	})
+
+	It("allows re-queueing after a drain", func() {
+		q := newRetryQueue(10)
+		q.add(toolGetArtistBiography, map[string]string{"name": "a"})
+		Expect(q.drain()).To(HaveLen(1))
+		Expect(q.len()).To(BeZero())
+		q.add(toolGetArtistBiography, map[string]string{"name": "a"})
+		Expect(q.len()).To(Equal(1))
+	})
+})
+
+var _ = Describe("deferred retries", func() {
+	var transport *switchTransport
+	var agent *mcpAgent
+
+	BeforeEach(func() {
+		DeferCleanup(configtest.SetupConfig())
+		transport = &switchTransport{fakeTransport: newFakeTransport(), broken: true}
+		transport.handle = func(method string) any {
+			if method == "tools/call" {
+				return map[string]any{"content": []map[string]any{{"type": "text", "text": "a bio"}}}
+			}
+			return map[string]any{}
+		}
+		agent = &mcpAgent{
+			client:  newClient(transport, restartPolicy{}),
+			health:  newToolHealth(),
+			pending: newRetryQueue(defaultRetryQueueSize),
+		}
+		agent.client.maxStartAttempts = 1
+	})
+
+	It("queues breaker-rejected lookups and replays them exactly once", func() {
+		// First call trips the cap; it failed against the real transport, so it
+		// is not queued.
+		_, err := agent.GetArtistBiography(context.Background(), "1", "First Artist", "")
+		Expect(err).To(HaveOccurred())
+		Expect(agent.pending.len()).To(BeZero())
+
+		// Breaker-rejected lookups queue, with duplicates suppressed.
+		for range 2 {
+			_, err = agent.GetArtistBiography(context.Background(), "1", "First Artist", "")
+			Expect(err).To(HaveOccurred())
+		}
+		_, err = agent.GetArtistBiography(context.Background(), "2", "Second Artist", "")
+		Expect(err).To(HaveOccurred())
+		Expect(agent.pending.len()).To(Equal(2))
+		Expect(transport.sentToolCalls()).To(BeEmpty())
+
+		// Fix the server, close the breaker, and replay.
+		transport.broken = false
+		agent.client.reenable()
+		agent.replayPending()
+
+		calls := transport.sentToolCalls()
+		Expect(calls).To(HaveLen(2))
+		var names []string
+		for _, raw := range calls {
+			var params struct {
+				Arguments map[string]string `json:"arguments"`
+			}
+			Expect(json.Unmarshal(raw, &params)).To(Succeed())
+			names = append(names, params.Arguments["name"])
+		}
+		Expect(names).To(ConsistOf("First Artist", "Second Artist"))
+		Expect(agent.pending.len()).To(BeZero())
+	})
+})
+
+var _ = Describe("circuit breaker reset", func() {
+	var transport *switchTransport
+	var agent *mcpAgent
+
+	BeforeEach(func() {
+		DeferCleanup(configtest.SetupConfig())
+		transport = &switchTransport{fakeTransport: newFakeTransport(), broken: true}
+		transport.handle = func(method string) any {
+			if method == "tools/call" {
+				return map[string]any{"content": []map[string]any{{"type": "text", "text": "a bio"}}}
+			}
+			return map[string]any{}
+		}
+		agent = &mcpAgent{
+			client:  newClient(transport, restartPolicy{}),
+			health:  newToolHealth(),
+			pending: newRetryQueue(defaultRetryQueueSize),
+		}
+		agent.client.maxStartAttempts = 1
+	})
+
+	It("reports the breaker state and lets the next call fetch after a reset", func() {
+		_, err := agent.GetArtistBiography(context.Background(), "1", "First Artist", "")
+		Expect(err).To(HaveOccurred())
+		Expect(agent.status().Breaker).To(Equal("open"))
+
+		// Fix the server and close the breaker: the very next call fetches.
+		transport.broken = false
+		agent.reenable()
+		Expect(agent.status().Breaker).To(Equal("closed"))
+
+		bio, err := agent.GetArtistBiography(context.Background(), "1", "First Artist", "")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(bio).To(Equal("a bio"))
+	})
+})
@@ -0,0 +1,16 @@
+//go:build !linux
+
+package mcp
+
+import "github.com/navidrome/navidrome/log"
+
+// applyResourceLimits is only implemented on Linux. On other platforms the
+// configured limits are reported as unavailable, and the server runs
+// unconstrained.
+func applyResourceLimits(_, maxMemoryMB, maxCPUSeconds int) error {
+	if maxMemoryMB > 0 || maxCPUSeconds > 0 {
+		log.Warn("MCP resource limits are not available on this platform", "maxMemoryMB", maxMemoryMB,
+			"maxCPUSeconds", maxCPUSeconds)
+	}
+	return nil
+}
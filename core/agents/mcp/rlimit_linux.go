@@ -0,0 +1,27 @@
+package mcp
+
+import (
+	"fmt"
+
+	"golang.org/x/sys/unix"
+)
+
+// applyResourceLimits caps the child process' address space and CPU time via
+// prlimit, so a misbehaving native server cannot take the host down with it.
+func applyResourceLimits(pid, maxMemoryMB, maxCPUSeconds int) error {
+	if maxMemoryMB > 0 {
+		limit := uint64(maxMemoryMB) * 1024 * 1024
+		rlim := &unix.Rlimit{Cur: limit, Max: limit}
+		if err := unix.Prlimit(pid, unix.RLIMIT_AS, rlim, nil); err != nil {
+			return fmt.Errorf("setting RLIMIT_AS: %w", err)
+		}
+	}
+	if maxCPUSeconds > 0 {
+		limit := uint64(maxCPUSeconds)
+		rlim := &unix.Rlimit{Cur: limit, Max: limit}
+		if err := unix.Prlimit(pid, unix.RLIMIT_CPU, rlim, nil); err != nil {
+			return fmt.Errorf("setting RLIMIT_CPU: %w", err)
+		}
+	}
+	return nil
+}
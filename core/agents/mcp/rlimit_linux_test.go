@@ -0,0 +1,51 @@
+package mcp
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("applyResourceLimits", func() {
+	var cmd *exec.Cmd
+
+	BeforeEach(func() {
+		cmd = exec.Command("sleep", "10")
+		Expect(cmd.Start()).To(Succeed())
+		DeferCleanup(func() {
+			_ = cmd.Process.Kill()
+			_ = cmd.Wait()
+		})
+	})
+
+	childLimits := func() string {
+		data, err := os.ReadFile(fmt.Sprintf("/proc/%d/limits", cmd.Process.Pid))
+		Expect(err).ToNot(HaveOccurred())
+		return string(data)
+	}
+
+	It("sets the address space and CPU rlimits on the child", func() {
+		Expect(applyResourceLimits(cmd.Process.Pid, 100, 30)).To(Succeed())
+
+		limits := childLimits()
+		for _, line := range strings.Split(limits, "\n") {
+			if strings.HasPrefix(line, "Max address space") {
+				Expect(line).To(ContainSubstring(fmt.Sprintf("%d", 100*1024*1024)))
+			}
+			if strings.HasPrefix(line, "Max cpu time") {
+				Expect(line).To(ContainSubstring("30"))
+			}
+		}
+		Expect(limits).To(ContainSubstring("Max address space"))
+	})
+
+	It("leaves limits untouched when unconfigured", func() {
+		before := childLimits()
+		Expect(applyResourceLimits(cmd.Process.Pid, 0, 0)).To(Succeed())
+		Expect(childLimits()).To(Equal(before))
+	})
+})
@@ -0,0 +1,69 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/navidrome/navidrome/conf"
+	"github.com/navidrome/navidrome/core/agents"
+	"github.com/navidrome/navidrome/log"
+)
+
+const selfTestTimeout = 30 * time.Second
+
+// SelfTestResult is the outcome of the startup self-test, for the status
+// endpoint.
+type SelfTestResult struct {
+	Passed  bool      `json:"passed"`
+	Latency string    `json:"latency,omitempty"`
+	Reason  string    `json:"reason,omitempty"`
+	At      time.Time `json:"at,omitzero"`
+}
+
+// runSelfTest validates the whole MCP chain (process, protocol, upstream
+// access) with a one-time biography lookup for a known artist - by default The
+// Beatles; an empty MCP.SelfTestMBID disables it. It runs in the background
+// after the first successful initialize, so it never blocks startup or the
+// call that triggered it.
+func (a *mcpAgent) runSelfTest() {
+	mbid := conf.Server.MCP.SelfTestMBID
+	if mbid == "" {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), selfTestTimeout)
+	defer cancel()
+
+	start := time.Now()
+	_, err := a.GetArtistBiography(ctx, "", "", mbid)
+	latency := time.Since(start).Round(time.Millisecond)
+
+	result := SelfTestResult{At: time.Now()}
+	if err == nil {
+		result.Passed = true
+		result.Latency = latency.String()
+		log.Info("MCP self-test PASS", "mbid", mbid, "latency", latency)
+	} else {
+		result.Reason = selfTestReason(err)
+		log.Warn("MCP self-test FAIL", "mbid", mbid, "reason", result.Reason, "latency", latency, err)
+	}
+
+	a.mu.Lock()
+	a.selfTest = &result
+	a.mu.Unlock()
+}
+
+// selfTestReason categorizes a self-test failure, so the log points at the
+// broken link in the chain instead of a raw error string.
+func selfTestReason(err error) string {
+	switch {
+	case errors.Is(err, agents.ErrNotFound):
+		return "known artist not found - upstream sources unreachable or degraded"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timed out - server or upstream sources too slow"
+	case errors.Is(err, errTransportClosed):
+		return "transport closed - server crashed or was recycled"
+	default:
+		return "tool call failed"
+	}
+}
@@ -0,0 +1,83 @@
+package mcp
+
+import (
+	"context"
+
+	"github.com/navidrome/navidrome/conf"
+	"github.com/navidrome/navidrome/conf/configtest"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("runSelfTest", func() {
+	var transport *fakeTransport
+	var agent *mcpAgent
+
+	BeforeEach(func() {
+		DeferCleanup(configtest.SetupConfig())
+		conf.Server.MCP.SelfTestMBID = "b10bbbfc-cf9e-42e0-be17-e2c3e1d2600d"
+		transport = newFakeTransport()
+		agent = &mcpAgent{
+			client:  newClient(transport, restartPolicy{}),
+			health:  newToolHealth(),
+			pending: newRetryQueue(defaultRetryQueueSize),
+		}
+	})
+
+	It("records a pass with latency when the lookup succeeds", func() {
+		transport.handle = func(method string) any {
+			if method == "tools/call" {
+				return map[string]any{"content": []map[string]any{{"type": "text", "text": "a bio"}}}
+			}
+			return map[string]any{}
+		}
+		agent.runSelfTest()
+
+		result := agent.status().SelfTest
+		Expect(result).ToNot(BeNil())
+		Expect(result.Passed).To(BeTrue())
+		Expect(result.Latency).ToNot(BeEmpty())
+		Expect(result.Reason).To(BeEmpty())
+	})
+
+	It("categorizes a known artist not being found", func() {
+		transport.handle = func(method string) any {
+			if method == "tools/call" {
+				return map[string]any{"content": []map[string]any{}, "isError": true}
+			}
+			return map[string]any{}
+		}
+		agent.runSelfTest()
+
+		result := agent.status().SelfTest
+		Expect(result).ToNot(BeNil())
+		Expect(result.Passed).To(BeFalse())
+		Expect(result.Reason).To(ContainSubstring("known artist not found"))
+	})
+
+	It("does not run when MCP.SelfTestMBID is empty", func() {
+		conf.Server.MCP.SelfTestMBID = ""
+		agent.runSelfTest()
+		Expect(agent.status().SelfTest).To(BeNil())
+	})
+
+	It("runs once in the background after the first initialize", func() {
+		ran := make(chan struct{})
+		transport.handle = func(method string) any {
+			if method == "tools/call" {
+				select {
+				case <-ran:
+				default:
+					close(ran)
+				}
+				return map[string]any{"content": []map[string]any{{"type": "text", "text": "a bio"}}}
+			}
+			return map[string]any{}
+		}
+		agent.client.onReady = agent.runSelfTest
+
+		Expect(agent.client.ensureReady(context.Background())).To(Succeed())
+		Eventually(ran).Should(BeClosed())
+		Eventually(func() *SelfTestResult { return agent.status().SelfTest }).ShouldNot(BeNil())
+	})
+})
@@ -0,0 +1,81 @@
+package mcp
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/navidrome/navidrome/conf"
+)
+
+// AgentState describes where the agent is in its lifecycle, separating "off on
+// purpose" from "broken": deliberately disabled setups log at Info, broken
+// ones at Error, and the status endpoint reports which is which.
+type AgentState string
+
+const (
+	// StateDisabled means MCP was deliberately turned off in the config.
+	StateDisabled AgentState = "disabled"
+	// StateNotConfigured means MCP is enabled but no server path was given.
+	StateNotConfigured AgentState = "notConfigured"
+	// StateConfiguredButMissing means a server path was given, but it does not
+	// point at a usable binary or wasm module.
+	StateConfiguredButMissing AgentState = "configuredButMissing"
+	// StateReady means the agent is active and able to serve lookups.
+	StateReady AgentState = "ready"
+	// StateFailed means the agent gave up at runtime (e.g. the server hit the
+	// restart-attempt cap).
+	StateFailed AgentState = "failed"
+)
+
+var (
+	stateMu     sync.Mutex
+	agentState  AgentState = StateDisabled
+	stateReason string     = "MCP.Enabled is false"
+)
+
+// computeAgentState derives the configuration-time state: whether MCP is off
+// on purpose, unconfigured, misconfigured, or good to go.
+func computeAgentState() (AgentState, string) {
+	if !conf.Server.MCP.Enabled {
+		return StateDisabled, "MCP.Enabled is false"
+	}
+	if !agentListed() {
+		return StateDisabled, `"mcp" is not listed in the Agents config`
+	}
+	if conf.Server.MCP.ServerPath == "" {
+		return StateNotConfigured, "MCP.ServerPath is not set"
+	}
+	if err := validateConfig(); err != nil {
+		return StateConfiguredButMissing, err.Error()
+	}
+	return StateReady, ""
+}
+
+// agentListed reports whether "mcp" appears in conf.Server.Agents. An agent
+// outside that list is never queried, so constructing it anyway - and, for a
+// wasm server, allocating a wazero runtime - would only waste memory.
+func agentListed() bool {
+	for _, name := range strings.Split(conf.Server.Agents, ",") {
+		if strings.TrimSpace(name) == mcpAgentName {
+			return true
+		}
+	}
+	return false
+}
+
+// setAgentState records a state transition; runtime events (successful starts,
+// hitting the restart cap) move the agent between Ready and Failed.
+func setAgentState(state AgentState, reason string) {
+	stateMu.Lock()
+	defer stateMu.Unlock()
+	agentState = state
+	stateReason = reason
+}
+
+// AgentStateInfo returns the current state and the reason behind it, for the
+// status endpoint and the doctor command.
+func AgentStateInfo() (AgentState, string) {
+	stateMu.Lock()
+	defer stateMu.Unlock()
+	return agentState, stateReason
+}
@@ -0,0 +1,81 @@
+package mcp
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	"github.com/navidrome/navidrome/conf"
+	"github.com/navidrome/navidrome/conf/configtest"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("agent state", func() {
+	BeforeEach(func() {
+		DeferCleanup(configtest.SetupConfig())
+	})
+
+	Describe("computeAgentState", func() {
+		It("reports Disabled when MCP is off on purpose", func() {
+			conf.Server.MCP.Enabled = false
+			state, reason := computeAgentState()
+			Expect(state).To(Equal(StateDisabled))
+			Expect(reason).To(ContainSubstring("MCP.Enabled"))
+		})
+
+		It("reports Disabled when the agent is not listed in the Agents config", func() {
+			conf.Server.MCP.Enabled = true
+			conf.Server.Agents = "lastfm,spotify"
+			state, reason := computeAgentState()
+			Expect(state).To(Equal(StateDisabled))
+			Expect(reason).To(ContainSubstring("not listed"))
+		})
+
+		It("reports NotConfigured when enabled without a server path", func() {
+			conf.Server.MCP.Enabled = true
+			conf.Server.Agents = "lastfm, mcp"
+			conf.Server.MCP.ServerPath = ""
+			state, reason := computeAgentState()
+			Expect(state).To(Equal(StateNotConfigured))
+			Expect(reason).To(ContainSubstring("MCP.ServerPath"))
+		})
+
+		It("reports ConfiguredButMissing when the server path is dead", func() {
+			conf.Server.MCP.Enabled = true
+			conf.Server.Agents = "mcp"
+			conf.Server.MCP.ServerPath = "/no/such/mcp-server"
+			state, reason := computeAgentState()
+			Expect(state).To(Equal(StateConfiguredButMissing))
+			Expect(reason).To(ContainSubstring("does not exist"))
+		})
+
+		It("reports Ready for a usable server binary", func() {
+			path := filepath.Join(GinkgoT().TempDir(), "mcp-server")
+			Expect(os.WriteFile(path, []byte("#!/bin/sh\n"), 0o755)).To(Succeed())
+			conf.Server.MCP.Enabled = true
+			conf.Server.Agents = "mcp"
+			conf.Server.MCP.ServerPath = path
+			state, reason := computeAgentState()
+			Expect(state).To(Equal(StateReady))
+			Expect(reason).To(BeEmpty())
+		})
+	})
+
+	Describe("runtime transitions", func() {
+		It("moves to Failed when the restart cap is hit, and back to Ready on re-enable", func() {
+			setAgentState(StateReady, "")
+			c := newClient(&brokenTransport{}, restartPolicy{})
+			c.maxStartAttempts = 1
+
+			_ = c.ensureReady(context.Background())
+			state, reason := AgentStateInfo()
+			Expect(state).To(Equal(StateFailed))
+			Expect(reason).To(ContainSubstring("failed start attempts"))
+
+			c.reenable()
+			state, _ = AgentStateInfo()
+			Expect(state).To(Equal(StateReady))
+		})
+	})
+})
@@ -0,0 +1,162 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+
+	"github.com/navidrome/navidrome/conf"
+	"github.com/navidrome/navidrome/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const statsFileName = "mcp-agent-stats.json"
+
+// transportStats counts protocol-protection events on a transport: stdout
+// lines diverted to the log, frames dropped for being oversized, and transport
+// resets. The counters are zeroed whenever the server is restarted, but the
+// cumulative totals are persisted to a stats file in the data folder.
+type transportStats struct {
+	diverted atomic.Int64
+	dropped  atomic.Int64
+	resets   atomic.Int64
+
+	warnOnce sync.Once
+}
+
+// TransportStats is the JSON representation of the transport counters, used by
+// the status endpoint and the persisted stats file.
+type TransportStats struct {
+	DivertedLines int64 `json:"divertedLines"`
+	DroppedFrames int64 `json:"droppedFrames"`
+	Resets        int64 `json:"resets"`
+}
+
+func (s *transportStats) divertedLine() {
+	s.diverted.Add(1)
+	getMCPMetrics().divertedLines.Inc()
+	s.warnFirstEvent()
+}
+
+func (s *transportStats) droppedFrame() {
+	s.dropped.Add(1)
+	getMCPMetrics().droppedFrames.Inc()
+	s.warnFirstEvent()
+}
+
+// reset records a transport restart: it folds the current counters into the
+// persisted totals and zeroes them for the new server instance.
+func (s *transportStats) reset() {
+	s.resets.Add(1)
+	getMCPMetrics().transportResets.Inc()
+	persistStats(s.snapshot())
+	s.diverted.Store(0)
+	s.dropped.Store(0)
+}
+
+func (s *transportStats) snapshot() TransportStats {
+	return TransportStats{
+		DivertedLines: s.diverted.Load(),
+		DroppedFrames: s.dropped.Load(),
+		Resets:        s.resets.Load(),
+	}
+}
+
+// warnFirstEvent emits a single Warn the first time any counter becomes
+// non-zero, so noisy servers are reported without flooding the logs.
+func (s *transportStats) warnFirstEvent() {
+	s.warnOnce.Do(func() {
+		snap := s.snapshot()
+		log.Warn("MCP server is misbehaving on stdout - some output was diverted or dropped",
+			"divertedLines", snap.DivertedLines, "droppedFrames", snap.DroppedFrames)
+	})
+}
+
+// persistStats merges the given counters into the cumulative totals stored in
+// the stats file. Failures are only logged: stats are best-effort. A read-only
+// data folder disables persistence altogether instead of warning on every
+// restart.
+func persistStats(snap TransportStats) {
+	if !persistence.enabled() {
+		return
+	}
+	path := filepath.Join(conf.Server.DataFolder, statsFileName)
+	totals := loadPersistedStats()
+	totals.DivertedLines += snap.DivertedLines
+	totals.DroppedFrames += snap.DroppedFrames
+	totals.Resets++
+	data, err := json.Marshal(totals)
+	if err == nil {
+		err = os.WriteFile(path, data, 0600)
+	}
+	if persistence.disableIfReadOnly(err) {
+		return
+	}
+	if err != nil {
+		log.Warn("Could not persist MCP transport stats", "path", path, err)
+	}
+}
+
+func loadPersistedStats() TransportStats {
+	var totals TransportStats
+	path := filepath.Join(conf.Server.DataFolder, statsFileName)
+	data, err := os.ReadFile(path)
+	if err == nil {
+		_ = json.Unmarshal(data, &totals)
+	}
+	return totals
+}
+
+type mcpMetrics struct {
+	divertedLines   prometheus.Counter
+	droppedFrames   prometheus.Counter
+	transportResets prometheus.Counter
+	orphanResponses prometheus.Counter
+	toolDegraded    *prometheus.GaugeVec
+	toolCalls       *prometheus.CounterVec
+}
+
+var getMCPMetrics = sync.OnceValue(func() *mcpMetrics {
+	instance := &mcpMetrics{
+		divertedLines: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "mcp_transport_diverted_lines",
+			Help: "Total stdout lines from the MCP server diverted to the log",
+		}),
+		droppedFrames: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "mcp_transport_dropped_frames",
+			Help: "Total oversized frames dropped from the MCP server stdout",
+		}),
+		transportResets: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "mcp_transport_resets",
+			Help: "Total MCP transport resets",
+		}),
+		orphanResponses: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "mcp_orphan_responses_total",
+			Help: "Total MCP server responses matching no outstanding request",
+		}),
+		toolDegraded: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "mcp_tool_degraded",
+			Help: "Whether an MCP tool is currently considered degraded (1) or healthy (0)",
+		}, []string{"tool"}),
+		toolCalls: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "mcp_tool_calls_total",
+			Help: "Total MCP tool calls, by tool and requesting user (hashed if MCP.AnonymizeUsers is set)",
+		}, []string{"tool", "user"}),
+	}
+	for name, c := range map[string]prometheus.Collector{
+		"mcp_transport_diverted_lines": instance.divertedLines,
+		"mcp_transport_dropped_frames": instance.droppedFrames,
+		"mcp_transport_resets":         instance.transportResets,
+		"mcp_orphan_responses_total":   instance.orphanResponses,
+		"mcp_tool_degraded":            instance.toolDegraded,
+		"mcp_tool_calls_total":         instance.toolCalls,
+	} {
+		if err := prometheus.DefaultRegisterer.Register(c); err != nil {
+			log.Fatal("Unable to create Prometheus metric instance", fmt.Errorf("unable to register %s metrics: %w", name, err))
+		}
+	}
+	return instance
+})
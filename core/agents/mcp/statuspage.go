@@ -0,0 +1,92 @@
+package mcp
+
+import (
+	"slices"
+)
+
+// The status endpoint returns everything the agent tracks, and its per-tool
+// maps grow with every routed plugin and catalog entry. Rather than ship the
+// raw lists in full on every poll, the endpoint serves a limit/offset window
+// over each one and always attaches server-side summary counts, so clients
+// can read the shape of the state without the lists at all.
+
+// maxStatusPageSize hard-caps the limit query param; a client cannot ask for
+// more than this many entries per list, however large the state grows.
+const maxStatusPageSize = 1000
+
+// defaultStatusPageSize is the window used when the client sends no limit.
+const defaultStatusPageSize = 100
+
+// StatusSummary carries counts computed over the full status lists, before
+// any paging, so totals stay correct whatever window was requested.
+type StatusSummary struct {
+	KnownTools      int `json:"knownTools"`
+	Tools           int `json:"tools"`
+	DegradedTools   int `json:"degradedTools"`
+	SuspiciousTools int `json:"suspiciousTools"`
+	Attributions    int `json:"attributions"`
+}
+
+func summarize(s Status) *StatusSummary {
+	sum := &StatusSummary{
+		KnownTools:   len(s.KnownTools),
+		Tools:        len(s.Tools),
+		Attributions: len(s.Attributions),
+	}
+	for _, th := range s.Tools {
+		if th.Degraded {
+			sum.DegradedTools++
+		}
+		if th.Suspicious {
+			sum.SuspiciousTools++
+		}
+	}
+	return sum
+}
+
+// pageWindow clamps a limit/offset pair against a list length: a zero or
+// negative limit means the default, anything above the cap is the cap.
+func pageWindow(total, limit, offset int) (int, int) {
+	if limit <= 0 {
+		limit = defaultStatusPageSize
+	}
+	limit = min(limit, maxStatusPageSize)
+	offset = max(offset, 0)
+	start := min(offset, total)
+	end := min(start+limit, total)
+	return start, end
+}
+
+// pageMap reduces a map to the requested window over its sorted keys, so
+// successive pages are stable and do not overlap.
+func pageMap[V any](m map[string]V, limit, offset int) map[string]V {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	start, end := pageWindow(len(keys), limit, offset)
+	paged := make(map[string]V, end-start)
+	for _, k := range keys[start:end] {
+		paged[k] = m[k]
+	}
+	return paged
+}
+
+// pageStatus applies the window to every list in the status and attaches the
+// summary, computed before paging. The scalar fields pass through untouched.
+func pageStatus(s Status, limit, offset int) Status {
+	s.Summary = summarize(s)
+	start, end := pageWindow(len(s.KnownTools), limit, offset)
+	s.KnownTools = s.KnownTools[start:end]
+	s.Tools = pageMap(s.Tools, limit, offset)
+	s.Attributions = pageMap(s.Attributions, limit, offset)
+	return s
+}
+
+// GetStatusPage is GetStatus with the lists reduced to the requested
+// limit/offset window (hard-capped at maxStatusPageSize) and summary counts
+// attached, for the status endpoint.
+func GetStatusPage(limit, offset int) Status {
+	return pageStatus(GetStatus(), limit, offset)
+}
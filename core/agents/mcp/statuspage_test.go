@@ -0,0 +1,80 @@
+package mcp
+
+import (
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("status paging", func() {
+	bigStatus := func(n int) Status {
+		s := Status{
+			Tools:        map[string]ToolHealth{},
+			Attributions: map[string]Attribution{},
+		}
+		for i := 0; i < n; i++ {
+			name := fmt.Sprintf("tool-%06d", i)
+			s.KnownTools = append(s.KnownTools, name)
+			s.Tools[name] = ToolHealth{Degraded: i%10 == 0, Suspicious: i%100 == 0}
+			s.Attributions[name] = Attribution{Source: "wikipedia"}
+		}
+		return s
+	}
+
+	It("windows every list by limit and offset", func() {
+		paged := pageStatus(bigStatus(50), 10, 20)
+		Expect(paged.KnownTools).To(HaveLen(10))
+		Expect(paged.KnownTools[0]).To(Equal("tool-000020"))
+		Expect(paged.Tools).To(HaveLen(10))
+		Expect(paged.Tools).To(HaveKey("tool-000020"))
+		Expect(paged.Attributions).To(HaveLen(10))
+	})
+
+	It("pages stably, without gaps or overlaps", func() {
+		first := pageStatus(bigStatus(30), 20, 0)
+		second := pageStatus(bigStatus(30), 20, 20)
+		Expect(first.KnownTools).To(HaveLen(20))
+		Expect(second.KnownTools).To(HaveLen(10))
+		Expect(append(first.KnownTools, second.KnownTools...)).To(Equal(bigStatus(30).KnownTools))
+	})
+
+	It("caps the limit regardless of what the client asks for", func() {
+		paged := pageStatus(bigStatus(maxStatusPageSize+500), 100000, 0)
+		Expect(paged.KnownTools).To(HaveLen(maxStatusPageSize))
+		Expect(paged.Tools).To(HaveLen(maxStatusPageSize))
+	})
+
+	It("falls back to the default window without a limit", func() {
+		paged := pageStatus(bigStatus(defaultStatusPageSize+50), 0, 0)
+		Expect(paged.KnownTools).To(HaveLen(defaultStatusPageSize))
+	})
+
+	It("tolerates offsets past the end", func() {
+		paged := pageStatus(bigStatus(5), 10, 99)
+		Expect(paged.KnownTools).To(BeEmpty())
+		Expect(paged.Tools).To(BeEmpty())
+	})
+
+	It("summarizes over the full lists, not the page", func() {
+		paged := pageStatus(bigStatus(200), 10, 0)
+		Expect(paged.Summary).ToNot(BeNil())
+		Expect(paged.Summary.KnownTools).To(Equal(200))
+		Expect(paged.Summary.Tools).To(Equal(200))
+		Expect(paged.Summary.Attributions).To(Equal(200))
+		Expect(paged.Summary.DegradedTools).To(Equal(20))
+		Expect(paged.Summary.SuspiciousTools).To(Equal(2))
+	})
+
+	It("stays fast with very large synthetic state", Label("slow"), func() {
+		s := bigStatus(100_000)
+		start := time.Now()
+		paged := pageStatus(s, maxStatusPageSize, 50_000)
+		elapsed := time.Since(start)
+		Expect(paged.KnownTools).To(HaveLen(maxStatusPageSize))
+		// Generous budget: paging must stay cheap even at 100k entries, but a
+		// loaded CI worker should not flake this.
+		Expect(elapsed).To(BeNumerically("<", 500*time.Millisecond))
+	})
+})
@@ -0,0 +1,354 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/navidrome/navidrome/conf"
+	"github.com/navidrome/navidrome/log"
+)
+
+// maxFrameSize is the largest JSON-RPC frame accepted from a server. Anything
+// bigger is discarded, as it is more likely runaway output than a real response.
+const maxFrameSize = 10 * 1024 * 1024
+
+// transport abstracts how the MCP server process is run and wired to the
+// client. Implementations exist for a native child process and, eventually,
+// for other execution models.
+type transport interface {
+	// Start launches the server and returns its stdin and a framed view of
+	// its stdout. Start is called again after Close to respawn the server.
+	Start(ctx context.Context) (io.WriteCloser, *framingReader, error)
+	Close() error
+	// Stats returns the counters tracking protocol-protection events for
+	// this transport.
+	Stats() *transportStats
+	// memoryMB reports the server's current memory usage, or 0 when it
+	// cannot be measured on this platform or execution model.
+	memoryMB() int
+	// kind names the execution model ("native" or "wasm"), used to pick
+	// budgets suited to how the transport starts.
+	kind() string
+}
+
+// framingReader reads newline-delimited frames from a server's stdout and
+// protects the JSON-RPC stream from misbehaving servers: lines that are not
+// JSON-RPC frames (e.g. stray prints from the server or its libraries) are
+// diverted to the log, and frames larger than maxFrameSize are dropped. Both
+// events are counted in the transport stats.
+type framingReader struct {
+	r     *bufio.Reader
+	stats *transportStats
+}
+
+func newFramingReader(r io.Reader, stats *transportStats) *framingReader {
+	return &framingReader{r: bufio.NewReaderSize(r, 64*1024), stats: stats}
+}
+
+// nextFrame returns the next JSON-RPC frame, skipping over diverted and
+// dropped data. It only returns an error when the underlying stream fails.
+func (f *framingReader) nextFrame() ([]byte, error) {
+	for {
+		line, tooLong, err := f.readLine()
+		if err != nil {
+			return nil, err
+		}
+		if tooLong {
+			f.stats.droppedFrame()
+			continue
+		}
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		if !looksLikeJSONRPC(line) {
+			f.stats.divertedLine()
+			log.Debug("MCP server wrote to stdout outside the protocol", "line", sanitizeLogLine(string(truncateLine(line))))
+			continue
+		}
+		return line, nil
+	}
+}
+
+// readLine reads a full line, reporting (and consuming) lines that exceed
+// maxFrameSize instead of returning them.
+func (f *framingReader) readLine() (line []byte, tooLong bool, err error) {
+	var buf []byte
+	for {
+		chunk, isPrefix, err := f.r.ReadLine()
+		if err != nil {
+			return nil, false, err
+		}
+		if tooLong {
+			if !isPrefix {
+				return nil, true, nil
+			}
+			continue
+		}
+		buf = append(buf, chunk...)
+		if len(buf) > maxFrameSize {
+			buf = nil
+			tooLong = true
+		}
+		if !isPrefix {
+			return buf, tooLong, nil
+		}
+	}
+}
+
+func looksLikeJSONRPC(line []byte) bool {
+	return bytes.HasPrefix(line, []byte("{")) && bytes.Contains(line, []byte(`"jsonrpc"`))
+}
+
+func truncateLine(line []byte) []byte {
+	const maxLogged = 200
+	if len(line) > maxLogged {
+		return line[:maxLogged]
+	}
+	return line
+}
+
+// newTransport picks the execution model for the configured server: a .wasm
+// path runs in-process via wazero, anything else is spawned as a native child
+// process. The cache backs the wasm module's http_fetch host function; native
+// servers cache on their own side.
+func newTransport(serverPath string, cache Cache) transport {
+	if strings.HasSuffix(serverPath, ".wasm") {
+		t := newWasmTransport(serverPath)
+		t.hostOpts = &hostFunctionOptions{cache: cache}
+		return t
+	}
+	return newStdioTransport(serverPath)
+}
+
+// stdioTransport runs the MCP server as a native child process, talking to it
+// over its stdin/stdout pipes.
+type stdioTransport struct {
+	serverPath string
+
+	mu  sync.Mutex
+	cmd *exec.Cmd
+	// stdin and stdout are our ends of the child's pipes, kept so Close can
+	// release them; otherwise each restart leaks two descriptors until GC.
+	stdin  *os.File
+	stdout *os.File
+
+	stats transportStats
+}
+
+func newStdioTransport(serverPath string) *stdioTransport {
+	return &stdioTransport{serverPath: serverPath}
+}
+
+// newPipe creates the stdio pipes for the server process. It is a seam for
+// tests to inject pipe-creation failures.
+var newPipe = os.Pipe
+
+func (t *stdioTransport) Start(ctx context.Context) (io.WriteCloser, *framingReader, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.cmd != nil {
+		return nil, nil, fmt.Errorf("mcp: server %s already running", t.serverPath)
+	}
+	// The pipes are created explicitly (instead of via cmd.StdinPipe and
+	// friends) so every partial-failure path can close both ends of whatever
+	// was already created, and nothing leaks under fd exhaustion.
+	stdinR, stdinW, err := newPipe()
+	if err != nil {
+		return nil, nil, wrapFDExhaustion(err)
+	}
+	stdoutR, stdoutW, err := newPipe()
+	if err != nil {
+		closeFiles(stdinR, stdinW)
+		return nil, nil, wrapFDExhaustion(err)
+	}
+	cmd := exec.Command(t.serverPath)
+	cmd.Stdin = stdinR
+	cmd.Stdout = stdoutW
+	cmd.Stderr = newServerLogWriter(filepath.Base(t.serverPath))
+	// The native server does its own HTTP; hand it the same User-Agent the
+	// host uses, so all outbound MCP traffic identifies itself consistently.
+	cmd.Env = append(os.Environ(), "MCP_USER_AGENT="+mcpUserAgent())
+	if err := cmd.Start(); err != nil {
+		closeFiles(stdinR, stdinW, stdoutR, stdoutW)
+		return nil, nil, wrapFDExhaustion(err)
+	}
+	// The child's ends are duplicated into the process; our copies must go, or
+	// the stdout pipe never reports EOF when the server dies.
+	closeFiles(stdinR, stdoutW)
+	if err := applyResourceLimits(cmd.Process.Pid, conf.Server.MCP.MaxMemoryMB, conf.Server.MCP.MaxCPUSeconds); err != nil {
+		log.Error("Could not apply resource limits to MCP server process", "path", t.serverPath,
+			"pid", cmd.Process.Pid, err)
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+		closeFiles(stdinW, stdoutR)
+		return nil, nil, err
+	}
+	t.cmd = cmd
+	t.stdin = stdinW
+	t.stdout = stdoutR
+	log.Debug("Started MCP server process", "path", t.serverPath, "pid", cmd.Process.Pid)
+	return stdinW, newFramingReader(stdoutR, &t.stats), nil
+}
+
+func closeFiles(files ...*os.File) {
+	for _, f := range files {
+		_ = f.Close()
+	}
+}
+
+// wrapFDExhaustion makes running out of file descriptors actionable, instead
+// of surfacing a bare "too many open files".
+func wrapFDExhaustion(err error) error {
+	if errors.Is(err, syscall.EMFILE) || errors.Is(err, syscall.ENFILE) {
+		return fmt.Errorf("mcp: cannot start server, out of file descriptors "+
+			"(consider raising the open files limit, e.g. ulimit -n): %w", err)
+	}
+	return err
+}
+
+// nativeShutdownGrace is how long shutdown waits for the server to exit after
+// SIGTERM before escalating to SIGKILL. A variable so tests can shorten it.
+var nativeShutdownGrace = 2 * time.Second
+
+// shutdown stops the server for application exit: stdin is closed (the
+// protocol-level stop signal) and a SIGTERM sent, and only a server that
+// ignores both for nativeShutdownGrace is killed. Unlike Close, which serves
+// the restart path and must be instant, shutdown always reaps the child, so
+// no zombie outlives the agent.
+func (t *stdioTransport) shutdown() error {
+	t.mu.Lock()
+	cmd := t.cmd
+	if cmd == nil {
+		t.mu.Unlock()
+		return nil
+	}
+	stdin, stdout := t.stdin, t.stdout
+	t.cmd = nil
+	t.stdin = nil
+	t.stdout = nil
+	t.stats.reset()
+	t.mu.Unlock()
+	closeFiles(stdin)
+	_ = cmd.Process.Signal(syscall.SIGTERM)
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+	select {
+	case <-done:
+	case <-time.After(nativeShutdownGrace):
+		log.Warn("MCP server ignored SIGTERM, killing it", "path", t.serverPath, "pid", cmd.Process.Pid)
+		_ = cmd.Process.Kill()
+		<-done
+	}
+	closeFiles(stdout)
+	log.Debug("Stopped MCP server process", "path", t.serverPath, "pid", cmd.Process.Pid)
+	return nil
+}
+
+func (t *stdioTransport) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.cmd == nil {
+		return nil
+	}
+	t.stats.reset()
+	err := t.cmd.Process.Kill()
+	_ = t.cmd.Wait()
+	// Closing our pipe ends unblocks the read loop with EOF and returns the
+	// descriptors now, instead of whenever the GC gets to them.
+	closeFiles(t.stdin, t.stdout)
+	t.cmd = nil
+	t.stdin = nil
+	t.stdout = nil
+	return err
+}
+
+func (t *stdioTransport) Stats() *transportStats {
+	return &t.stats
+}
+
+func (t *stdioTransport) memoryMB() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.cmd == nil || t.cmd.Process == nil {
+		return 0
+	}
+	return processMemoryMB(t.cmd.Process.Pid)
+}
+
+func (t *stdioTransport) kind() string {
+	return "native"
+}
+
+// serverLogWriter forwards the server's stderr to Navidrome's log, line by
+// line. The content is server-controlled, so every line is sanitized and
+// attributed to the plugin in a structured field - never spliced into the
+// message - before it reaches the log.
+type serverLogWriter struct {
+	plugin string
+	buf    bytes.Buffer
+}
+
+func newServerLogWriter(plugin string) *serverLogWriter {
+	return &serverLogWriter{plugin: plugin}
+}
+
+func (w *serverLogWriter) Write(p []byte) (int, error) {
+	w.buf.Write(p)
+	for {
+		line, err := w.buf.ReadString('\n')
+		if err != nil {
+			w.buf.WriteString(line)
+			break
+		}
+		log.Debug("MCP server output", "plugin", w.plugin, "line", sanitizeLogLine(line[:len(line)-1]))
+	}
+	return len(p), nil
+}
+
+// maxForwardedLineLen caps a forwarded server log line; anything longer is
+// more likely runaway output than a message worth keeping whole.
+const maxForwardedLineLen = 512
+
+// sanitizeLogLine makes a server-controlled line safe to forward: ANSI escape
+// sequences and control characters are stripped (a hostile plugin could
+// otherwise move the cursor or fake log records), and the line is capped at
+// maxForwardedLineLen. Embedded newlines count as control characters, so a
+// multi-line payload cannot start a line of its own.
+func sanitizeLogLine(line string) string {
+	var b strings.Builder
+	b.Grow(min(len(line), maxForwardedLineLen))
+	esc := false
+	for _, r := range line {
+		switch {
+		case esc:
+			// Inside an escape sequence: a CSI sequence ends on a final byte
+			// in @..~, anything else is a single-character sequence.
+			if r == '[' || r == ';' || (r >= '0' && r <= '9') || r == '?' {
+				continue
+			}
+			esc = false
+		case r == 0x1b:
+			esc = true
+		case r < 0x20 || r == 0x7f:
+			// Drop control characters, including \r and embedded \n.
+		default:
+			b.WriteRune(r)
+			if b.Len() >= maxForwardedLineLen {
+				return b.String() + "..."
+			}
+		}
+	}
+	return b.String()
+}
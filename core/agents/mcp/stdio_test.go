@@ -0,0 +1,199 @@
+package mcp
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/navidrome/navidrome/conf"
+	"github.com/navidrome/navidrome/conf/configtest"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("framingReader", func() {
+	var stats *transportStats
+
+	BeforeEach(func() {
+		stats = &transportStats{}
+	})
+
+	read := func(input string) []string {
+		fr := newFramingReader(strings.NewReader(input), stats)
+		var frames []string
+		for {
+			frame, err := fr.nextFrame()
+			if err != nil {
+				Expect(err).To(MatchError(io.EOF))
+				return frames
+			}
+			frames = append(frames, string(frame))
+		}
+	}
+
+	It("passes JSON-RPC frames through", func() {
+		frames := read(`{"jsonrpc":"2.0","id":1,"result":{}}` + "\n")
+		Expect(frames).To(ConsistOf(`{"jsonrpc":"2.0","id":1,"result":{}}`))
+		Expect(stats.snapshot()).To(Equal(TransportStats{}))
+	})
+
+	It("diverts stray stdout lines and counts them", func() {
+		input := "server booting...\n" +
+			`{"jsonrpc":"2.0","id":1,"result":{}}` + "\n" +
+			"some debug print\n"
+		frames := read(input)
+		Expect(frames).To(ConsistOf(`{"jsonrpc":"2.0","id":1,"result":{}}`))
+		Expect(stats.snapshot().DivertedLines).To(Equal(int64(2)))
+		Expect(stats.snapshot().DroppedFrames).To(BeZero())
+	})
+
+	It("diverts non-frame JSON lines", func() {
+		frames := read(`{"not":"a frame"}` + "\n")
+		Expect(frames).To(BeEmpty())
+		Expect(stats.snapshot().DivertedLines).To(Equal(int64(1)))
+	})
+
+	It("drops oversized frames and counts them", func() {
+		huge := `{"jsonrpc":"2.0","id":1,"result":"` + strings.Repeat("x", maxFrameSize) + `"}`
+		input := huge + "\n" + `{"jsonrpc":"2.0","id":2,"result":{}}` + "\n"
+		frames := read(input)
+		Expect(frames).To(ConsistOf(`{"jsonrpc":"2.0","id":2,"result":{}}`))
+		Expect(stats.snapshot().DroppedFrames).To(Equal(int64(1)))
+		Expect(stats.snapshot().DivertedLines).To(BeZero())
+	})
+
+	It("skips blank lines without counting them", func() {
+		frames := read("\n\n" + `{"jsonrpc":"2.0","id":1,"result":{}}` + "\n")
+		Expect(frames).To(HaveLen(1))
+		Expect(stats.snapshot()).To(Equal(TransportStats{}))
+	})
+})
+
+var _ = Describe("sanitizeLogLine", func() {
+	It("passes ordinary text through unchanged", func() {
+		Expect(sanitizeLogLine("looked up artist Sigur Rós")).To(Equal("looked up artist Sigur Rós"))
+	})
+
+	It("strips ANSI escape sequences", func() {
+		Expect(sanitizeLogLine("\x1b[31mERROR\x1b[0m something \x1b[2J")).To(Equal("ERROR something "))
+	})
+
+	It("strips control characters", func() {
+		Expect(sanitizeLogLine("be\x07ep\rdone\x7f")).To(Equal("beepdone"))
+	})
+
+	It("keeps an embedded newline from starting a fake log record", func() {
+		Expect(sanitizeLogLine("ok\nlevel=error msg=forged")).To(Equal("oklevel=error msg=forged"))
+	})
+
+	It("caps runaway lines", func() {
+		sanitized := sanitizeLogLine(strings.Repeat("x", 10*maxForwardedLineLen))
+		Expect(len(sanitized)).To(BeNumerically("<=", maxForwardedLineLen+3))
+		Expect(sanitized).To(HaveSuffix("..."))
+	})
+})
+
+var _ = Describe("stdioTransport.shutdown", func() {
+	BeforeEach(func() {
+		// shutdown persists transport stats; keep them out of the repo tree.
+		DeferCleanup(configtest.SetupConfig())
+		conf.Server.DataFolder = GinkgoT().TempDir()
+	})
+
+	// reaped asserts the child is fully gone - not just dead, but waited on,
+	// so no zombie outlives the transport.
+	reaped := func(pid int) {
+		Eventually(func() error {
+			return syscall.Kill(pid, 0)
+		}).Should(MatchError(syscall.ESRCH))
+	}
+
+	It("stops a long-running server and leaves no zombie", func() {
+		t := newStdioTransport("/bin/cat") // runs until stdin closes or SIGTERM
+		_, _, err := t.Start(context.Background())
+		Expect(err).ToNot(HaveOccurred())
+		pid := t.cmd.Process.Pid
+
+		Expect(t.shutdown()).To(Succeed())
+		reaped(pid)
+		Expect(t.cmd).To(BeNil())
+	})
+
+	It("escalates to SIGKILL when the server ignores SIGTERM", func() {
+		script := filepath.Join(GinkgoT().TempDir(), "stubborn")
+		Expect(os.WriteFile(script, []byte("#!/bin/sh\ntrap '' TERM\nsleep 60\n"), 0o755)).To(Succeed())
+		oldGrace := nativeShutdownGrace
+		nativeShutdownGrace = 100 * time.Millisecond
+		DeferCleanup(func() { nativeShutdownGrace = oldGrace })
+
+		t := newStdioTransport(script)
+		_, _, err := t.Start(context.Background())
+		Expect(err).ToNot(HaveOccurred())
+		pid := t.cmd.Process.Pid
+		// Give the shell a moment to install the TERM trap.
+		time.Sleep(50 * time.Millisecond)
+
+		Expect(t.shutdown()).To(Succeed())
+		reaped(pid)
+	})
+
+	It("is a no-op when the server never started", func() {
+		t := newStdioTransport("/bin/cat")
+		Expect(t.shutdown()).To(Succeed())
+		Expect(t.Close()).To(Succeed())
+	})
+})
+
+var _ = Describe("stdioTransport.Start failure paths", func() {
+	// openFDs counts this process's open file descriptors, to assert that no
+	// partial-failure path leaks any.
+	openFDs := func() int {
+		entries, err := os.ReadDir("/proc/self/fd")
+		if err != nil {
+			Skip("/proc/self/fd not available on this platform")
+		}
+		return len(entries)
+	}
+
+	// failPipeAt makes pipe creation fail with EMFILE on the nth call.
+	failPipeAt := func(n int) {
+		calls := 0
+		realPipe := newPipe
+		newPipe = func() (*os.File, *os.File, error) {
+			calls++
+			if calls == n {
+				return nil, nil, &os.SyscallError{Syscall: "pipe", Err: syscall.EMFILE}
+			}
+			return realPipe()
+		}
+		DeferCleanup(func() { newPipe = realPipe })
+	}
+
+	It("surfaces fd exhaustion on the first pipe with an actionable message", func() {
+		failPipeAt(1)
+		before := openFDs()
+		_, _, err := newStdioTransport("/bin/true").Start(context.Background())
+		Expect(err).To(MatchError(ContainSubstring("open files limit")))
+		Expect(err).To(MatchError(syscall.EMFILE))
+		Expect(openFDs()).To(Equal(before))
+	})
+
+	It("closes the first pipe when the second one fails", func() {
+		failPipeAt(2)
+		before := openFDs()
+		_, _, err := newStdioTransport("/bin/true").Start(context.Background())
+		Expect(err).To(MatchError(syscall.EMFILE))
+		Expect(openFDs()).To(Equal(before))
+	})
+
+	It("closes both pipes when the process cannot be started", func() {
+		before := openFDs()
+		_, _, err := newStdioTransport("/nonexistent/mcp-server").Start(context.Background())
+		Expect(err).To(HaveOccurred())
+		Expect(openFDs()).To(Equal(before))
+	})
+})
@@ -0,0 +1,3 @@
+module github.com/navidrome/navidrome/core/agents/mcp/testplugin
+
+go 1.21
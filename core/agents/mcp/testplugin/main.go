@@ -0,0 +1,142 @@
+// testplugin is a minimal reference MCP server for the wasm execution model.
+// It exists for two audiences: plugin authors looking for the smallest working
+// example of the host ABI (stdio JSON-RPC plus the "navidrome" host module),
+// and the integration tests, which load it through the real wasm transport to
+// validate the ABI end-to-end without network access.
+//
+// Build it with GOOS=wasip1 GOARCH=wasm (see the testplugin Makefile target).
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"unsafe"
+)
+
+// httpFetch is the host-provided HTTP entry point: WASI has no sockets, so
+// all outbound HTTP goes through the host. It returns the number of body
+// bytes written to buf, or a negative error code.
+//
+//go:wasmimport navidrome http_fetch
+func httpFetch(urlPtr, urlLen, bodyPtr, bodyCap uint32) int32
+
+// httpFetchHdrs is http_fetch with extra request headers, serialized as
+// newline-delimited "Name: value" pairs. A zero-length blob means none.
+//
+//go:wasmimport navidrome http_fetch_hdrs
+func httpFetchHdrs(urlPtr, urlLen, hdrPtr, hdrLen, bodyPtr, bodyCap uint32) int32
+
+type message struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      *int64          `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+func main() {
+	out := bufio.NewWriter(os.Stdout)
+	scanner := bufio.NewScanner(os.Stdin)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var msg message
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil || msg.ID == nil {
+			continue // Notifications and junk need no reply
+		}
+		reply(out, *msg.ID, handle(&msg))
+	}
+}
+
+func handle(msg *message) any {
+	switch msg.Method {
+	case "initialize":
+		return map[string]any{
+			"protocolVersion": "2024-11-05",
+			"serverInfo":      map[string]string{"name": "testplugin", "version": "0.0.1"},
+			"capabilities":    map[string]any{"tools": map[string]any{}},
+		}
+	case "tools/list":
+		return map[string]any{"tools": []map[string]any{
+			{"name": "echo", "description": "Echo the input text back"},
+			{"name": "fetch_url", "description": "Fetch a URL through the host's http_fetch"},
+		}}
+	case "tools/call":
+		return callTool(msg.Params)
+	default:
+		return map[string]any{}
+	}
+}
+
+func callTool(params json.RawMessage) any {
+	var call struct {
+		Name      string `json:"name"`
+		Arguments struct {
+			Text    string `json:"text"`
+			URL     string `json:"url"`
+			Headers string `json:"headers"` // Newline-delimited "Name: value" pairs
+		} `json:"arguments"`
+	}
+	if err := json.Unmarshal(params, &call); err != nil {
+		return errorResult(err.Error())
+	}
+	switch call.Name {
+	case "echo":
+		fmt.Fprintf(os.Stderr, "echoing %d bytes\n", len(call.Arguments.Text))
+		return textResult(call.Arguments.Text)
+	case "fetch_url":
+		body, code := fetch(call.Arguments.URL, call.Arguments.Headers)
+		if code != 0 {
+			return errorResult(fmt.Sprintf("http_fetch failed with code %d", code))
+		}
+		return textResult(string(body))
+	default:
+		return errorResult("unknown tool " + call.Name)
+	}
+}
+
+// fetch calls the host's http_fetch with the URL and a 1MiB response buffer,
+// or http_fetch_hdrs when extra request headers are given.
+func fetch(url, headers string) ([]byte, int32) {
+	if url == "" {
+		return nil, -1
+	}
+	urlBytes := []byte(url)
+	buf := make([]byte, 1<<20)
+	var n int32
+	if headers == "" {
+		n = httpFetch(
+			uint32(uintptr(unsafe.Pointer(&urlBytes[0]))), uint32(len(urlBytes)),
+			uint32(uintptr(unsafe.Pointer(&buf[0]))), uint32(len(buf)))
+	} else {
+		hdrBytes := []byte(headers)
+		n = httpFetchHdrs(
+			uint32(uintptr(unsafe.Pointer(&urlBytes[0]))), uint32(len(urlBytes)),
+			uint32(uintptr(unsafe.Pointer(&hdrBytes[0]))), uint32(len(hdrBytes)),
+			uint32(uintptr(unsafe.Pointer(&buf[0]))), uint32(len(buf)))
+	}
+	if n < 0 {
+		return nil, n
+	}
+	return buf[:n], 0
+}
+
+func textResult(text string) any {
+	return map[string]any{"content": []map[string]any{{"type": "text", "text": text}}}
+}
+
+func errorResult(text string) any {
+	result := textResult(text).(map[string]any)
+	result["isError"] = true
+	return result
+}
+
+func reply(out *bufio.Writer, id int64, result any) {
+	data, err := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": id, "result": result})
+	if err != nil {
+		return
+	}
+	out.Write(data)
+	out.WriteByte('\n')
+	out.Flush()
+}
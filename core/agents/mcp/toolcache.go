@@ -0,0 +1,75 @@
+package mcp
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/navidrome/navidrome/conf"
+	"github.com/navidrome/navidrome/log"
+)
+
+const toolCacheFileName = "mcp-tools-cache.json"
+
+// cachedToolList is the persisted result of tools/list discovery, keyed by the
+// plugin file hash: a byte-identical plugin cannot report a different catalog,
+// so the cached list is safe to reuse for capability gating before the first
+// initialize handshake completes.
+type cachedToolList struct {
+	Hash  string   `json:"hash"`
+	Tools []string `json:"tools"`
+}
+
+func toolCachePath() string {
+	return filepath.Join(conf.Server.DataFolder, toolCacheFileName)
+}
+
+// pluginFileHash returns the hex SHA-256 of the plugin file.
+func pluginFileHash(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// loadCachedToolList returns the tool list recorded for the given plugin hash,
+// or nil when there is no usable cache: missing file, unreadable JSON, or a
+// list discovered from a different plugin version.
+func loadCachedToolList(hash string) []string {
+	data, err := os.ReadFile(toolCachePath())
+	if err != nil {
+		return nil
+	}
+	var cached cachedToolList
+	if err := json.Unmarshal(data, &cached); err != nil || cached.Hash != hash {
+		return nil
+	}
+	return cached.Tools
+}
+
+// saveCachedToolList persists the discovered tool list for the plugin hash.
+// Best-effort, and off entirely when the data folder is read-only.
+func saveCachedToolList(hash string, tools []string) {
+	if !persistence.enabled() {
+		return
+	}
+	data, err := json.Marshal(cachedToolList{Hash: hash, Tools: tools})
+	if err == nil {
+		err = os.WriteFile(toolCachePath(), data, 0600)
+	}
+	if persistence.disableIfReadOnly(err) {
+		return
+	}
+	if err != nil {
+		log.Warn("Could not persist MCP tool list cache", "path", toolCachePath(), err)
+	}
+}
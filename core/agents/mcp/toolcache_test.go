@@ -0,0 +1,104 @@
+package mcp
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	"github.com/navidrome/navidrome/conf"
+	"github.com/navidrome/navidrome/conf/configtest"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("tool list cache", func() {
+	BeforeEach(func() {
+		DeferCleanup(configtest.SetupConfig())
+		conf.Server.DataFolder = GinkgoT().TempDir()
+		persistence = &persistenceState{}
+		DeferCleanup(func() { persistence = &persistenceState{} })
+	})
+
+	writePlugin := func(content string) string {
+		path := filepath.Join(GinkgoT().TempDir(), "mcp-server")
+		Expect(os.WriteFile(path, []byte(content), 0o700)).To(Succeed())
+		return path
+	}
+
+	Describe("pluginFileHash", func() {
+		It("changes when the plugin file changes", func() {
+			h1, err := pluginFileHash(writePlugin("version one"))
+			Expect(err).ToNot(HaveOccurred())
+			h2, err := pluginFileHash(writePlugin("version two"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(h1).ToNot(Equal(h2))
+		})
+
+		It("fails for a missing file", func() {
+			_, err := pluginFileHash("/no/such/plugin")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	It("reuses the cached list when the plugin hash matches", func() {
+		saveCachedToolList("hash-1", []string{"get_artist_biography", "get_artist_url"})
+		Expect(loadCachedToolList("hash-1")).To(Equal([]string{"get_artist_biography", "get_artist_url"}))
+	})
+
+	It("ignores the cache when the plugin hash differs", func() {
+		saveCachedToolList("hash-1", []string{"get_artist_biography"})
+		Expect(loadCachedToolList("hash-2")).To(BeNil())
+	})
+
+	It("ignores a corrupt cache file", func() {
+		Expect(os.WriteFile(toolCachePath(), []byte("not json"), 0o600)).To(Succeed())
+		Expect(loadCachedToolList("hash-1")).To(BeNil())
+	})
+
+	It("does not write the cache when persistence is disabled", func() {
+		persistence.disabled = true
+		saveCachedToolList("hash-1", []string{"get_artist_biography"})
+		Expect(toolCachePath()).ToNot(BeAnExistingFile())
+	})
+})
+
+var _ = Describe("client tool catalog", func() {
+	BeforeEach(func() {
+		DeferCleanup(configtest.SetupConfig())
+	})
+
+	It("supports everything while the catalog is unknown", func() {
+		c := newClient(newFakeTransport(), restartPolicy{})
+		Expect(c.supportsTool("get_artist_biography")).To(BeTrue())
+	})
+
+	It("gates calls on a known catalog", func() {
+		c := newClient(newFakeTransport(), restartPolicy{})
+		c.setKnownTools([]string{"get_artist_url", "get_artist_biography"})
+		Expect(c.supportsTool("get_artist_biography")).To(BeTrue())
+		Expect(c.supportsTool("get_artist_awards")).To(BeFalse())
+		Expect(c.knownToolList()).To(Equal([]string{"get_artist_biography", "get_artist_url"}))
+	})
+
+	It("discovers the catalog from tools/list after initialize", func() {
+		transport := newFakeTransport()
+		transport.handle = func(method string) any {
+			if method == "tools/list" {
+				return map[string]any{"tools": []map[string]any{
+					{"name": "get_artist_url"},
+					{"name": "get_artist_biography"},
+				}}
+			}
+			return map[string]any{}
+		}
+		c := newClient(transport, restartPolicy{})
+		DeferCleanup(c.reset)
+
+		// Seeded from a stale cache: the post-initialize refresh diverges.
+		c.setKnownTools([]string{"get_artist_biography", "get_artist_thumbnail"})
+		tools, err := c.listTools(context.Background())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(tools).To(Equal([]string{"get_artist_biography", "get_artist_url"}))
+		Expect(tools).ToNot(Equal(c.knownToolList()))
+	})
+})
@@ -0,0 +1,86 @@
+package mcp
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/navidrome/navidrome/conf"
+	"github.com/navidrome/navidrome/log"
+)
+
+var wasmMagic = []byte("\x00asm")
+
+// validateConfig checks the MCP configuration before the agent is used, so
+// misconfiguration surfaces once, at startup, with an actionable message -
+// instead of lazily on the first lookup. A non-nil error means the agent is
+// unusable and should stay disabled.
+func validateConfig() error {
+	serverPath := conf.Server.MCP.ServerPath
+	if serverPath == "" {
+		return fmt.Errorf("MCP.ServerPath must point to the mcp-server binary or wasm module")
+	}
+	info, err := os.Stat(serverPath)
+	if os.IsNotExist(err) {
+		return fmt.Errorf("MCP.ServerPath %q does not exist", serverPath)
+	}
+	if err != nil {
+		return fmt.Errorf("MCP.ServerPath %q is not readable: %w", serverPath, err)
+	}
+	if info.IsDir() {
+		return fmt.Errorf("MCP.ServerPath %q is a directory, expected a binary or wasm module", serverPath)
+	}
+	if strings.HasSuffix(serverPath, ".wasm") {
+		if err := validateWasmFile(serverPath); err != nil {
+			return err
+		}
+	} else if info.Mode().Perm()&0111 == 0 {
+		return fmt.Errorf("MCP.ServerPath %q is not executable", serverPath)
+	}
+	if t := conf.Server.MCP.SearchURLTemplate; t != "" {
+		if err := validateSearchURLTemplate(t); err != nil {
+			return err
+		}
+	}
+	if conf.Server.MCP.StartupTimeout < 0 || conf.Server.MCP.InitTimeout < 0 {
+		log.Warn("Negative MCP timeout configured, using defaults",
+			"startupTimeout", conf.Server.MCP.StartupTimeout, "initTimeout", conf.Server.MCP.InitTimeout)
+	}
+	return nil
+}
+
+// validateSearchURLTemplate checks a MCP.SearchURLTemplate value: it must
+// contain a {query} placeholder and expand to an https URL. The server applies
+// the same rules and would silently ignore a template failing them, so a bad
+// value is better rejected here, at config load.
+func validateSearchURLTemplate(template string) error {
+	if !strings.Contains(template, "{query}") {
+		return fmt.Errorf("MCP.SearchURLTemplate %q does not contain a {query} placeholder", template)
+	}
+	u, err := url.Parse(strings.ReplaceAll(template, "{query}", "probe"))
+	if err != nil {
+		return fmt.Errorf("MCP.SearchURLTemplate %q is not a valid URL: %w", template, err)
+	}
+	if u.Scheme != "https" {
+		return fmt.Errorf("MCP.SearchURLTemplate %q must use https", template)
+	}
+	return nil
+}
+
+// validateWasmFile checks that the file actually is a wasm module, catching
+// the common mistake of pointing a .wasm path at a native binary (or vice
+// versa, a truncated download).
+func validateWasmFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("MCP.ServerPath %q is not readable: %w", path, err)
+	}
+	defer f.Close()
+	magic := make([]byte, 4)
+	if _, err := f.Read(magic); err != nil || !bytes.Equal(magic, wasmMagic) {
+		return fmt.Errorf("MCP.ServerPath %q has a .wasm extension but is not a wasm module", path)
+	}
+	return nil
+}
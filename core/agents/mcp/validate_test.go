@@ -0,0 +1,86 @@
+package mcp
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/navidrome/navidrome/conf"
+	"github.com/navidrome/navidrome/conf/configtest"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("validateConfig", func() {
+	var dir string
+
+	BeforeEach(func() {
+		DeferCleanup(configtest.SetupConfig())
+		dir = GinkgoT().TempDir()
+	})
+
+	It("rejects an empty server path", func() {
+		conf.Server.MCP.ServerPath = ""
+		Expect(validateConfig()).To(MatchError(ContainSubstring("must point to the mcp-server")))
+	})
+
+	It("rejects a missing server path", func() {
+		conf.Server.MCP.ServerPath = filepath.Join(dir, "nope")
+		Expect(validateConfig()).To(MatchError(ContainSubstring("does not exist")))
+	})
+
+	It("rejects a directory", func() {
+		conf.Server.MCP.ServerPath = dir
+		Expect(validateConfig()).To(MatchError(ContainSubstring("is a directory")))
+	})
+
+	It("rejects a non-executable native binary", func() {
+		path := filepath.Join(dir, "mcp-server")
+		Expect(os.WriteFile(path, []byte("#!/bin/sh\n"), 0600)).To(Succeed())
+		conf.Server.MCP.ServerPath = path
+		Expect(validateConfig()).To(MatchError(ContainSubstring("is not executable")))
+	})
+
+	It("rejects a .wasm file without the wasm magic", func() {
+		path := filepath.Join(dir, "mcp-server.wasm")
+		Expect(os.WriteFile(path, []byte("ELF..."), 0600)).To(Succeed())
+		conf.Server.MCP.ServerPath = path
+		Expect(validateConfig()).To(MatchError(ContainSubstring("is not a wasm module")))
+	})
+
+	It("accepts a valid wasm module", func() {
+		path := filepath.Join(dir, "mcp-server.wasm")
+		Expect(os.WriteFile(path, []byte("\x00asm\x01\x00\x00\x00"), 0600)).To(Succeed())
+		conf.Server.MCP.ServerPath = path
+		Expect(validateConfig()).To(Succeed())
+	})
+
+	It("accepts an executable native binary", func() {
+		path := filepath.Join(dir, "mcp-server")
+		Expect(os.WriteFile(path, []byte("#!/bin/sh\n"), 0700)).To(Succeed())
+		conf.Server.MCP.ServerPath = path
+		Expect(validateConfig()).To(Succeed())
+	})
+
+	Describe("search URL template", func() {
+		BeforeEach(func() {
+			path := filepath.Join(dir, "mcp-server")
+			Expect(os.WriteFile(path, []byte("#!/bin/sh\n"), 0700)).To(Succeed())
+			conf.Server.MCP.ServerPath = path
+		})
+
+		It("accepts a DuckDuckGo template", func() {
+			conf.Server.MCP.SearchURLTemplate = "https://duckduckgo.com/?q={query}"
+			Expect(validateConfig()).To(Succeed())
+		})
+
+		It("rejects a template without the {query} placeholder", func() {
+			conf.Server.MCP.SearchURLTemplate = "https://duckduckgo.com/?q=fixed"
+			Expect(validateConfig()).To(MatchError(ContainSubstring("{query} placeholder")))
+		})
+
+		It("rejects a non-https template", func() {
+			conf.Server.MCP.SearchURLTemplate = "http://duckduckgo.com/?q={query}"
+			Expect(validateConfig()).To(MatchError(ContainSubstring("must use https")))
+		})
+	})
+})
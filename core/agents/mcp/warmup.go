@@ -0,0 +1,89 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/navidrome/navidrome/log"
+)
+
+// defaultWarmupWorkers bounds how many targets warm up concurrently. Two is
+// enough to overlap slow starts without saturating small machines.
+const defaultWarmupWorkers = 2
+
+// warmupTarget is one thing to start during boot warm-up. Today there is a
+// single configured server, but the pool is written for N targets so adding
+// more does not change the startup path.
+type warmupTarget struct {
+	name  string
+	start func(ctx context.Context) error
+}
+
+type warmupResult struct {
+	name string
+	took time.Duration
+	err  error
+}
+
+// warmUp starts every target using a bounded worker pool. Each target fails
+// independently: one broken server neither delays nor aborts the others.
+// Cancelling the context stops the pool cleanly - targets not yet started are
+// recorded with the context's error instead of being run.
+func warmUp(ctx context.Context, targets []warmupTarget, workers int) []warmupResult {
+	if workers <= 0 {
+		workers = defaultWarmupWorkers
+	}
+	results := make([]warmupResult, len(targets))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				target := targets[i]
+				results[i].name = target.name
+				if err := ctx.Err(); err != nil {
+					results[i].err = err
+					continue
+				}
+				start := time.Now()
+				results[i].err = target.start(ctx)
+				results[i].took = time.Since(start)
+			}
+		}()
+	}
+	for i := range targets {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return results
+}
+
+// warmupSummary renders all results as a single line, so the whole warm-up
+// lands in one log entry.
+func warmupSummary(results []warmupResult) string {
+	parts := make([]string, len(results))
+	for i, r := range results {
+		status := "ok"
+		if r.err != nil {
+			status = "failed: " + r.err.Error()
+		}
+		parts[i] = fmt.Sprintf("%s: %s (%s)", r.name, status, r.took.Round(time.Millisecond))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// warmUpAtBoot eagerly starts the server instead of waiting for the first
+// lookup, for deployments that prefer paying the startup cost at boot.
+func (a *mcpAgent) warmUpAtBoot(ctx context.Context) {
+	targets := []warmupTarget{
+		{name: "mcp-server", start: a.client.ensureReady},
+	}
+	results := warmUp(ctx, targets, defaultWarmupWorkers)
+	log.Info(ctx, "MCP warm-up complete", "summary", warmupSummary(results))
+}
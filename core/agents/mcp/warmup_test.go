@@ -0,0 +1,84 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("warmUp", func() {
+	It("runs targets concurrently, bounded by the worker count", func() {
+		started := make(chan string, 4)
+		release := make(chan struct{})
+		slow := func(name string) warmupTarget {
+			return warmupTarget{name: name, start: func(context.Context) error {
+				started <- name
+				<-release
+				return nil
+			}}
+		}
+		targets := []warmupTarget{slow("one"), slow("two"), slow("three")}
+
+		done := make(chan []warmupResult, 1)
+		go func() { done <- warmUp(context.Background(), targets, 2) }()
+
+		// Two targets start right away; the third must wait for a free worker.
+		Eventually(started).Should(HaveLen(2))
+		Consistently(started).Should(HaveLen(2))
+		release <- struct{}{}
+		Eventually(started).Should(HaveLen(3))
+		close(release)
+
+		results := <-done
+		Expect(results).To(HaveLen(3))
+		for _, r := range results {
+			Expect(r.err).ToNot(HaveOccurred())
+		}
+	})
+
+	It("fails targets independently", func() {
+		boom := errors.New("boom")
+		targets := []warmupTarget{
+			{name: "bad", start: func(context.Context) error { return boom }},
+			{name: "good", start: func(context.Context) error { return nil }},
+		}
+		results := warmUp(context.Background(), targets, 1)
+		Expect(results[0].err).To(MatchError(boom))
+		Expect(results[1].err).ToNot(HaveOccurred())
+	})
+
+	It("cancels cleanly, never starting the remaining targets", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		var secondRan atomic.Bool
+		targets := []warmupTarget{
+			{name: "blocking", start: func(ctx context.Context) error {
+				cancel()
+				<-ctx.Done()
+				return ctx.Err()
+			}},
+			{name: "never", start: func(context.Context) error {
+				secondRan.Store(true)
+				return nil
+			}},
+		}
+
+		results := warmUp(ctx, targets, 1)
+		Expect(results[0].err).To(MatchError(context.Canceled))
+		Expect(results[1].err).To(MatchError(context.Canceled))
+		Expect(secondRan.Load()).To(BeFalse())
+	})
+})
+
+var _ = Describe("warmupSummary", func() {
+	It("renders all targets on a single line", func() {
+		results := []warmupResult{
+			{name: "one", took: 1500 * time.Millisecond},
+			{name: "two", took: 10 * time.Millisecond, err: errors.New("exec: not found")},
+		}
+		Expect(warmupSummary(results)).To(Equal("one: ok (1.5s), two: failed: exec: not found (10ms)"))
+	})
+})
@@ -0,0 +1,210 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/navidrome/navidrome/log"
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+	"github.com/tetratelabs/wazero/sys"
+)
+
+// mcpABIVersion is the stdio/host ABI version this host implements. Modules
+// declare the version they were built against in the abiSectionName custom
+// section, and the host refuses to run modules built for a different version.
+const mcpABIVersion = 1
+
+// abiSectionName is the custom section in the wasm binary holding the ABI
+// version, as an ASCII decimal number.
+const abiSectionName = "navidrome:mcp-abi"
+
+// wasmTransport runs the MCP server as a WASI module inside the process,
+// using wazero. The module's stdin/stdout are wired to in-process pipes and
+// speak the same newline-delimited JSON-RPC protocol as the native transport.
+type wasmTransport struct {
+	modulePath string
+	hostOpts   *hostFunctionOptions
+
+	mu      sync.Mutex
+	runtime wazero.Runtime
+	done    chan struct{}
+	// stdin is our reader end of the module's stdin pipe. Close closes it to
+	// unblock a module parked in a stdin read; the instantiation goroutine
+	// closes it when the module exits, so a concurrent client write fails
+	// instead of blocking forever on a pipe nobody reads anymore.
+	stdin *io.PipeReader
+
+	stats transportStats
+}
+
+func newWasmTransport(modulePath string) *wasmTransport {
+	return &wasmTransport{modulePath: modulePath}
+}
+
+func (t *wasmTransport) Start(ctx context.Context) (io.WriteCloser, *framingReader, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.runtime != nil {
+		return nil, nil, fmt.Errorf("mcp: wasm module %s already running", t.modulePath)
+	}
+	wasmBytes, err := os.ReadFile(t.modulePath)
+	if err != nil {
+		return nil, nil, wrapFDExhaustion(err)
+	}
+
+	// Custom sections are needed to read the module's declared ABI version.
+	// The compilation cache is shared with every other wasm transport in the
+	// process and held until Close.
+	cfg := wazero.NewRuntimeConfig().WithCustomSections(true).WithCompilationCache(wasmCache.acquire())
+	r := wazero.NewRuntimeWithConfig(ctx, cfg)
+	fail := func(err error) (io.WriteCloser, *framingReader, error) {
+		_ = r.Close(ctx)
+		wasmCache.release()
+		return nil, nil, err
+	}
+	if _, err := wasi_snapshot_preview1.Instantiate(ctx, r); err != nil {
+		return fail(err)
+	}
+	if err := registerHostFunctions(ctx, r, t.hostOpts); err != nil {
+		return fail(err)
+	}
+	compiled, err := r.CompileModule(ctx, wasmBytes)
+	if err != nil {
+		return fail(fmt.Errorf("mcp: compiling wasm module: %w", err))
+	}
+	if err := checkABIVersion(compiled); err != nil {
+		return fail(err)
+	}
+	if err := checkModuleImports(compiled); err != nil {
+		return fail(err)
+	}
+
+	stdinR, stdinW := io.Pipe()
+	stdoutR, stdoutW := io.Pipe()
+	modCfg := wazero.NewModuleConfig().
+		WithName("mcp-server").
+		WithStdin(stdinR).
+		WithStdout(stdoutW).
+		WithStderr(newServerLogWriter(filepath.Base(t.modulePath)))
+
+	t.runtime = r
+	t.done = make(chan struct{})
+	t.stdin = stdinR
+	go func() {
+		defer close(t.done)
+		_, err := r.InstantiateModule(context.WithoutCancel(ctx), compiled, modCfg)
+		if exitErr, ok := err.(*sys.ExitError); ok && exitErr.ExitCode() == 0 {
+			err = nil
+		}
+		if err != nil {
+			log.Warn("MCP wasm module exited with error", "module", t.modulePath, err)
+		}
+		_ = stdinR.Close()
+		_ = stdoutW.Close()
+	}()
+	log.Debug("Started MCP server wasm module", "module", t.modulePath)
+	return stdinW, newFramingReader(stdoutR, &t.stats), nil
+}
+
+func (t *wasmTransport) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.runtime == nil {
+		return nil
+	}
+	t.stats.reset()
+	// Closing the runtime does not interrupt a host read already parked on the
+	// stdin pipe; closing our end does, so the module cannot keep the
+	// instantiation goroutine (and this Close) waiting forever.
+	_ = t.stdin.Close()
+	err := t.runtime.Close(context.Background())
+	<-t.done
+	t.runtime = nil
+	t.stdin = nil
+	// Only now, with the module definitely gone, is it safe to drop our hold
+	// on the shared compilation cache; the nil runtime above makes a second
+	// Close a no-op, so the hold is dropped exactly once per Start.
+	wasmCache.release()
+	return err
+}
+
+func (t *wasmTransport) Stats() *transportStats {
+	return &t.stats
+}
+
+// memoryMB is not measurable for an in-process wasm module, as its memory is
+// part of the Navidrome process.
+func (t *wasmTransport) memoryMB() int {
+	return 0
+}
+
+func (t *wasmTransport) kind() string {
+	return "wasm"
+}
+
+// allowedHostFunctions are the functions the host exports in the "navidrome"
+// module. Anything else a module asks for is a capability this host does not
+// grant.
+var allowedHostFunctions = []string{"http_fetch", "http_fetch_hdrs", "http_fetch_len", "http_head"}
+
+// checkModuleImports validates the module's imports against what this host
+// actually grants: the WASI preview 1 subset without sockets, plus the
+// "navidrome" host functions. Listing every disallowed import up front beats
+// the generic unresolved-import error at instantiation, and doubles as an
+// early warning for modules compiled against a newer host ABI.
+func checkModuleImports(compiled wazero.CompiledModule) error {
+	var disallowed []string
+	for _, def := range compiled.ImportedFunctions() {
+		module, name, ok := def.Import()
+		if !ok {
+			continue
+		}
+		switch {
+		case module == wasi_snapshot_preview1.ModuleName && !strings.HasPrefix(name, "sock_"):
+			continue
+		case module == hostModuleName && slices.Contains(allowedHostFunctions, name):
+			continue
+		}
+		disallowed = append(disallowed, module+"."+name)
+	}
+	if len(disallowed) > 0 {
+		return fmt.Errorf("mcp: module imports capabilities this host does not grant: %s - "+
+			"rebuild the module without them, or update Navidrome if they belong to a newer host ABI",
+			strings.Join(disallowed, ", "))
+	}
+	return nil
+}
+
+// checkABIVersion validates the module's declared ABI version against the one
+// this host implements. Modules without the section predate versioning and
+// are assumed to be version 1.
+func checkABIVersion(compiled wazero.CompiledModule) error {
+	for _, section := range compiled.CustomSections() {
+		if section.Name() != abiSectionName {
+			continue
+		}
+		raw := strings.TrimSpace(string(section.Data()))
+		version, err := strconv.Atoi(raw)
+		if err != nil {
+			return fmt.Errorf("mcp: module declares malformed ABI version %q in section %q", raw, abiSectionName)
+		}
+		if version != mcpABIVersion {
+			return fmt.Errorf("mcp: module ABI version %d is not compatible with this host (version %d) - "+
+				"update the module or Navidrome so they match", version, mcpABIVersion)
+		}
+		return nil
+	}
+	log.Debug("MCP wasm module does not declare an ABI version, assuming version 1", "section", abiSectionName)
+	if mcpABIVersion != 1 {
+		return fmt.Errorf("mcp: module does not declare an ABI version and this host requires version %d", mcpABIVersion)
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package mcp
+
+//go:generate sh -c "cd testplugin && GOOS=wasip1 GOARCH=wasm go build -o testplugin.wasm ."
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"github.com/navidrome/navidrome/conf"
+	"github.com/navidrome/navidrome/conf/configtest"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// buildTestPlugin builds the reference wasm plugin (or reuses a previous
+// build), so the suite exercises the real wasm transport and host ABI without
+// network access.
+func buildTestPlugin() string {
+	path := filepath.Join("testplugin", "testplugin.wasm")
+	if _, err := os.Stat(path); err == nil {
+		return path
+	}
+	cmd := exec.Command("go", "build", "-o", "testplugin.wasm", ".")
+	cmd.Dir = "testplugin"
+	cmd.Env = append(os.Environ(), "GOOS=wasip1", "GOARCH=wasm")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		Skip("cannot build test plugin: " + err.Error() + "\n" + string(out))
+	}
+	return path
+}
+
+var _ = Describe("wasm integration", Label("slow"), func() {
+	var c *client
+	var upstream *httptest.Server
+
+	BeforeEach(func() {
+		DeferCleanup(configtest.SetupConfig())
+		conf.Server.DataFolder = GinkgoT().TempDir()
+		path := buildTestPlugin()
+		upstream = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = w.Write([]byte("hello from host"))
+		}))
+		DeferCleanup(upstream.Close)
+
+		transport := newWasmTransport(path)
+		transport.hostOpts = &hostFunctionOptions{client: upstream.Client()}
+		c = newClient(transport, restartPolicy{})
+		DeferCleanup(func() { c.reset() })
+	})
+
+	callText := func(tool string, args map[string]string) string {
+		ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+		defer cancel()
+		resp, err := c.callTool(ctx, tool, args)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(resp.IsError).To(BeFalse(), "tool returned an error: %+v", resp.Content)
+		Expect(resp.Content).ToNot(BeEmpty())
+		return resp.Content[0].Text
+	}
+
+	It("round-trips the echo tool through the real wasm transport", func() {
+		Expect(callText("echo", map[string]string{"text": "round-trip"})).To(Equal("round-trip"))
+	})
+
+	It("fetches over the host's http_fetch", func() {
+		Expect(callText("fetch_url", map[string]string{"url": upstream.URL})).To(Equal("hello from host"))
+	})
+})
@@ -0,0 +1,122 @@
+package mcp
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/tetratelabs/wazero"
+)
+
+var _ = Describe("checkABIVersion", func() {
+	var ctx context.Context
+	var runtime wazero.Runtime
+
+	BeforeEach(func() {
+		ctx = GinkgoT().Context()
+		runtime = wazero.NewRuntimeWithConfig(ctx, wazero.NewRuntimeConfig().WithCustomSections(true))
+		DeferCleanup(func() { _ = runtime.Close(ctx) })
+	})
+
+	// minimalModule builds an empty but valid wasm binary, optionally with an
+	// ABI version custom section.
+	minimalModule := func(abiSection ...string) []byte {
+		mod := []byte("\x00asm\x01\x00\x00\x00")
+		for _, version := range abiSection {
+			payload := append([]byte{byte(len(abiSectionName))}, abiSectionName...)
+			payload = append(payload, version...)
+			mod = append(mod, 0x00, byte(len(payload)))
+			mod = append(mod, payload...)
+		}
+		return mod
+	}
+
+	compile := func(wasmBytes []byte) wazero.CompiledModule {
+		compiled, err := runtime.CompileModule(ctx, wasmBytes)
+		Expect(err).ToNot(HaveOccurred())
+		return compiled
+	}
+
+	It("accepts a module declaring the host's ABI version", func() {
+		compiled := compile(minimalModule("1"))
+		Expect(checkABIVersion(compiled)).To(Succeed())
+	})
+
+	It("rejects a module declaring a different ABI version", func() {
+		compiled := compile(minimalModule("2"))
+		err := checkABIVersion(compiled)
+		Expect(err).To(MatchError(ContainSubstring("ABI version 2 is not compatible")))
+	})
+
+	It("rejects a module with a malformed ABI version", func() {
+		compiled := compile(minimalModule("one"))
+		err := checkABIVersion(compiled)
+		Expect(err).To(MatchError(ContainSubstring("malformed ABI version")))
+	})
+
+	It("assumes version 1 when the module declares no version", func() {
+		compiled := compile(minimalModule())
+		Expect(checkABIVersion(compiled)).To(Succeed())
+	})
+})
+
+var _ = Describe("checkModuleImports", func() {
+	var ctx context.Context
+	var runtime wazero.Runtime
+
+	BeforeEach(func() {
+		ctx = GinkgoT().Context()
+		runtime = wazero.NewRuntime(ctx)
+		DeferCleanup(func() { _ = runtime.Close(ctx) })
+	})
+
+	// moduleImporting builds a valid wasm binary importing the given ()->()
+	// functions, each given as "module.name".
+	moduleImporting := func(imports ...[2]string) []byte {
+		mod := []byte("\x00asm\x01\x00\x00\x00")
+		mod = append(mod, 0x01, 0x04, 0x01, 0x60, 0x00, 0x00) // type section: ()->()
+		payload := []byte{byte(len(imports))}
+		for _, imp := range imports {
+			payload = append(payload, byte(len(imp[0])))
+			payload = append(payload, imp[0]...)
+			payload = append(payload, byte(len(imp[1])))
+			payload = append(payload, imp[1]...)
+			payload = append(payload, 0x00, 0x00) // func import, type 0
+		}
+		mod = append(mod, 0x02, byte(len(payload)))
+		return append(mod, payload...)
+	}
+
+	compile := func(wasmBytes []byte) wazero.CompiledModule {
+		compiled, err := runtime.CompileModule(ctx, wasmBytes)
+		Expect(err).ToNot(HaveOccurred())
+		return compiled
+	}
+
+	It("accepts WASI and granted host functions", func() {
+		compiled := compile(moduleImporting(
+			[2]string{"wasi_snapshot_preview1", "proc_exit"},
+			[2]string{"navidrome", "http_fetch"},
+			[2]string{"navidrome", "http_head"},
+		))
+		Expect(checkModuleImports(compiled)).To(Succeed())
+	})
+
+	It("rejects imports the host does not grant, listing each one", func() {
+		compiled := compile(moduleImporting(
+			[2]string{"wasi_snapshot_preview1", "sock_accept"},
+			[2]string{"env", "dangerous"},
+			[2]string{"navidrome", "http_fetch"},
+		))
+		err := checkModuleImports(compiled)
+		Expect(err).To(MatchError(ContainSubstring("wasi_snapshot_preview1.sock_accept")))
+		Expect(err).To(MatchError(ContainSubstring("env.dangerous")))
+		Expect(err.Error()).ToNot(ContainSubstring("http_fetch"))
+	})
+
+	It("rejects unknown navidrome host functions, as from a newer ABI", func() {
+		compiled := compile(moduleImporting([2]string{"navidrome", "http_post"}))
+		err := checkModuleImports(compiled)
+		Expect(err).To(MatchError(ContainSubstring("navidrome.http_post")))
+	})
+})
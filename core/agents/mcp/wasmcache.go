@@ -0,0 +1,69 @@
+package mcp
+
+import (
+	"context"
+	"sync"
+
+	"github.com/navidrome/navidrome/log"
+	"github.com/tetratelabs/wazero"
+)
+
+// Several wasm modules can run at once when MCP.ToolServers routes tools to
+// different servers. Each transport keeps its own wazero.Runtime - closing a
+// runtime is how a transport kills its module, so sharing one would tie every
+// module's lifetime to the first Close. What they can safely share is the
+// compilation cache, which lets a second module (or a restart of the first)
+// reuse already-compiled code instead of recompiling from scratch.
+
+// newCompilationCache is a seam for tests to observe the shared cache's
+// lifecycle.
+var newCompilationCache = wazero.NewCompilationCache
+
+// sharedWasmCache hands the process-wide compilation cache to wasm transports,
+// reference counted: the first acquire creates the cache and the last release
+// closes it. A transport holds a reference for as long as its module runs, so
+// one module going away never closes the cache out from under another.
+type sharedWasmCache struct {
+	mu    sync.Mutex
+	refs  int
+	cache wazero.CompilationCache
+}
+
+// wasmCache is the instance every wasm transport in the process draws from.
+var wasmCache sharedWasmCache
+
+// acquire returns the shared cache, creating it for the first holder. Every
+// acquire must be paired with exactly one release.
+func (s *sharedWasmCache) acquire() wazero.CompilationCache {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.refs == 0 {
+		s.cache = newCompilationCache()
+	}
+	s.refs++
+	return s.cache
+}
+
+// release drops one holder, closing the cache when the last one is gone. An
+// unbalanced release is a caller bug; it is logged and ignored, so a double
+// Close cannot close a cache other holders are still using.
+func (s *sharedWasmCache) release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.refs == 0 {
+		log.Error("MCP wasm compilation cache released more times than acquired")
+		return
+	}
+	s.refs--
+	if s.refs == 0 {
+		_ = s.cache.Close(context.Background())
+		s.cache = nil
+	}
+}
+
+// holders reports the current reference count, for tests.
+func (s *sharedWasmCache) holders() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.refs
+}
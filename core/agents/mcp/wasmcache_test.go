@@ -0,0 +1,92 @@
+package mcp
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/tetratelabs/wazero"
+)
+
+// fakeCompilationCache counts Close calls, standing in for the wazero cache in
+// lifecycle tests.
+type fakeCompilationCache struct {
+	closes int
+}
+
+func (f *fakeCompilationCache) Close(context.Context) error {
+	f.closes++
+	return nil
+}
+
+var _ = Describe("sharedWasmCache", func() {
+	var s *sharedWasmCache
+	var created []*fakeCompilationCache
+
+	BeforeEach(func() {
+		s = &sharedWasmCache{}
+		created = nil
+		orig := newCompilationCache
+		newCompilationCache = func() wazero.CompilationCache {
+			f := &fakeCompilationCache{}
+			created = append(created, f)
+			return f
+		}
+		DeferCleanup(func() { newCompilationCache = orig })
+	})
+
+	It("hands every holder the same cache", func() {
+		first := s.acquire()
+		second := s.acquire()
+		Expect(second).To(BeIdenticalTo(first))
+		Expect(created).To(HaveLen(1))
+	})
+
+	It("closes the cache exactly once, when the last holder releases it", func() {
+		s.acquire()
+		s.acquire()
+		s.release()
+		Expect(created[0].closes).To(BeZero(), "first release must not close a shared cache")
+		s.release()
+		Expect(created[0].closes).To(Equal(1))
+		s.release() // Unbalanced; logged and ignored.
+		Expect(created[0].closes).To(Equal(1))
+	})
+
+	It("creates a fresh cache for holders arriving after the last release", func() {
+		s.acquire()
+		s.release()
+		s.acquire()
+		Expect(created).To(HaveLen(2))
+		Expect(created[0].closes).To(Equal(1))
+		Expect(created[1].closes).To(BeZero())
+	})
+})
+
+var _ = Describe("wasm transports sharing the compilation cache", Label("slow"), func() {
+	It("keeps the cache alive until the last module is closed, in either order", func() {
+		path := buildTestPlugin()
+		ctx := GinkgoT().Context()
+		for _, firstStartedFirst := range []bool{true, false} {
+			baseline := wasmCache.holders()
+			first := newWasmTransport(path)
+			second := newWasmTransport(path)
+			for _, t := range []*wasmTransport{first, second} {
+				_, _, err := t.Start(ctx)
+				Expect(err).ToNot(HaveOccurred())
+			}
+			Expect(wasmCache.holders()).To(Equal(baseline + 2))
+
+			closeOrder := []*wasmTransport{first, second}
+			if !firstStartedFirst {
+				closeOrder = []*wasmTransport{second, first}
+			}
+			Expect(closeOrder[0].Close()).To(Succeed())
+			Expect(wasmCache.holders()).To(Equal(baseline+1),
+				"closing one module must not drop the other's hold")
+			Expect(closeOrder[1].Close()).To(Succeed())
+			Expect(closeOrder[1].Close()).To(Succeed(), "double Close is a no-op")
+			Expect(wasmCache.holders()).To(Equal(baseline))
+		}
+	})
+})
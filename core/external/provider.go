@@ -14,13 +14,13 @@ import (
 	"github.com/navidrome/navidrome/core/agents"
 	_ "github.com/navidrome/navidrome/core/agents/lastfm"
 	_ "github.com/navidrome/navidrome/core/agents/listenbrainz"
+	_ "github.com/navidrome/navidrome/core/agents/mcp"
 	_ "github.com/navidrome/navidrome/core/agents/spotify"
 	"github.com/navidrome/navidrome/log"
 	"github.com/navidrome/navidrome/model"
 	"github.com/navidrome/navidrome/utils"
 	. "github.com/navidrome/navidrome/utils/gg"
 	"github.com/navidrome/navidrome/utils/random"
-	"github.com/navidrome/navidrome/utils/slice"
 	"github.com/navidrome/navidrome/utils/str"
 	"golang.org/x/sync/errgroup"
 )
@@ -568,15 +568,14 @@ func (e *provider) callGetSimilar(ctx context.Context, agent agents.ArtistSimila
 }
 
 func (e *provider) mapSimilarArtists(ctx context.Context, similar []agents.Artist, includeNotPresent bool) (model.Artists, error) {
-	var result model.Artists
-	var notPresent []string
-
-	artistNames := slice.Map(similar, func(artist agents.Artist) string { return artist.Name })
-
-	// Query all artists at once
-	clauses := slice.Map(artistNames, func(name string) squirrel.Sqlizer {
-		return squirrel.Like{"artist.name": name}
-	})
+	// Query all artists at once, by MBID and by name
+	clauses := make([]squirrel.Sqlizer, 0, len(similar)*2)
+	for _, s := range similar {
+		if s.MBID != "" {
+			clauses = append(clauses, squirrel.Eq{"artist.mbz_artist_id": s.MBID})
+		}
+		clauses = append(clauses, squirrel.Like{"artist.name": s.Name})
+	}
 	artists, err := e.ds.Artist(ctx).GetAll(model.QueryOptions{
 		Filters: squirrel.Or(clauses),
 	})
@@ -584,27 +583,46 @@ func (e *provider) mapSimilarArtists(ctx context.Context, similar []agents.Artis
 		return nil, err
 	}
 
-	// Create a map for quick lookup
-	artistMap := make(map[string]model.Artist)
+	// Create maps for quick lookup, matching by MBID first, then by name
+	byMBID := make(map[string]model.Artist)
+	byName := make(map[string]model.Artist)
 	for _, artist := range artists {
-		artistMap[artist.Name] = artist
+		if artist.MbzArtistID != "" {
+			byMBID[artist.MbzArtistID] = artist
+		}
+		byName[artist.Name] = artist
 	}
 
-	// Process the similar artists
-	for _, s := range similar {
-		if artist, found := artistMap[s.Name]; found {
-			result = append(result, artist)
-		} else {
-			notPresent = append(notPresent, s.Name)
+	// Process the similar artists in the agent's order, de-duplicated
+	var result model.Artists
+	seen := make(map[string]struct{})
+	addOnce := func(key string, artist model.Artist) {
+		if _, ok := seen[key]; ok {
+			return
 		}
+		seen[key] = struct{}{}
+		result = append(result, artist)
 	}
-
-	// Then fill up with non-present artists
-	if includeNotPresent {
-		for _, s := range notPresent {
-			// Let the ID empty to indicate that the artist is not present in the DB
-			sa := model.Artist{Name: s}
-			result = append(result, sa)
+	for _, s := range similar {
+		if s.MBID != "" {
+			if artist, found := byMBID[s.MBID]; found {
+				addOnce("id:"+artist.ID, artist)
+				continue
+			}
+		}
+		if artist, found := byName[s.Name]; found {
+			addOnce("id:"+artist.ID, artist)
+			continue
+		}
+		if includeNotPresent {
+			// Let the ID empty to indicate that the artist is not present in
+			// the DB, but keep the MBID so agents without local matches (e.g.
+			// MCP) still surface resolvable entries
+			key := s.MBID
+			if key == "" {
+				key = s.Name
+			}
+			addOnce("ext:"+key, model.Artist{Name: s.Name, MbzArtistID: s.MBID})
 		}
 	}
 
@@ -104,6 +104,36 @@ var _ = Describe("Provider - UpdateArtistInfo", func() {
 		ag.AssertExpectations(GinkgoT())
 	})
 
+	It("matches similar artists by MBID and keeps external-only entries", func() {
+		originalArtist := &model.Artist{ID: "ar-main", Name: "Main Artist"}
+		localMatch := model.Artist{ID: "ar-local", Name: "Local Name", MbzArtistID: "mbid-local"}
+		mockArtistRepo.SetData(model.Artists{*originalArtist, localMatch})
+
+		rawSimilar := []agents.Artist{
+			{Name: "Renamed Abroad", MBID: "mbid-local"}, // Matches the library by MBID despite the name
+			{Name: "Only External", MBID: "mbid-ext"},
+			{Name: "Only External", MBID: "mbid-ext"}, // Duplicate, must collapse
+		}
+
+		ag.On("GetArtistMBID", ctx, "ar-main", "Main Artist").Return("mbid-main", nil).Once()
+		ag.On("GetArtistImages", ctx, "ar-main", "Main Artist", "mbid-main").Return(nil, agents.ErrNotFound).Once()
+		ag.On("GetArtistBiography", ctx, "ar-main", "Main Artist", "mbid-main").Return("", agents.ErrNotFound).Once()
+		ag.On("GetArtistURL", ctx, "ar-main", "Main Artist", "mbid-main").Return("", agents.ErrNotFound).Once()
+		ag.On("GetSimilarArtists", ctx, "ar-main", "Main Artist", "mbid-main", 100).Return(rawSimilar, nil).Once()
+
+		updatedArtist, err := p.UpdateArtistInfo(ctx, "ar-main", 10, true)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(updatedArtist.SimilarArtists).To(HaveLen(2))
+		Expect(updatedArtist.SimilarArtists[0].ID).To(Equal("ar-local"))
+		Expect(updatedArtist.SimilarArtists[0].Name).To(Equal("Local Name"))
+		Expect(updatedArtist.SimilarArtists[1].ID).To(BeEmpty())
+		Expect(updatedArtist.SimilarArtists[1].Name).To(Equal("Only External"))
+		Expect(updatedArtist.SimilarArtists[1].MbzArtistID).To(Equal("mbid-ext"))
+
+		ag.AssertExpectations(GinkgoT())
+	})
+
 	It("returns cached info when artist exists and info is not expired", func() {
 		now := time.Now()
 		originalArtist := &model.Artist{
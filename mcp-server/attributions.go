@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/navidrome/navidrome/mcp-server/lookup"
+)
+
+// attributionsResourceURI identifies the resource listing the licenses of all
+// sources this server uses, for CC BY-SA compliance.
+const attributionsResourceURI = "mcp://server/attributions"
+
+// attribution records where a piece of metadata came from and under which
+// license it was published.
+type attribution struct {
+	Source      string    `json:"source"`
+	License     string    `json:"license"`
+	URL         string    `json:"url,omitempty"`
+	RetrievedAt time.Time `json:"retrievedAt,omitzero"`
+}
+
+// attributionFor builds the attribution for a lookup result.
+func attributionFor(r *lookup.BioResult) attribution {
+	return attribution{
+		Source:      r.Source,
+		License:     r.License,
+		URL:         r.URL,
+		RetrievedAt: r.RetrievedAt,
+	}
+}
+
+// attributionsResource lists every source the lookup client uses with its
+// license, regardless of whether it has been used yet.
+func attributionsResource(c *lookup.Client) resource {
+	return resource{
+		uri:         attributionsResourceURI,
+		name:        "Source attributions",
+		description: "Licenses and attribution for all upstream metadata sources",
+		mimeType:    "application/json",
+		read: func(_ context.Context) ([]byte, error) {
+			var list []attribution
+			for _, src := range c.Sources() {
+				list = append(list, attribution{Source: src.Name(), License: src.License()})
+			}
+			return json.Marshal(list)
+		},
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/navidrome/navidrome/mcp-server/lookup"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("attributions", func() {
+	It("reports the license of each default source", func() {
+		c := lookup.NewClient(lookup.NewFetcher(), nil)
+		licenses := map[string]string{}
+		for _, src := range c.Sources() {
+			licenses[src.Name()] = src.License()
+		}
+		Expect(licenses).To(Equal(map[string]string{
+			"wikipedia":            "CC BY-SA 4.0",
+			"dbpedia":              "CC BY-SA 3.0",
+			"wikidata-description": "CC0 1.0",
+		}))
+	})
+
+	It("copies the attribution fields from a lookup result", func() {
+		result := &lookup.BioResult{
+			Source:      "first",
+			License:     "CC BY-SA 4.0",
+			Text:        "a bio",
+			URL:         "https://example.com",
+			RetrievedAt: time.Now(),
+		}
+		attr := attributionFor(result)
+		Expect(attr.Source).To(Equal("first"))
+		Expect(attr.License).To(Equal("CC BY-SA 4.0"))
+		Expect(attr.URL).To(Equal("https://example.com"))
+		Expect(attr.RetrievedAt).ToNot(BeZero())
+	})
+
+	It("lists all sources in the attributions resource", func() {
+		c := lookup.NewClient(lookup.NewFetcher(), nil)
+		res := attributionsResource(c)
+		Expect(res.uri).To(Equal(attributionsResourceURI))
+
+		data, err := res.read(context.Background())
+		Expect(err).ToNot(HaveOccurred())
+		var list []attribution
+		Expect(json.Unmarshal(data, &list)).To(Succeed())
+		Expect(list).To(HaveLen(3))
+		Expect(list[0].Source).To(Equal("wikipedia"))
+		Expect(list[2].License).To(Equal("CC0 1.0"))
+	})
+})
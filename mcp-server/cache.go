@@ -0,0 +1,338 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/navidrome/navidrome/mcp-server/lookup"
+)
+
+const defaultCacheTTL = 24 * time.Hour
+
+// cacheEntry is a cached tool result. NotFound entries negative-cache artists
+// no source knows about, so they are not re-queried on every scan.
+type cacheEntry struct {
+	Value    string    `json:"value,omitempty"`
+	NotFound bool      `json:"notFound,omitempty"`
+	Expires  time.Time `json:"expires"`
+
+	// ValueGz holds the gzipped, base64-encoded value when the disk cache
+	// stored the entry compressed. Exactly one of Value and ValueGz is set on
+	// disk; readers always see Value.
+	ValueGz string `json:"valueGz,omitempty"`
+
+	// Set by the dedup layer: Ref points an artist key at a content-addressed
+	// entry, Owner is the first artist key that stored the content, and Shared
+	// marks content that was stored for more than one distinct artist.
+	Ref    string `json:"ref,omitempty"`
+	Owner  string `json:"owner,omitempty"`
+	Shared bool   `json:"shared,omitempty"`
+
+	// FetchedMBID and FetchedName record the identity the entry was fetched
+	// under, verified again on every read. When a user corrects a wrongly
+	// tagged artist's MBID, the persisted entry must not keep serving the old
+	// artist's data until its TTL runs out.
+	FetchedMBID string `json:"fetchedMbid,omitempty"`
+	FetchedName string `json:"fetchedName,omitempty"`
+}
+
+func (e *cacheEntry) expired() bool {
+	return !e.Expires.IsZero() && time.Now().After(e.Expires)
+}
+
+// stamp records the query's identity on the entry, for matchesQuery to verify
+// on later reads.
+func (e *cacheEntry) stamp(q lookup.ArtistQuery) {
+	e.FetchedMBID = strings.TrimSpace(q.MBID)
+	e.FetchedName = normalizedName(q.Name)
+}
+
+// matchesQuery reports whether the entry was fetched under the same identity
+// the current call asks about. Entries persisted before stamping existed carry
+// no identity and are accepted as-is; they age out through their TTL.
+func (e *cacheEntry) matchesQuery(q lookup.ArtistQuery) bool {
+	if e.FetchedMBID != "" && e.FetchedMBID != strings.TrimSpace(q.MBID) {
+		return false
+	}
+	if e.FetchedName != "" && e.FetchedName != normalizedName(q.Name) {
+		return false
+	}
+	return true
+}
+
+// normalizedName folds the spelling variations that do not change which artist
+// a name refers to.
+func normalizedName(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
+
+// resultCache abstracts where tool results are cached. Implementations must
+// be safe for concurrent use and degrade gracefully: a broken backend should
+// behave like an empty cache, never fail a call.
+type resultCache interface {
+	get(ctx context.Context, key string) (*cacheEntry, bool)
+	set(ctx context.Context, key string, entry cacheEntry)
+}
+
+// newCache builds the cache selected by MCP_CACHE_BACKEND: "memory" (the
+// default), "disk", "redis" or "none".
+func newCache(cfg serverConfig) resultCache {
+	var cache resultCache
+	switch cfg.CacheBackend {
+	case "", "memory":
+		cache = newMemoryCache(cfg.CacheTTL)
+	case "disk":
+		cache = newDiskCache(cfg.CacheDir, cfg.CacheTTL, cfg.CacheCompress, cfg.CacheCompressMin)
+	case "redis":
+		cache = newRedisCache(cfg.RedisAddr, cfg.RedisPassword, cfg.CacheTTL)
+	case "none":
+		cache = noCache{}
+	default:
+		log.Printf("unknown cache backend %q, caching disabled", cfg.CacheBackend)
+		cache = noCache{}
+	}
+	if cfg.CacheDedup {
+		cache = &dedupCache{inner: cache}
+	}
+	return cache
+}
+
+// cacheKeyVersion prefixes every cache key. Bump it whenever the key
+// derivation changes (as when new arguments join the hash): entries persisted
+// under the old prefix are simply never read again and age out through their
+// TTL, so no explicit migration of disk or Redis backends is needed.
+const cacheKeyVersion = "mcp2"
+
+// canonicalArgs renders every result-affecting argument of a tool call in a
+// fixed order, with whitespace trimmed. Arguments that cannot change what is
+// cached stay out, so they do not fragment the cache: IncludeLinks requests
+// bypass the cache entirely.
+func canonicalArgs(tool string, q lookup.ArtistQuery) string {
+	return strings.Join([]string{
+		tool,
+		strings.TrimSpace(q.ID),
+		strings.TrimSpace(q.MBID),
+		strings.TrimSpace(q.Name),
+		strconv.FormatBool(q.Validate),
+		strings.TrimSpace(q.SearchURLTemplate),
+	}, "\x00")
+}
+
+// cacheKey derives a stable key for a tool call from its canonicalized
+// arguments, and records the fingerprint for the cache-keys resource.
+func cacheKey(tool string, q lookup.ArtistQuery) string {
+	args := canonicalArgs(tool, q)
+	sum := sha256.Sum256([]byte(args))
+	key := cacheKeyVersion + ":" + hex.EncodeToString(sum[:16])
+	keyFingerprints.record(key, strings.ReplaceAll(args, "\x00", "|"))
+	return key
+}
+
+// maxFingerprints bounds the fingerprint log; it is diagnostic data, not a
+// cache, so dropping entries only loses debug detail.
+const maxFingerprints = 512
+
+// keyFingerprints maps recently derived cache keys back to the canonical
+// arguments they were hashed from, so the cache-keys resource can show which
+// argument variant a key belongs to.
+var keyFingerprints = &fingerprintLog{entries: map[string]string{}}
+
+type fingerprintLog struct {
+	mu      sync.Mutex
+	entries map[string]string
+}
+
+func (l *fingerprintLog) record(key, args string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, ok := l.entries[key]; !ok && len(l.entries) >= maxFingerprints {
+		for k := range l.entries {
+			delete(l.entries, k)
+			break
+		}
+	}
+	l.entries[key] = args
+}
+
+func (l *fingerprintLog) snapshot() map[string]string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	out := make(map[string]string, len(l.entries))
+	for k, v := range l.entries {
+		out[k] = v
+	}
+	return out
+}
+
+// cacheKeysResourceURI identifies the debug resource mapping cache keys to the
+// canonicalized arguments they were derived from.
+const cacheKeysResourceURI = "mcp://server/cache-keys"
+
+func cacheKeysResource() resource {
+	return resource{
+		uri:         cacheKeysResourceURI,
+		name:        "Cache key fingerprints",
+		description: "Recently derived cache keys and the canonicalized tool arguments behind them",
+		mimeType:    "application/json",
+		read: func(_ context.Context) ([]byte, error) {
+			return json.Marshal(keyFingerprints.snapshot())
+		},
+	}
+}
+
+type noCache struct{}
+
+func (noCache) get(context.Context, string) (*cacheEntry, bool) { return nil, false }
+func (noCache) set(context.Context, string, cacheEntry)         {}
+
+// memoryCache is a simple TTL map, for single-instance deployments.
+type memoryCache struct {
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+	ttl     time.Duration
+}
+
+func newMemoryCache(ttl time.Duration) *memoryCache {
+	if ttl <= 0 {
+		ttl = defaultCacheTTL
+	}
+	return &memoryCache{entries: make(map[string]cacheEntry), ttl: ttl}
+}
+
+func (c *memoryCache) get(_ context.Context, key string) (*cacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if !ok || entry.expired() {
+		delete(c.entries, key)
+		return nil, false
+	}
+	return &entry, true
+}
+
+func (c *memoryCache) set(_ context.Context, key string, entry cacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry.Expires = time.Now().Add(c.ttl)
+	c.entries[key] = entry
+}
+
+// defaultCompressMin is the smallest value worth compressing: below this the
+// gzip framing eats the savings.
+const defaultCompressMin = 1024
+
+// diskCache stores one JSON file per entry, surviving server restarts. With
+// compression enabled, values at least compressMin bytes long are gzipped on
+// disk and transparently decompressed on read - full bio texts compress well,
+// which matters for large libraries with thousands of cached bios.
+type diskCache struct {
+	dir         string
+	ttl         time.Duration
+	compress    bool
+	compressMin int
+}
+
+func newDiskCache(dir string, ttl time.Duration, compress bool, compressMin int) resultCache {
+	if ttl <= 0 {
+		ttl = defaultCacheTTL
+	}
+	if compressMin <= 0 {
+		compressMin = defaultCompressMin
+	}
+	if dir == "" {
+		dir = filepath.Join(os.TempDir(), "navidrome-mcp-cache")
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		log.Printf("cannot create cache dir %s, caching disabled: %v", dir, err)
+		return noCache{}
+	}
+	return &diskCache{dir: dir, ttl: ttl, compress: compress, compressMin: compressMin}
+}
+
+func (c *diskCache) path(key string) string {
+	return filepath.Join(c.dir, key+".json")
+}
+
+func (c *diskCache) get(_ context.Context, key string) (*cacheEntry, bool) {
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return nil, false
+	}
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil || entry.expired() {
+		_ = os.Remove(c.path(key))
+		return nil, false
+	}
+	if entry.ValueGz != "" {
+		value, err := gunzipString(entry.ValueGz)
+		if err != nil {
+			// A value that cannot decompress is as good as no value.
+			_ = os.Remove(c.path(key))
+			return nil, false
+		}
+		entry.Value = value
+		entry.ValueGz = ""
+	}
+	return &entry, true
+}
+
+func (c *diskCache) set(_ context.Context, key string, entry cacheEntry) {
+	entry.Expires = time.Now().Add(c.ttl)
+	if c.compress && len(entry.Value) >= c.compressMin {
+		if gz, err := gzipString(entry.Value); err == nil && len(gz) < len(entry.Value) {
+			entry.ValueGz = gz
+			entry.Value = ""
+		}
+	}
+	data, err := json.Marshal(entry)
+	if err == nil {
+		err = os.WriteFile(c.path(key), data, 0600)
+	}
+	if err != nil {
+		log.Printf("cannot write cache entry %s: %v", key, err)
+	}
+}
+
+// gzipString compresses a string to base64-wrapped gzip, safe to embed in the
+// entry's JSON.
+func gzipString(s string) (string, error) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write([]byte(s)); err != nil {
+		return "", err
+	}
+	if err := w.Close(); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+func gunzipString(s string) (string, error) {
+	raw, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return "", err
+	}
+	r, err := gzip.NewReader(bytes.NewReader(raw))
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
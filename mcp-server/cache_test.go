@@ -0,0 +1,318 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/navidrome/navidrome/mcp-server/lookup"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// behavesLikeACache asserts the resultCache contract: get/set round-trips,
+// negative caching, and misses for unknown keys.
+func behavesLikeACache(newCache func() resultCache) {
+	var cache resultCache
+	ctx := context.Background()
+
+	BeforeEach(func() {
+		cache = newCache()
+	})
+
+	It("misses for unknown keys", func() {
+		_, ok := cache.get(ctx, "mcp:unknown")
+		Expect(ok).To(BeFalse())
+	})
+
+	It("round-trips entries", func() {
+		cache.set(ctx, "mcp:key", cacheEntry{Value: "a bio"})
+		entry, ok := cache.get(ctx, "mcp:key")
+		Expect(ok).To(BeTrue())
+		Expect(entry.Value).To(Equal("a bio"))
+		Expect(entry.NotFound).To(BeFalse())
+	})
+
+	It("negative-caches not-found results", func() {
+		cache.set(ctx, "mcp:missing", cacheEntry{NotFound: true})
+		entry, ok := cache.get(ctx, "mcp:missing")
+		Expect(ok).To(BeTrue())
+		Expect(entry.NotFound).To(BeTrue())
+	})
+}
+
+var _ = Describe("cacheKey", func() {
+	base := lookup.ArtistQuery{Name: "The Artist", MBID: "mbid-1"}
+
+	It("is stable for identical arguments", func() {
+		Expect(cacheKey("get_artist_url", base)).To(Equal(cacheKey("get_artist_url", base)))
+	})
+
+	It("isolates argument variants of the same artist", func() {
+		validated := base
+		validated.Validate = true
+		templated := base
+		templated.SearchURLTemplate = "https://duckduckgo.com/?q={query}"
+
+		keys := map[string]bool{
+			cacheKey("get_artist_url", base):      true,
+			cacheKey("get_artist_url", validated): true,
+			cacheKey("get_artist_url", templated): true,
+		}
+		Expect(keys).To(HaveLen(3))
+	})
+
+	It("ignores arguments that never reach the cache", func() {
+		withLinks := base
+		withLinks.IncludeLinks = true
+		Expect(cacheKey("get_artist_biography", withLinks)).To(Equal(cacheKey("get_artist_biography", base)))
+	})
+
+	It("canonicalizes whitespace in string arguments", func() {
+		padded := lookup.ArtistQuery{Name: "  The Artist ", MBID: "mbid-1"}
+		Expect(cacheKey("get_artist_url", padded)).To(Equal(cacheKey("get_artist_url", base)))
+	})
+
+	It("uses a versioned prefix, so rekeyed entries never collide with old ones", func() {
+		Expect(cacheKey("get_artist_url", base)).To(HavePrefix(cacheKeyVersion + ":"))
+	})
+
+	It("exposes the argument fingerprint through the cache-keys resource", func() {
+		key := cacheKey("get_artist_url", base)
+		res := cacheKeysResource()
+		Expect(res.uri).To(Equal(cacheKeysResourceURI))
+
+		data, err := res.read(context.Background())
+		Expect(err).ToNot(HaveOccurred())
+		var keys map[string]string
+		Expect(json.Unmarshal(data, &keys)).To(Succeed())
+		Expect(keys).To(HaveKeyWithValue(key, "get_artist_url||mbid-1|The Artist|false|"))
+	})
+})
+
+var _ = Describe("cache entry identity", func() {
+	fetched := lookup.ArtistQuery{Name: "The Artist", MBID: "mbid-1"}
+
+	stamped := func() cacheEntry {
+		entry := cacheEntry{Value: "a bio"}
+		entry.stamp(fetched)
+		return entry
+	}
+
+	It("matches the query it was fetched under", func() {
+		entry := stamped()
+		Expect(entry.matchesQuery(fetched)).To(BeTrue())
+	})
+
+	It("rejects the entry after an MBID correction", func() {
+		corrected := fetched
+		corrected.MBID = "mbid-2"
+		entry := stamped()
+		Expect(entry.matchesQuery(corrected)).To(BeFalse())
+	})
+
+	It("rejects the entry after a rename", func() {
+		renamed := fetched
+		renamed.Name = "Another Artist"
+		entry := stamped()
+		Expect(entry.matchesQuery(renamed)).To(BeFalse())
+	})
+
+	It("ignores case and whitespace differences in the name", func() {
+		variant := fetched
+		variant.Name = "  the   ARTIST "
+		entry := stamped()
+		Expect(entry.matchesQuery(variant)).To(BeTrue())
+	})
+
+	It("accepts unstamped entries from before stamping existed", func() {
+		entry := cacheEntry{Value: "a bio"}
+		Expect(entry.matchesQuery(fetched)).To(BeTrue())
+	})
+})
+
+var _ = Describe("memoryCache", func() {
+	behavesLikeACache(func() resultCache { return newMemoryCache(time.Minute) })
+
+	It("expires entries after the TTL", func() {
+		cache := newMemoryCache(time.Millisecond)
+		cache.set(context.Background(), "mcp:key", cacheEntry{Value: "a bio"})
+		Eventually(func() bool {
+			_, ok := cache.get(context.Background(), "mcp:key")
+			return ok
+		}).Should(BeFalse())
+	})
+})
+
+var _ = Describe("diskCache", func() {
+	behavesLikeACache(func() resultCache { return newDiskCache(GinkgoT().TempDir(), time.Minute, false, 0) })
+
+	It("degrades to no-cache when the directory cannot be created", func() {
+		cache := newDiskCache("/proc/nope/cache", time.Minute, false, 0)
+		Expect(cache).To(Equal(noCache{}))
+	})
+
+	Describe("compression", func() {
+		var dir string
+		var cache resultCache
+		ctx := context.Background()
+
+		BeforeEach(func() {
+			dir = GinkgoT().TempDir()
+			cache = newDiskCache(dir, time.Minute, true, 64)
+		})
+
+		rawEntry := func(key string) cacheEntry {
+			data, err := os.ReadFile(filepath.Join(dir, key+".json"))
+			Expect(err).ToNot(HaveOccurred())
+			var entry cacheEntry
+			Expect(json.Unmarshal(data, &entry)).To(Succeed())
+			return entry
+		}
+
+		It("stores large values compressed and round-trips them", func() {
+			bio := strings.Repeat("a very repetitive biography ", 50)
+			cache.set(ctx, "mcp:big", cacheEntry{Value: bio})
+
+			raw := rawEntry("mcp:big")
+			Expect(raw.Value).To(BeEmpty())
+			Expect(raw.ValueGz).ToNot(BeEmpty())
+			Expect(len(raw.ValueGz)).To(BeNumerically("<", len(bio)))
+
+			entry, ok := cache.get(ctx, "mcp:big")
+			Expect(ok).To(BeTrue())
+			Expect(entry.Value).To(Equal(bio))
+			Expect(entry.ValueGz).To(BeEmpty())
+		})
+
+		It("stores values below the threshold uncompressed", func() {
+			cache.set(ctx, "mcp:small", cacheEntry{Value: "a short bio"})
+
+			raw := rawEntry("mcp:small")
+			Expect(raw.Value).To(Equal("a short bio"))
+			Expect(raw.ValueGz).To(BeEmpty())
+		})
+
+		It("treats undecompressable values as misses", func() {
+			cache.set(ctx, "mcp:bad", cacheEntry{Value: strings.Repeat("x", 100)})
+			path := filepath.Join(dir, "mcp:bad.json")
+			data, err := json.Marshal(cacheEntry{ValueGz: "bm90IGd6aXA=", Expires: time.Now().Add(time.Minute)})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(os.WriteFile(path, data, 0600)).To(Succeed())
+
+			_, ok := cache.get(ctx, "mcp:bad")
+			Expect(ok).To(BeFalse())
+			Expect(path).ToNot(BeAnExistingFile())
+		})
+	})
+})
+
+var _ = Describe("redisCache", func() {
+	var addr string
+	var srv *fakeRedis
+
+	BeforeEach(func() {
+		srv = newFakeRedis()
+		addr = srv.addr
+		DeferCleanup(srv.close)
+	})
+
+	behavesLikeACache(func() resultCache { return newRedisCache(addr, "", time.Minute) })
+
+	It("serves misses when Redis is unavailable", func() {
+		cache := newRedisCache("127.0.0.1:1", "", time.Minute)
+		cache.set(context.Background(), "mcp:key", cacheEntry{Value: "a bio"})
+		_, ok := cache.get(context.Background(), "mcp:key")
+		Expect(ok).To(BeFalse())
+	})
+})
+
+// fakeRedis implements just enough RESP (GET/SET with EX) for the cache tests.
+type fakeRedis struct {
+	addr     string
+	listener net.Listener
+	mu       sync.Mutex
+	data     map[string]string
+}
+
+func newFakeRedis() *fakeRedis {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	Expect(err).ToNot(HaveOccurred())
+	f := &fakeRedis{addr: listener.Addr().String(), listener: listener, data: map[string]string{}}
+	go f.accept()
+	return f
+}
+
+func (f *fakeRedis) close() { _ = f.listener.Close() }
+
+func (f *fakeRedis) accept() {
+	for {
+		conn, err := f.listener.Accept()
+		if err != nil {
+			return
+		}
+		go f.serve(conn)
+	}
+}
+
+func (f *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			return
+		}
+		f.mu.Lock()
+		switch strings.ToUpper(args[0]) {
+		case "GET":
+			if v, ok := f.data[args[1]]; ok {
+				_, _ = conn.Write([]byte("$" + strconv.Itoa(len(v)) + "\r\n" + v + "\r\n"))
+			} else {
+				_, _ = conn.Write([]byte("$-1\r\n"))
+			}
+		case "SET":
+			f.data[args[1]] = args[2]
+			_, _ = conn.Write([]byte("+OK\r\n"))
+		default:
+			_, _ = conn.Write([]byte("-ERR unknown command\r\n"))
+		}
+		f.mu.Unlock()
+	}
+}
+
+func readCommand(r *bufio.Reader) ([]string, error) {
+	header, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(header[1:]))
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		sizeLine, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimSpace(sizeLine[1:]))
+		if err != nil {
+			return nil, err
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		args = append(args, string(buf[:size]))
+	}
+	return args, nil
+}
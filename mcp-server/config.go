@@ -0,0 +1,131 @@
+package main
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// serverConfig holds the server's runtime options. As the server runs as a
+// child process, everything is configured through MCP_* environment variables
+// set by the host.
+type serverConfig struct {
+	// DisableBioCleanup skips the aggressive biography cleanup (whitespace
+	// collapsing and truncation), for localized content where any transform
+	// is a risk.
+	DisableBioCleanup bool
+	// CacheBackend selects where tool results are cached: "memory" (the
+	// default), "disk", "redis" or "none".
+	CacheBackend  string
+	CacheDir      string
+	CacheTTL      time.Duration
+	RedisAddr     string
+	RedisPassword string
+	// CacheDedup stores identical result texts once, content-addressed, and
+	// flags results served for distinct artists (a possible mismatch signal).
+	CacheDedup bool
+	// CacheCompress gzips disk cache values of at least CacheCompressMin bytes
+	// (default 1KB); smaller values are stored as-is.
+	CacheCompress    bool
+	CacheCompressMin int
+	// StoreMaxEntries caps each persistent store (MBID memoization, not-found
+	// tracking); StoreTTL bounds how long their entries live, and
+	// StoreCompactInterval schedules the periodic compaction that rewrites the
+	// files dropping expired entries (zero disables it).
+	StoreMaxEntries      int
+	StoreTTL             time.Duration
+	StoreCompactInterval time.Duration
+	// SourceOrders overrides the per-type source preference (bio/image/genre/
+	// links), each a comma-separated list of source names.
+	SourceOrders map[string][]string
+	// StartupProbe checks each upstream endpoint's reachability at startup,
+	// logging latency and temporarily skipping sources behind unreachable ones.
+	StartupProbe bool
+	// LogFullBodies logs the complete body of failed upstream responses, which
+	// are otherwise truncated to logBodyLimit in errors and logs.
+	LogFullBodies bool
+	// RequestLog controls how outbound URLs appear in logs: "full-url" (the
+	// default), "hosts-only" (paths and queries redacted) or "off".
+	RequestLog string
+	// FetchMaxRetries and FetchRetryDelay tune how transient upstream failures
+	// (5xx, connection resets) are retried; zero values keep the defaults.
+	FetchMaxRetries int
+	FetchRetryDelay time.Duration
+	// MBIDMinScore is the minimum MusicBrainz search score (0-100) required to
+	// trust an MBID resolved from a bare artist name; zero keeps the default.
+	MBIDMinScore int
+	// UserAgent overrides the User-Agent sent with every upstream request;
+	// empty keeps the build's default.
+	UserAgent string
+	// LookupCacheSize bounds the in-memory lookup cache; LookupCacheTTL and
+	// LookupCacheMissTTL are how long successful and not-found answers live.
+	// Zero values keep the defaults.
+	LookupCacheSize    int
+	LookupCacheTTL     time.Duration
+	LookupCacheMissTTL time.Duration
+}
+
+func loadConfig() serverConfig {
+	return serverConfig{
+		DisableBioCleanup:    envBool("MCP_DISABLE_BIO_CLEANUP"),
+		CacheBackend:         os.Getenv("MCP_CACHE_BACKEND"),
+		CacheDir:             os.Getenv("MCP_CACHE_DIR"),
+		CacheTTL:             envDuration("MCP_CACHE_TTL"),
+		RedisAddr:            os.Getenv("MCP_CACHE_REDIS_ADDR"),
+		RedisPassword:        os.Getenv("MCP_CACHE_REDIS_PASSWORD"),
+		CacheDedup:           envBool("MCP_CACHE_DEDUP"),
+		CacheCompress:        envBool("MCP_CACHE_COMPRESS"),
+		CacheCompressMin:     envInt("MCP_CACHE_COMPRESS_MIN"),
+		StoreMaxEntries:      envInt("MCP_STORE_MAX_ENTRIES"),
+		StoreTTL:             envDuration("MCP_STORE_TTL"),
+		StoreCompactInterval: envDuration("MCP_STORE_COMPACT_INTERVAL"),
+		SourceOrders: map[string][]string{
+			"bio":   envList("MCP_SOURCES_BIO"),
+			"image": envList("MCP_SOURCES_IMAGE"),
+			"genre": envList("MCP_SOURCES_GENRE"),
+			"links": envList("MCP_SOURCES_LINKS"),
+		},
+		StartupProbe:       envBool("MCP_STARTUP_PROBE"),
+		LogFullBodies:      envBool("MCP_LOG_FULL_BODIES"),
+		RequestLog:         os.Getenv("MCP_REQUEST_LOG"),
+		FetchMaxRetries:    envInt("MCP_FETCH_MAX_RETRIES"),
+		FetchRetryDelay:    envDuration("MCP_FETCH_RETRY_DELAY"),
+		MBIDMinScore:       envInt("MCP_MBID_MIN_SCORE"),
+		UserAgent:          os.Getenv("MCP_USER_AGENT"),
+		LookupCacheSize:    envInt("MCP_LOOKUP_CACHE_SIZE"),
+		LookupCacheTTL:     envDuration("MCP_LOOKUP_CACHE_TTL"),
+		LookupCacheMissTTL: envDuration("MCP_LOOKUP_CACHE_MISS_TTL"),
+	}
+}
+
+func envDuration(name string) time.Duration {
+	d, err := time.ParseDuration(os.Getenv(name))
+	if err != nil {
+		return 0
+	}
+	return d
+}
+
+func envBool(name string) bool {
+	v, err := strconv.ParseBool(os.Getenv(name))
+	return err == nil && v
+}
+
+func envInt(name string) int {
+	v, err := strconv.Atoi(os.Getenv(name))
+	if err != nil {
+		return 0
+	}
+	return v
+}
+
+func envList(name string) []string {
+	var list []string
+	for _, item := range strings.Split(os.Getenv(name), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
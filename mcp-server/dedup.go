@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// dedupCache wraps another cache with content-addressed de-duplication:
+// identical result texts are stored once, under a key derived from their hash,
+// and artist keys only store a reference. Different same-name artists
+// occasionally resolve to the same (wrong) upstream page; when distinct artist
+// keys end up referencing the same content, the content is marked shared so
+// callers can surface a possible-mismatch signal.
+type dedupCache struct {
+	inner resultCache
+}
+
+// contentKey derives the content-addressed key for a result text.
+func contentKey(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return "mcp:content:" + hex.EncodeToString(sum[:16])
+}
+
+func (c *dedupCache) get(ctx context.Context, key string) (*cacheEntry, bool) {
+	entry, ok := c.inner.get(ctx, key)
+	if !ok {
+		return nil, false
+	}
+	if entry.Ref == "" {
+		return entry, true
+	}
+	content, ok := c.inner.get(ctx, entry.Ref)
+	if !ok {
+		// The content entry expired before the reference: treat as a miss.
+		return nil, false
+	}
+	// The identity stamp lives on the reference, not the shared content: the
+	// same text can legitimately back several artists.
+	return &cacheEntry{Value: content.Value, Shared: content.Shared,
+		FetchedMBID: entry.FetchedMBID, FetchedName: entry.FetchedName}, true
+}
+
+func (c *dedupCache) set(ctx context.Context, key string, entry cacheEntry) {
+	// Only successful text results are worth de-duplicating.
+	if entry.NotFound || entry.Value == "" {
+		c.inner.set(ctx, key, entry)
+		return
+	}
+	ck := contentKey(entry.Value)
+	content, ok := c.inner.get(ctx, ck)
+	switch {
+	case !ok:
+		content = &cacheEntry{Value: entry.Value, Owner: key}
+	case content.Owner != key && !content.Shared:
+		content.Shared = true
+	}
+	c.inner.set(ctx, ck, *content)
+	c.inner.set(ctx, key, cacheEntry{Ref: ck, FetchedMBID: entry.FetchedMBID, FetchedName: entry.FetchedName})
+}
+
+// sharedContent reports whether the content referenced by key is shared with
+// other, distinct artist keys.
+func (c *dedupCache) sharedContent(ctx context.Context, key string) bool {
+	entry, ok := c.inner.get(ctx, key)
+	if !ok || entry.Ref == "" {
+		return false
+	}
+	content, ok := c.inner.get(ctx, entry.Ref)
+	return ok && content.Shared
+}
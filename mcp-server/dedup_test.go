@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("dedupCache", func() {
+	var ctx context.Context
+	var inner *memoryCache
+	var cache *dedupCache
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		inner = newMemoryCache(0)
+		cache = &dedupCache{inner: inner}
+	})
+
+	countWithValue := func(value string) int {
+		count := 0
+		for _, entry := range inner.entries {
+			if entry.Value == value {
+				count++
+			}
+		}
+		return count
+	}
+
+	It("stores identical content once, shared across keys", func() {
+		cache.set(ctx, "artist-a", cacheEntry{Value: "same bio"})
+		cache.set(ctx, "artist-b", cacheEntry{Value: "same bio"})
+		Expect(countWithValue("same bio")).To(Equal(1))
+
+		entry, ok := cache.get(ctx, "artist-a")
+		Expect(ok).To(BeTrue())
+		Expect(entry.Value).To(Equal("same bio"))
+	})
+
+	It("does not flag content stored for a single artist", func() {
+		cache.set(ctx, "artist-a", cacheEntry{Value: "unique bio"})
+		entry, ok := cache.get(ctx, "artist-a")
+		Expect(ok).To(BeTrue())
+		Expect(entry.Shared).To(BeFalse())
+		Expect(cache.sharedContent(ctx, "artist-a")).To(BeFalse())
+	})
+
+	It("flags content served for distinct artists", func() {
+		cache.set(ctx, "artist-a", cacheEntry{Value: "same bio"})
+		cache.set(ctx, "artist-b", cacheEntry{Value: "same bio"})
+
+		for _, key := range []string{"artist-a", "artist-b"} {
+			entry, ok := cache.get(ctx, key)
+			Expect(ok).To(BeTrue())
+			Expect(entry.Shared).To(BeTrue(), "key %s", key)
+		}
+		Expect(cache.sharedContent(ctx, "artist-b")).To(BeTrue())
+	})
+
+	It("does not flag a re-store under the same key", func() {
+		cache.set(ctx, "artist-a", cacheEntry{Value: "same bio"})
+		cache.set(ctx, "artist-a", cacheEntry{Value: "same bio"})
+		entry, ok := cache.get(ctx, "artist-a")
+		Expect(ok).To(BeTrue())
+		Expect(entry.Shared).To(BeFalse())
+	})
+
+	It("passes not-found entries straight through", func() {
+		cache.set(ctx, "artist-a", cacheEntry{NotFound: true})
+		entry, ok := cache.get(ctx, "artist-a")
+		Expect(ok).To(BeTrue())
+		Expect(entry.NotFound).To(BeTrue())
+	})
+
+	It("keeps the identity stamp on the reference, per artist", func() {
+		a := cacheEntry{Value: "same bio", FetchedMBID: "mbid-a"}
+		b := cacheEntry{Value: "same bio", FetchedMBID: "mbid-b"}
+		cache.set(ctx, "artist-a", a)
+		cache.set(ctx, "artist-b", b)
+
+		entry, ok := cache.get(ctx, "artist-a")
+		Expect(ok).To(BeTrue())
+		Expect(entry.FetchedMBID).To(Equal("mbid-a"))
+		entry, ok = cache.get(ctx, "artist-b")
+		Expect(ok).To(BeTrue())
+		Expect(entry.FetchedMBID).To(Equal("mbid-b"))
+	})
+
+	It("treats a dangling reference as a miss", func() {
+		cache.set(ctx, "artist-a", cacheEntry{Value: "bio"})
+		delete(inner.entries, contentKey("bio"))
+		_, ok := cache.get(ctx, "artist-a")
+		Expect(ok).To(BeFalse())
+	})
+})
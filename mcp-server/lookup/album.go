@@ -0,0 +1,123 @@
+package lookup
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// DBpedia ontology types used to disambiguate album matches.
+const (
+	dboAlbumType     = "http://dbpedia.org/ontology/Album"
+	dboLiveAlbumType = "http://dbpedia.org/ontology/LiveAlbum"
+)
+
+// AlbumQuery identifies the album a tool call is about. MBID is the
+// MusicBrainz release-group ID.
+type AlbumQuery struct {
+	Album  string `json:"album"`
+	Artist string `json:"artist"`
+	MBID   string `json:"mbid"`
+}
+
+// AlbumInfo is the structured payload of the get_album_info tool.
+type AlbumInfo struct {
+	Description string `json:"description"`
+	URL         string `json:"url,omitempty"`
+	Source      string `json:"source"`
+}
+
+// albumSource resolves album descriptions: Wikidata by release-group MBID
+// (P436) first, then DBpedia by album and artist label. Many albums have a
+// DBpedia resource but no P436 statement, so the fallback carries real weight.
+type albumSource struct {
+	fetcher *Fetcher
+}
+
+func newAlbumSource(f *Fetcher) *albumSource {
+	return &albumSource{fetcher: f}
+}
+
+func (s *albumSource) getAlbumInfo(ctx context.Context, q AlbumQuery) (*AlbumInfo, error) {
+	if q.MBID != "" {
+		if info, err := s.wikidataAlbum(ctx, q.MBID); err == nil {
+			return info, nil
+		}
+	}
+	if q.Album == "" || q.Artist == "" {
+		return nil, ErrNotFound
+	}
+	return s.dbpediaAlbum(ctx, q)
+}
+
+func (s *albumSource) wikidataAlbum(ctx context.Context, mbid string) (*AlbumInfo, error) {
+	query := fmt.Sprintf(`SELECT ?description ?article WHERE {
+  ?album wdt:P436 %q ;
+         schema:description ?description .
+  FILTER(LANG(?description) = "en")
+  OPTIONAL {
+    ?article schema:about ?album ;
+             schema:isPartOf <https://en.wikipedia.org/> .
+  }
+} LIMIT 1`, mbid)
+	results, err := s.fetcher.sparql(ctx, wikidataSPARQLEndpoint, query)
+	if err != nil {
+		return nil, err
+	}
+	return albumFromWikidataResults(results)
+}
+
+// albumFromWikidataResults extracts the description (and article link, when
+// the album has one) from a P436 query.
+func albumFromWikidataResults(results *sparqlResults) (*AlbumInfo, error) {
+	description := results.first("description")
+	if description == "" {
+		return nil, ErrNotFound
+	}
+	return &AlbumInfo{Description: description, URL: results.first("article"), Source: "wikidata"}, nil
+}
+
+func (s *albumSource) dbpediaAlbum(ctx context.Context, q AlbumQuery) (*AlbumInfo, error) {
+	query := fmt.Sprintf(`SELECT ?abstract ?resource ?type WHERE {
+  ?resource rdfs:label %q@en ;
+            a ?type ;
+            dbo:artist ?artist ;
+            dbo:abstract ?abstract .
+  ?artist rdfs:label %q@en .
+  FILTER (lang(?abstract) = "en")
+  FILTER (?type IN (dbo:Album, dbo:LiveAlbum))
+}`, sanitizeSPARQLString(q.Album), sanitizeSPARQLString(q.Artist))
+	results, err := s.fetcher.sparql(ctx, dbpediaSPARQLEndpoint, query)
+	if err != nil {
+		return nil, err
+	}
+	return albumFromDBpediaResults(results, q.Album)
+}
+
+// albumFromDBpediaResults picks the best match among the typed candidates:
+// when the artist has a studio and a live album under the same name, the one
+// typed dbo:Album wins, unless the queried name itself says "live".
+func albumFromDBpediaResults(results *sparqlResults, name string) (*AlbumInfo, error) {
+	preferred := dboAlbumType
+	if strings.Contains(strings.ToLower(name), "live") {
+		preferred = dboLiveAlbumType
+	}
+	var fallback *AlbumInfo
+	for _, binding := range results.Results.Bindings {
+		abstract := binding["abstract"].Value
+		if abstract == "" {
+			continue
+		}
+		info := &AlbumInfo{Description: abstract, URL: binding["resource"].Value, Source: "dbpedia"}
+		if binding["type"].Value == preferred {
+			return info, nil
+		}
+		if fallback == nil {
+			fallback = info
+		}
+	}
+	if fallback == nil {
+		return nil, ErrNotFound
+	}
+	return fallback, nil
+}
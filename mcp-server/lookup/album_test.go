@@ -0,0 +1,75 @@
+package lookup
+
+import (
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("album info", func() {
+	parse := func(body string) *sparqlResults {
+		var results sparqlResults
+		Expect(json.Unmarshal([]byte(body), &results)).To(Succeed())
+		return &results
+	}
+
+	Describe("albumFromWikidataResults", func() {
+		It("returns the description and article link", func() {
+			results := parse(`{"results":{"bindings":[
+				{"description":{"value":"1969 studio album by the Beatles"},
+				 "article":{"value":"https://en.wikipedia.org/wiki/Abbey_Road"}}
+			]}}`)
+			info, err := albumFromWikidataResults(results)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(info.Description).To(Equal("1969 studio album by the Beatles"))
+			Expect(info.URL).To(Equal("https://en.wikipedia.org/wiki/Abbey_Road"))
+			Expect(info.Source).To(Equal("wikidata"))
+		})
+
+		It("returns ErrNotFound for empty results", func() {
+			_, err := albumFromWikidataResults(parse(`{"results":{"bindings":[]}}`))
+			Expect(err).To(MatchError(ErrNotFound))
+		})
+	})
+
+	Describe("albumFromDBpediaResults", func() {
+		twoAlbums := `{"results":{"bindings":[
+			{"abstract":{"value":"Live recording from 1988"},
+			 "resource":{"value":"http://dbpedia.org/resource/Album_(Live)"},
+			 "type":{"value":"http://dbpedia.org/ontology/LiveAlbum"}},
+			{"abstract":{"value":"Studio album from 1985"},
+			 "resource":{"value":"http://dbpedia.org/resource/Album"},
+			 "type":{"value":"http://dbpedia.org/ontology/Album"}}
+		]}}`
+
+		It("prefers the studio album when two share a name", func() {
+			info, err := albumFromDBpediaResults(parse(twoAlbums), "Seventh Son")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(info.Description).To(Equal("Studio album from 1985"))
+			Expect(info.Source).To(Equal("dbpedia"))
+		})
+
+		It("prefers the live album when the queried name says live", func() {
+			info, err := albumFromDBpediaResults(parse(twoAlbums), "Seventh Son (Live)")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(info.Description).To(Equal("Live recording from 1988"))
+		})
+
+		It("falls back to the only candidate when the preferred type is absent", func() {
+			results := parse(`{"results":{"bindings":[
+				{"abstract":{"value":"Live recording from 1988"},
+				 "resource":{"value":"http://dbpedia.org/resource/Album_(Live)"},
+				 "type":{"value":"http://dbpedia.org/ontology/LiveAlbum"}}
+			]}}`)
+			info, err := albumFromDBpediaResults(results, "Seventh Son")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(info.Description).To(Equal("Live recording from 1988"))
+		})
+
+		It("returns ErrNotFound with no usable candidates", func() {
+			_, err := albumFromDBpediaResults(parse(`{"results":{"bindings":[]}}`), "Seventh Son")
+			Expect(err).To(MatchError(ErrNotFound))
+		})
+	})
+})
@@ -0,0 +1,70 @@
+package lookup
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+)
+
+// maxAwards caps how many awards are returned for heavily decorated artists.
+const maxAwards = 20
+
+// Award is a single award received by the artist, with the year from the
+// point-in-time qualifier (P585) when Wikidata has one.
+type Award struct {
+	Name string `json:"name"`
+	Year int    `json:"year,omitempty"`
+}
+
+// awardsSource fetches the artist's awards received (P166) from Wikidata.
+type awardsSource struct {
+	fetcher *Fetcher
+}
+
+func newAwardsSource(f *Fetcher) *awardsSource {
+	return &awardsSource{fetcher: f}
+}
+
+func (s *awardsSource) getAwards(ctx context.Context, q ArtistQuery) ([]Award, error) {
+	if q.MBID == "" {
+		return []Award{}, nil
+	}
+	query := fmt.Sprintf(`SELECT ?awardLabel ?year WHERE {
+  ?artist wdt:P434 %q ;
+          p:P166 ?statement .
+  ?statement ps:P166 ?award .
+  OPTIONAL { ?statement pq:P585 ?date . BIND(YEAR(?date) AS ?year) }
+  SERVICE wikibase:label { bd:serviceParam wikibase:language "en,[AUTO_LANGUAGE]". }
+} ORDER BY ?year`, q.MBID)
+	results, err := s.fetcher.sparql(ctx, wikidataSPARQLEndpoint, query)
+	if err != nil {
+		return nil, err
+	}
+	return awardsFromResults(results), nil
+}
+
+// awardsFromResults extracts awards with optional year qualifiers,
+// de-duplicated and capped at maxAwards.
+func awardsFromResults(results *sparqlResults) []Award {
+	awards := []Award{}
+	seen := map[Award]struct{}{}
+	for _, binding := range results.Results.Bindings {
+		name := binding["awardLabel"].Value
+		if name == "" || entityIDLabel.MatchString(name) {
+			continue
+		}
+		a := Award{Name: name}
+		if year, err := strconv.Atoi(binding["year"].Value); err == nil {
+			a.Year = year
+		}
+		if _, ok := seen[a]; ok {
+			continue
+		}
+		seen[a] = struct{}{}
+		awards = append(awards, a)
+		if len(awards) == maxAwards {
+			break
+		}
+	}
+	return awards
+}
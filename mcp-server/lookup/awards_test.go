@@ -0,0 +1,55 @@
+package lookup
+
+import (
+	"encoding/json"
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("awardsFromResults", func() {
+	parse := func(body string) *sparqlResults {
+		var results sparqlResults
+		Expect(json.Unmarshal([]byte(body), &results)).To(Succeed())
+		return &results
+	}
+
+	It("parses awards with and without year qualifiers", func() {
+		results := parse(`{"results":{"bindings":[
+			{"awardLabel":{"value":"Grammy Award for Best New Artist"},"year":{"value":"1990"}},
+			{"awardLabel":{"value":"Polar Music Prize"}},
+			{"awardLabel":{"value":"Brit Award for Best Group"},"year":{"value":"1995"}}
+		]}}`)
+		Expect(awardsFromResults(results)).To(Equal([]Award{
+			{Name: "Grammy Award for Best New Artist", Year: 1990},
+			{Name: "Polar Music Prize"},
+			{Name: "Brit Award for Best Group", Year: 1995},
+		}))
+	})
+
+	It("de-duplicates identical award/year pairs but keeps distinct years", func() {
+		results := parse(`{"results":{"bindings":[
+			{"awardLabel":{"value":"Grammy Award"},"year":{"value":"1990"}},
+			{"awardLabel":{"value":"Grammy Award"},"year":{"value":"1990"}},
+			{"awardLabel":{"value":"Grammy Award"},"year":{"value":"1992"}}
+		]}}`)
+		Expect(awardsFromResults(results)).To(HaveLen(2))
+	})
+
+	It("caps the list at maxAwards", func() {
+		bindings := ""
+		for i := 0; i < maxAwards+10; i++ {
+			if i > 0 {
+				bindings += ","
+			}
+			bindings += fmt.Sprintf(`{"awardLabel":{"value":"Award %d"}}`, i)
+		}
+		results := parse(`{"results":{"bindings":[` + bindings + `]}}`)
+		Expect(awardsFromResults(results)).To(HaveLen(maxAwards))
+	})
+
+	It("returns an empty, non-nil list when there are none", func() {
+		Expect(awardsFromResults(parse(`{"results":{"bindings":[]}}`))).To(Equal([]Award{}))
+	})
+})
@@ -0,0 +1,222 @@
+// Package lookup resolves artist and album metadata from Wikidata, Wikipedia,
+// DBpedia and MusicBrainz. It is the engine behind the mcp-server tools, but
+// has no MCP dependency: any Go program can construct a Client and call the
+// typed getters directly.
+package lookup
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+)
+
+// Version identifies this lookup library build; the mcp-server reports it in
+// its initialize response and every upstream request carries it in the
+// User-Agent. A variable, so release builds can stamp it via
+// -ldflags="-X .../mcp-server/lookup.Version=..." (see `make mcp-server`).
+var Version = "0.1.0"
+
+// Store memoizes small key/value resolutions (e.g. MBID→article) across
+// restarts. Implementations are provided by the embedding program; a nil Store
+// simply disables memoization.
+type Store interface {
+	Get(key string) (string, bool)
+	Set(key, value string)
+}
+
+// Client bundles every lookup this package offers behind one type. All getters
+// are context-aware, honor per-source timeouts, and return ErrNotFound when no
+// upstream knows the answer - never a partial result dressed up as a hit.
+type Client struct {
+	fetcher     *Fetcher
+	pipeline    *pipeline
+	thumbnails  *thumbnailChain
+	details     *detailsSource
+	awards      *awardsSource
+	discography *discographySource
+	sortNames   *sortNameSource
+	albums      *albumSource
+	similar     *similarSource
+	quickFacts  *quickFactsSource
+	images      *imageSource
+	topSongs    *topSongsSource
+	mbids       *mbidResolver
+}
+
+// NewClient builds a Client on the given Fetcher, with the default source
+// chain. The store memoizes MBID resolutions and may be nil.
+func NewClient(f *Fetcher, store Store) *Client {
+	p := newPipeline(defaultSources(f, store))
+	p.head = f.head
+	return &Client{
+		fetcher:     f,
+		pipeline:    p,
+		thumbnails:  newThumbnailChain(f),
+		details:     newDetailsSource(f),
+		awards:      newAwardsSource(f),
+		discography: newDiscographySource(f),
+		sortNames:   newSortNameSource(f),
+		albums:      newAlbumSource(f),
+		similar:     newSimilarSource(f),
+		quickFacts:  newQuickFactsSource(f),
+		images:      newImageSource(f),
+		topSongs:    newTopSongsSource(f),
+		mbids:       newMBIDResolver(f),
+	}
+}
+
+// SetMBIDMinScore overrides the minimum MusicBrainz search score (0-100)
+// required to trust a resolved MBID, as from MCP_MBID_MIN_SCORE. Zero keeps
+// the default.
+func (c *Client) SetMBIDMinScore(score int) {
+	if score > 0 {
+		c.mbids.minScore = score
+	}
+}
+
+// ApplySourceOrders overrides the per-type source preference, as from the
+// MCP_SOURCES_* configuration. Unknown source names are logged and skipped.
+func (c *Client) ApplySourceOrders(orders map[string][]string) {
+	c.pipeline.applySourceOrders(orders)
+}
+
+// SetLookupCachePolicy tunes the in-memory lookup cache: maxEntries bounds
+// its size, hitTTL and missTTL how long successful and not-found answers
+// live, as from the MCP_LOOKUP_CACHE_* configuration. Zero values keep the
+// defaults.
+func (c *Client) SetLookupCachePolicy(maxEntries int, hitTTL, missTTL time.Duration) {
+	c.pipeline.cache.configure(maxEntries, hitTTL, missTTL)
+}
+
+// Sources lists the registered biography/URL sources, for attribution.
+func (c *Client) Sources() []BioSource {
+	return c.pipeline.sources
+}
+
+// ProbeEndpoints checks the default upstream endpoints and temporarily skips
+// sources behind unreachable ones. Meant to run in the background at startup.
+func (c *Client) ProbeEndpoints(ctx context.Context) {
+	probeEndpoints(ctx, c.fetcher, c.pipeline, defaultProbeTargets())
+}
+
+// GetBiography returns the first non-empty biography the source chain
+// produces, with its attribution fields filled. Queries without an MBID first
+// try to resolve one through MusicBrainz, so the Wikidata-keyed sources are
+// not skipped for artists with incomplete tags; a resolved MBID is reported in
+// the result so the caller can persist it.
+func (c *Client) GetBiography(ctx context.Context, q ArtistQuery) (*BioResult, error) {
+	q, resolved := c.resolveMissingMBID(ctx, q)
+	result, err := c.pipeline.getBiography(ctx, q)
+	if err == nil {
+		result.ResolvedMBID = resolved
+	}
+	return result, err
+}
+
+// resolveMissingMBID fills in q.MBID via a MusicBrainz search when the query
+// has none, additionally returning the resolved value. Resolution failures are
+// not fatal: the original query proceeds unchanged, and the name-keyed sources
+// still apply.
+func (c *Client) resolveMissingMBID(ctx context.Context, q ArtistQuery) (ArtistQuery, string) {
+	if q.MBID != "" {
+		return q, ""
+	}
+	mbid, err := c.mbids.resolve(ctx, q.Name)
+	if err != nil {
+		if !errors.Is(err, ErrNotFound) {
+			log.Printf("MBID resolution failed for %q: %v", q.Name, err)
+		}
+		return q, ""
+	}
+	log.Printf("resolved %q to MBID %s via MusicBrainz search", q.Name, mbid)
+	q.MBID = mbid
+	return q, mbid
+}
+
+// GetURL returns the canonical artist URL, falling back to a search URL built
+// from the query's template when no source has one.
+func (c *Client) GetURL(ctx context.Context, q ArtistQuery) (*BioResult, error) {
+	return c.pipeline.getURL(ctx, q)
+}
+
+// GetThumbnail returns a single small image URL from the fastest source.
+func (c *Client) GetThumbnail(ctx context.Context, q ArtistQuery) (string, error) {
+	return c.thumbnails.getThumbnail(ctx, q)
+}
+
+// GetDetails returns structured artist facts (instruments, languages). Absent
+// facts yield empty fields, not errors.
+func (c *Client) GetDetails(ctx context.Context, q ArtistQuery) (*ArtistDetails, error) {
+	return c.details.getDetails(ctx, q)
+}
+
+// GetAwards returns the artist's awards; no awards is a successful, empty
+// result.
+func (c *Client) GetAwards(ctx context.Context, q ArtistQuery) ([]Award, error) {
+	return c.awards.getAwards(ctx, q)
+}
+
+// GetDiscography returns the artist's release groups, ordered by year.
+func (c *Client) GetDiscography(ctx context.Context, q ArtistQuery) ([]DiscographyEntry, error) {
+	return c.discography.getDiscography(ctx, q)
+}
+
+// GetSortName returns the artist's sort name ("Beatles, The").
+func (c *Client) GetSortName(ctx context.Context, q ArtistQuery) (string, error) {
+	return c.sortNames.getSortName(ctx, q)
+}
+
+// GetAlbumInfo returns a description for an album.
+func (c *Client) GetAlbumInfo(ctx context.Context, q AlbumQuery) (*AlbumInfo, error) {
+	return c.albums.getAlbumInfo(ctx, q)
+}
+
+// GetSimilar returns artists related to the given one, with MBIDs populated.
+func (c *Client) GetSimilar(ctx context.Context, q SimilarQuery) ([]SimilarArtist, error) {
+	return c.similar.getSimilar(ctx, q)
+}
+
+// GetImages returns the artist's image candidates, each Commons file expanded
+// into scaled variants, largest first.
+func (c *Client) GetImages(ctx context.Context, q ArtistQuery) ([]ArtistImage, error) {
+	return c.images.getImages(ctx, q)
+}
+
+// GetTopSongs returns the artist's best-known songs, capped by q.Count.
+func (c *Client) GetTopSongs(ctx context.Context, q TopSongsQuery) ([]TopSong, error) {
+	return c.topSongs.getTopSongs(ctx, q)
+}
+
+// ResolveMBID resolves the artist's name to a MusicBrainz ID, for callers
+// that want the identifier itself rather than its effect on other lookups. A
+// query that already carries an MBID gets it back unchanged.
+func (c *Client) ResolveMBID(ctx context.Context, q ArtistQuery) (string, error) {
+	if q.MBID != "" {
+		return q.MBID, nil
+	}
+	return c.mbids.resolve(ctx, q.Name)
+}
+
+// PlanBiography lists the requests GetBiography would issue for the query, in
+// order, without touching the network - the engine behind the tools' dryRun
+// argument. A missing MBID adds the MusicBrainz search that would resolve it.
+func (c *Client) PlanBiography(q ArtistQuery) []PlannedRequest {
+	var planned []PlannedRequest
+	if q.MBID == "" && q.Name != "" {
+		planned = append(planned, PlannedRequest{Source: "musicbrainz", Method: http.MethodGet, URL: c.mbids.searchURL(q.Name)})
+	}
+	return append(planned, c.pipeline.planLookup(typeBio, q)...)
+}
+
+// PlanURL lists the requests GetURL would issue for the query, in order,
+// without touching the network.
+func (c *Client) PlanURL(q ArtistQuery) []PlannedRequest {
+	return c.pipeline.planLookup(typeLinks, q)
+}
+
+// GetQuickFacts returns the compact fact bundle for a brand-new artist.
+func (c *Client) GetQuickFacts(ctx context.Context, q ArtistQuery) (*ArtistQuickFacts, error) {
+	return c.quickFacts.getQuickFacts(ctx, q)
+}
@@ -0,0 +1,64 @@
+package lookup
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+const dbpediaSPARQLEndpoint = "https://dbpedia.org/sparql"
+
+// dbpediaSource looks the artist up on DBpedia by name and returns the
+// English abstract. It is a fallback for artists without an MBID, so it
+// trades precision for coverage.
+type dbpediaSource struct {
+	fetcher *Fetcher
+}
+
+func newDBpediaSource(f *Fetcher) *dbpediaSource {
+	return &dbpediaSource{fetcher: f}
+}
+
+func (s *dbpediaSource) Name() string { return "dbpedia" }
+
+func (s *dbpediaSource) License() string { return "CC BY-SA 3.0" }
+
+func (s *dbpediaSource) Lookup(ctx context.Context, q ArtistQuery) (*BioResult, error) {
+	if q.Name == "" {
+		return nil, ErrNotFound
+	}
+	results, err := s.fetcher.sparql(ctx, dbpediaSPARQLEndpoint, dbpediaAbstractQuery(q.Name))
+	if err != nil {
+		return nil, err
+	}
+	abstract := results.first("abstract")
+	if abstract == "" {
+		return nil, ErrNotFound
+	}
+	return &BioResult{Text: abstract, URL: results.first("resource")}, nil
+}
+
+// dbpediaAbstractQuery is the SPARQL query looking the artist's abstract up
+// by name, shared with the dry-run planner.
+func dbpediaAbstractQuery(name string) string {
+	return fmt.Sprintf(`SELECT ?abstract ?resource WHERE {
+  ?resource rdfs:label %q@en ;
+            a dbo:MusicalArtist ;
+            dbo:abstract ?abstract .
+  FILTER (lang(?abstract) = "en")
+} LIMIT 1`, sanitizeSPARQLString(name))
+}
+
+// plan lists the abstract request a lookup would issue.
+func (s *dbpediaSource) plan(q ArtistQuery) []PlannedRequest {
+	if q.Name == "" {
+		return nil
+	}
+	return plannedGet(s.Name(), sparqlRequestURL(dbpediaSPARQLEndpoint, dbpediaAbstractQuery(q.Name)))
+}
+
+// sanitizeSPARQLString strips characters that would break out of a SPARQL
+// string literal.
+func sanitizeSPARQLString(s string) string {
+	return strings.NewReplacer("\"", "", "\\", "", "\n", " ", "\r", " ").Replace(s)
+}
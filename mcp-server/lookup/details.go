@@ -0,0 +1,109 @@
+package lookup
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+)
+
+// ArtistDetails is the structured payload of the get_artist_details tool.
+type ArtistDetails struct {
+	Instruments []string         `json:"instruments"`
+	Languages   []ArtistLanguage `json:"languages"`
+}
+
+// ArtistLanguage is a language the artist performs in, with its ISO 639-1
+// code (when Wikidata has one) and localized name.
+type ArtistLanguage struct {
+	Code string `json:"code,omitempty"`
+	Name string `json:"name"`
+}
+
+// detailsSource fetches structured artist facts from Wikidata, starting with
+// the instruments played (P1303). Absent facts yield empty fields, not errors.
+type detailsSource struct {
+	fetcher *Fetcher
+}
+
+func newDetailsSource(f *Fetcher) *detailsSource {
+	return &detailsSource{fetcher: f}
+}
+
+func (s *detailsSource) getDetails(ctx context.Context, q ArtistQuery) (*ArtistDetails, error) {
+	details := &ArtistDetails{Instruments: []string{}, Languages: []ArtistLanguage{}}
+	if q.MBID == "" {
+		return details, nil
+	}
+	query := fmt.Sprintf(`SELECT ?instrumentLabel WHERE {
+  ?artist wdt:P434 %q ;
+          wdt:P1303 ?instrument .
+  SERVICE wikibase:label { bd:serviceParam wikibase:language "en,[AUTO_LANGUAGE]". }
+}`, q.MBID)
+	results, err := s.fetcher.sparql(ctx, wikidataSPARQLEndpoint, query)
+	if err != nil {
+		return nil, err
+	}
+	details.Instruments = instrumentsFromResults(results)
+	details.Languages = s.languages(ctx, q.MBID)
+	return details, nil
+}
+
+// languages lists the artist's languages of performance: P1412 (languages
+// spoken or written), with P407 (language of work) as a fallback signal, each
+// with its ISO 639-1 code where Wikidata has one. Absent facts yield an empty
+// list, not an error.
+func (s *detailsSource) languages(ctx context.Context, mbid string) []ArtistLanguage {
+	query := fmt.Sprintf(`SELECT ?code ?languageLabel WHERE {
+  ?artist wdt:P434 %q .
+  { ?artist wdt:P1412 ?language . } UNION { ?artist wdt:P407 ?language . }
+  OPTIONAL { ?language wdt:P218 ?code . }
+  SERVICE wikibase:label { bd:serviceParam wikibase:language "en,[AUTO_LANGUAGE]". }
+}`, mbid)
+	results, err := s.fetcher.sparql(ctx, wikidataSPARQLEndpoint, query)
+	if err != nil {
+		return []ArtistLanguage{}
+	}
+	return languagesFromResults(results)
+}
+
+// entityIDLabel matches labels the Wikidata label service could not resolve,
+// which come back as the raw entity ID (e.g. "Q547262").
+var entityIDLabel = regexp.MustCompile(`^Q\d+$`)
+
+// instrumentsFromResults extracts the localized instrument names from a P1303
+// query, de-duplicated and in response order.
+func instrumentsFromResults(results *sparqlResults) []string {
+	instruments := []string{}
+	seen := map[string]struct{}{}
+	for _, binding := range results.Results.Bindings {
+		label := binding["instrumentLabel"].Value
+		if label == "" || entityIDLabel.MatchString(label) {
+			continue
+		}
+		if _, ok := seen[label]; ok {
+			continue
+		}
+		seen[label] = struct{}{}
+		instruments = append(instruments, label)
+	}
+	return instruments
+}
+
+// languagesFromResults extracts the localized language names and ISO codes
+// from a P1412/P407 query, de-duplicated and in response order.
+func languagesFromResults(results *sparqlResults) []ArtistLanguage {
+	languages := []ArtistLanguage{}
+	seen := map[string]struct{}{}
+	for _, binding := range results.Results.Bindings {
+		name := binding["languageLabel"].Value
+		if name == "" || entityIDLabel.MatchString(name) {
+			continue
+		}
+		if _, ok := seen[name]; ok {
+			continue
+		}
+		seen[name] = struct{}{}
+		languages = append(languages, ArtistLanguage{Code: binding["code"].Value, Name: name})
+	}
+	return languages
+}
@@ -0,0 +1,82 @@
+package lookup
+
+import (
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("instrumentsFromResults", func() {
+	parse := func(body string) *sparqlResults {
+		var results sparqlResults
+		Expect(json.Unmarshal([]byte(body), &results)).To(Succeed())
+		return &results
+	}
+
+	It("parses a multi-instrument response, de-duplicated and in order", func() {
+		results := parse(`{"results":{"bindings":[
+			{"instrumentLabel":{"value":"piano"}},
+			{"instrumentLabel":{"value":"saxophone"}},
+			{"instrumentLabel":{"value":"piano"}},
+			{"instrumentLabel":{"value":"flute"}}
+		]}}`)
+		Expect(instrumentsFromResults(results)).To(Equal([]string{"piano", "saxophone", "flute"}))
+	})
+
+	It("drops unresolved entity-ID labels", func() {
+		results := parse(`{"results":{"bindings":[
+			{"instrumentLabel":{"value":"Q547262"}},
+			{"instrumentLabel":{"value":"guitar"}}
+		]}}`)
+		Expect(instrumentsFromResults(results)).To(Equal([]string{"guitar"}))
+	})
+
+	It("returns an empty, non-nil list when there are no instruments", func() {
+		results := parse(`{"results":{"bindings":[]}}`)
+		Expect(instrumentsFromResults(results)).To(Equal([]string{}))
+	})
+})
+
+var _ = Describe("languagesFromResults", func() {
+	parse := func(body string) *sparqlResults {
+		var results sparqlResults
+		Expect(json.Unmarshal([]byte(body), &results)).To(Succeed())
+		return &results
+	}
+
+	It("parses a single-language response", func() {
+		results := parse(`{"results":{"bindings":[
+			{"code":{"value":"en"},"languageLabel":{"value":"English"}}
+		]}}`)
+		Expect(languagesFromResults(results)).To(Equal([]ArtistLanguage{
+			{Code: "en", Name: "English"},
+		}))
+	})
+
+	It("parses multiple languages, de-duplicated and in order", func() {
+		results := parse(`{"results":{"bindings":[
+			{"code":{"value":"is"},"languageLabel":{"value":"Icelandic"}},
+			{"code":{"value":"en"},"languageLabel":{"value":"English"}},
+			{"code":{"value":"is"},"languageLabel":{"value":"Icelandic"}}
+		]}}`)
+		Expect(languagesFromResults(results)).To(Equal([]ArtistLanguage{
+			{Code: "is", Name: "Icelandic"},
+			{Code: "en", Name: "English"},
+		}))
+	})
+
+	It("keeps languages without an ISO code and drops unresolved labels", func() {
+		results := parse(`{"results":{"bindings":[
+			{"languageLabel":{"value":"Scots"}},
+			{"languageLabel":{"value":"Q33111"}}
+		]}}`)
+		Expect(languagesFromResults(results)).To(Equal([]ArtistLanguage{
+			{Name: "Scots"},
+		}))
+	})
+
+	It("returns an empty, non-nil list when there are no languages", func() {
+		Expect(languagesFromResults(parse(`{"results":{"bindings":[]}}`))).To(Equal([]ArtistLanguage{}))
+	})
+})
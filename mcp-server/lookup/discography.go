@@ -0,0 +1,109 @@
+package lookup
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+)
+
+const musicbrainzAPIEndpoint = "https://musicbrainz.org/ws/2"
+
+// Pagination limits for the discography lookup: pageSize is MusicBrainz's
+// maximum per request, maxDiscography caps the total for prolific artists.
+const (
+	discographyPageSize = 100
+	maxDiscography      = 200
+)
+
+// DiscographyEntry is a single release group in an artist's discography.
+type DiscographyEntry struct {
+	Title string `json:"title"`
+	Year  int    `json:"year,omitempty"`
+	Type  string `json:"type,omitempty"`
+	MBID  string `json:"mbid"`
+}
+
+// releaseGroupsResponse is the subset of the MusicBrainz release-group browse
+// response we use.
+type releaseGroupsResponse struct {
+	Count         int `json:"release-group-count"`
+	ReleaseGroups []struct {
+		ID               string `json:"id"`
+		Title            string `json:"title"`
+		FirstReleaseDate string `json:"first-release-date"`
+		PrimaryType      string `json:"primary-type"`
+	} `json:"release-groups"`
+}
+
+// discographySource fetches an artist's release groups from MusicBrainz.
+type discographySource struct {
+	fetcher *Fetcher
+}
+
+func newDiscographySource(f *Fetcher) *discographySource {
+	return &discographySource{fetcher: f}
+}
+
+func (s *discographySource) getDiscography(ctx context.Context, q ArtistQuery) ([]DiscographyEntry, error) {
+	if q.MBID == "" {
+		return nil, ErrNotFound
+	}
+	var entries []DiscographyEntry
+	for offset := 0; len(entries) < maxDiscography; offset += discographyPageSize {
+		u := fmt.Sprintf("%s/release-group?artist=%s&limit=%d&offset=%d&fmt=json",
+			musicbrainzAPIEndpoint, url.QueryEscape(q.MBID), discographyPageSize, offset)
+		var page releaseGroupsResponse
+		if err := s.fetcher.getJSON(ctx, u, nil, &page); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entriesFromResponse(&page)...)
+		if offset+discographyPageSize >= page.Count {
+			break
+		}
+	}
+	if len(entries) == 0 {
+		return nil, ErrNotFound
+	}
+	if len(entries) > maxDiscography {
+		entries = entries[:maxDiscography]
+	}
+	sortDiscography(entries)
+	return entries, nil
+}
+
+func entriesFromResponse(page *releaseGroupsResponse) []DiscographyEntry {
+	var entries []DiscographyEntry
+	for _, rg := range page.ReleaseGroups {
+		if rg.Title == "" || rg.ID == "" {
+			continue
+		}
+		entry := DiscographyEntry{Title: rg.Title, Type: rg.PrimaryType, MBID: rg.ID}
+		if len(rg.FirstReleaseDate) >= 4 {
+			if year, err := strconv.Atoi(rg.FirstReleaseDate[:4]); err == nil {
+				entry.Year = year
+			}
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// sortDiscography orders entries by year ascending, undated releases last, and
+// by title within the same year, so the output is stable across page layouts.
+func sortDiscography(entries []DiscographyEntry) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		yi, yj := entries[i].Year, entries[j].Year
+		if yi == 0 {
+			yi = int(^uint(0) >> 1)
+		}
+		if yj == 0 {
+			yj = int(^uint(0) >> 1)
+		}
+		if yi != yj {
+			return yi < yj
+		}
+		return entries[i].Title < entries[j].Title
+	})
+}
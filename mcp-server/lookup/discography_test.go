@@ -0,0 +1,53 @@
+package lookup
+
+import (
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("discography", func() {
+	parse := func(body string) *releaseGroupsResponse {
+		var page releaseGroupsResponse
+		Expect(json.Unmarshal([]byte(body), &page)).To(Succeed())
+		return &page
+	}
+
+	It("parses a canned response into structured entries", func() {
+		page := parse(`{"release-group-count":2,"release-groups":[
+			{"id":"rg-1","title":"Abbey Road","first-release-date":"1969-09-26","primary-type":"Album"},
+			{"id":"rg-2","title":"Let It Be","first-release-date":"1970-05-08","primary-type":"Album"}
+		]}`)
+		Expect(entriesFromResponse(page)).To(Equal([]DiscographyEntry{
+			{Title: "Abbey Road", Year: 1969, Type: "Album", MBID: "rg-1"},
+			{Title: "Let It Be", Year: 1970, Type: "Album", MBID: "rg-2"},
+		}))
+	})
+
+	It("skips entries without a title or MBID and tolerates missing dates", func() {
+		page := parse(`{"release-group-count":3,"release-groups":[
+			{"id":"rg-1","title":""},
+			{"id":"","title":"No ID"},
+			{"id":"rg-2","title":"Undated EP","primary-type":"EP"}
+		]}`)
+		Expect(entriesFromResponse(page)).To(Equal([]DiscographyEntry{
+			{Title: "Undated EP", Type: "EP", MBID: "rg-2"},
+		}))
+	})
+
+	It("orders entries by year, undated last, and by title within a year", func() {
+		entries := []DiscographyEntry{
+			{Title: "Undated", MBID: "rg-1"},
+			{Title: "Zebra", Year: 1970, MBID: "rg-2"},
+			{Title: "Later", Year: 1980, MBID: "rg-3"},
+			{Title: "Alpha", Year: 1970, MBID: "rg-4"},
+		}
+		sortDiscography(entries)
+		titles := make([]string, len(entries))
+		for i, e := range entries {
+			titles[i] = e.Title
+		}
+		Expect(titles).To(Equal([]string{"Alpha", "Zebra", "Later", "Undated"}))
+	})
+})
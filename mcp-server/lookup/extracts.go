@@ -0,0 +1,130 @@
+package lookup
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+)
+
+// maxTitleBytes is MediaWiki's hard limit on page title length.
+const maxTitleBytes = 255
+
+// validateTitle rejects titles that could change the query semantics when
+// embedded in a MediaWiki titles= parameter: the batch separator, control
+// characters, and titles over MediaWiki's 255-byte limit. Titles are extracted
+// from Wikidata-provided URLs, which anyone can edit - they are untrusted
+// input, not data.
+func validateTitle(title string) error {
+	if strings.Contains(title, "|") {
+		return fmt.Errorf("invalid title %q: %q is the batch separator", title, "|")
+	}
+	for _, r := range title {
+		if r < 0x20 || r == 0x7f {
+			return fmt.Errorf("invalid title %q: contains control characters", title)
+		}
+	}
+	if len(title) > maxTitleBytes {
+		return fmt.Errorf("invalid title: %d bytes is over MediaWiki's %d-byte limit", len(title), maxTitleBytes)
+	}
+	return nil
+}
+
+// mediaWikiExtracts is the subset of the MediaWiki prop=extracts response we
+// use. The API answers under canonical page titles; the normalized and
+// redirects lists record how the requested titles were rewritten on the way.
+type mediaWikiExtracts struct {
+	Query struct {
+		Normalized []titleRewrite `json:"normalized"`
+		Redirects  []titleRewrite `json:"redirects"`
+		Pages      map[string]struct {
+			Title   string `json:"title"`
+			Extract string `json:"extract"`
+		} `json:"pages"`
+	} `json:"query"`
+}
+
+// titleRewrite is one from→to title rewrite reported by the API.
+type titleRewrite struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// extracts fetches the intro extracts for one or more titles in a single
+// request, keyed by the titles as requested. Titles the wiki does not know are
+// absent from the map. Both the single and batch lookup paths go through here,
+// so redirect bookkeeping lives in one place.
+func (s *wikipediaSource) extracts(ctx context.Context, titles []string) (map[string]string, error) {
+	for _, title := range titles {
+		if err := validateTitle(title); err != nil {
+			return nil, err
+		}
+	}
+	u := fmt.Sprintf("%s?action=query&prop=extracts&exintro=1&explaintext=1&redirects=1&titles=%s&format=json",
+		wikipediaActionAPI, url.QueryEscape(strings.Join(titles, "|")))
+	var resp mediaWikiExtracts
+	if err := s.fetcher.getJSON(ctx, u, nil, &resp); err != nil {
+		return nil, err
+	}
+	extracts, diverged := extractsByTitle(&resp, titles)
+	if len(diverged) > 0 {
+		log.Printf("wikipedia extracts: canonical titles %q diverge from requested %q beyond reported normalization, text dropped",
+			diverged, titles)
+	}
+	return extracts, nil
+}
+
+// extract is the single-title convenience over extracts.
+func (s *wikipediaSource) extract(ctx context.Context, title string) (string, error) {
+	extracts, err := s.extracts(ctx, []string{title})
+	if err != nil {
+		return "", err
+	}
+	text, ok := extracts[title]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return text, nil
+}
+
+// extractsByTitle maps each requested title to its extract, following the
+// normalization and redirect rewrites the API reports, so batched artists do
+// not get each other's text. Missing pages are simply absent from the result.
+// Canonical titles in the response that no requested title reaches through the
+// reported rewrites are returned separately: their text is never attributed to
+// a requested title, only reported so the caller can log the divergence.
+func extractsByTitle(resp *mediaWikiExtracts, titles []string) (map[string]string, []string) {
+	byCanonical := map[string]string{}
+	for _, page := range resp.Query.Pages {
+		if page.Extract != "" {
+			byCanonical[page.Title] = page.Extract
+		}
+	}
+	extracts := make(map[string]string, len(titles))
+	claimed := make(map[string]bool, len(titles))
+	for _, title := range titles {
+		canonical := rewriteTitle(resp.Query.Redirects, rewriteTitle(resp.Query.Normalized, title))
+		if text, ok := byCanonical[canonical]; ok {
+			extracts[title] = text
+			claimed[canonical] = true
+		}
+	}
+	var diverged []string
+	for canonical := range byCanonical {
+		if !claimed[canonical] {
+			diverged = append(diverged, canonical)
+		}
+	}
+	return extracts, diverged
+}
+
+// rewriteTitle applies the first matching from→to rewrite, if any.
+func rewriteTitle(rewrites []titleRewrite, title string) string {
+	for _, r := range rewrites {
+		if r.From == title {
+			return r.To
+		}
+	}
+	return title
+}
@@ -0,0 +1,111 @@
+package lookup
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("extractsByTitle", func() {
+	parse := func(body string) *mediaWikiExtracts {
+		var resp mediaWikiExtracts
+		Expect(json.Unmarshal([]byte(body), &resp)).To(Succeed())
+		return &resp
+	}
+
+	It("maps a three-title batch back to the requested titles", func() {
+		// "nirvana (band)" is normalized, "Led Zepplin" is a redirect, and
+		// "No Such Band" is missing (negative page ID, no extract).
+		resp := parse(`{"query":{
+			"normalized":[{"from":"nirvana (band)","to":"Nirvana (band)"}],
+			"redirects":[{"from":"Led Zepplin","to":"Led Zeppelin"}],
+			"pages":{
+				"21231":{"title":"Nirvana (band)","extract":"Nirvana was an American rock band."},
+				"17909":{"title":"Led Zeppelin","extract":"Led Zeppelin were an English rock band."},
+				"-1":{"title":"No Such Band"}
+			}
+		}}`)
+		titles := []string{"nirvana (band)", "Led Zepplin", "No Such Band"}
+		Expect(extractsByTitle(resp, titles)).To(Equal(map[string]string{
+			"nirvana (band)": "Nirvana was an American rock band.",
+			"Led Zepplin":    "Led Zeppelin were an English rock band.",
+		}))
+	})
+
+	It("follows a normalization chained into a redirect", func() {
+		resp := parse(`{"query":{
+			"normalized":[{"from":"led zepplin","to":"Led Zepplin"}],
+			"redirects":[{"from":"Led Zepplin","to":"Led Zeppelin"}],
+			"pages":{"17909":{"title":"Led Zeppelin","extract":"An English rock band."}}
+		}}`)
+		Expect(extractsByTitle(resp, []string{"led zepplin"})).To(Equal(map[string]string{
+			"led zepplin": "An English rock band.",
+		}))
+	})
+
+	It("returns an empty map when nothing matches", func() {
+		resp := parse(`{"query":{"pages":{"-1":{"title":"Nope"}}}}`)
+		Expect(extractsByTitle(resp, []string{"Nope"})).To(BeEmpty())
+	})
+
+	It("never attributes text from a canonical title no requested title reaches", func() {
+		// No normalized/redirects entry connects the requested title to the
+		// returned page: the text is dropped and the divergence reported.
+		resp := parse(`{"query":{"pages":{"666":{"title":"Totally Different Page","extract":"Wrong text."}}}}`)
+		extracts, diverged := extractsByTitle(resp, []string{"The Artist"})
+		Expect(extracts).To(BeEmpty())
+		Expect(diverged).To(Equal([]string{"Totally Different Page"}))
+	})
+})
+
+var _ = Describe("validateTitle", func() {
+	It("accepts ordinary titles", func() {
+		Expect(validateTitle("Nirvana (band)")).To(Succeed())
+		Expect(validateTitle("AC/DC")).To(Succeed())
+	})
+
+	It("rejects the batch separator", func() {
+		Expect(validateTitle("AC|DC")).To(MatchError(ContainSubstring("batch separator")))
+	})
+
+	It("rejects control characters", func() {
+		Expect(validateTitle("The\nArtist")).To(MatchError(ContainSubstring("control characters")))
+		Expect(validateTitle("The\x00Artist")).To(MatchError(ContainSubstring("control characters")))
+	})
+
+	It("rejects titles over MediaWiki's byte limit", func() {
+		long := strings.Repeat("a", maxTitleBytes+1)
+		Expect(validateTitle(long)).To(MatchError(ContainSubstring("byte limit")))
+	})
+})
+
+var _ = Describe("titleFromArticleURL", func() {
+	It("decodes the title exactly once", func() {
+		Expect(titleFromArticleURL("https://en.wikipedia.org/wiki/AC%2FDC")).To(Equal("AC/DC"))
+		// A double-encoded separator stays encoded: literal text, not a pipe.
+		Expect(titleFromArticleURL("https://en.wikipedia.org/wiki/AC%257CDC")).To(Equal("AC%7CDC"))
+	})
+
+	It("rejects hostile titles from a tampered article URL", func() {
+		Expect(titleFromArticleURL("https://en.wikipedia.org/wiki/Artist%7CAnother")).To(BeEmpty())
+		Expect(titleFromArticleURL("https://en.wikipedia.org/wiki/Artist%0AAnother")).To(BeEmpty())
+		Expect(titleFromArticleURL("https://en.wikipedia.org/wiki/" + strings.Repeat("a", 300))).To(BeEmpty())
+	})
+})
+
+var _ = Describe("wikipediaSource extracts", func() {
+	It("rejects titles containing the batch separator before fetching", func() {
+		s := newWikipediaSource(nil)
+		_, err := s.extracts(context.Background(), []string{"AC|DC"})
+		Expect(err).To(MatchError(ContainSubstring("batch separator")))
+	})
+
+	It("rejects titles with control characters before fetching", func() {
+		s := newWikipediaSource(nil)
+		_, err := s.extracts(context.Background(), []string{"AC\rDC"})
+		Expect(err).To(MatchError(ContainSubstring("control characters")))
+	})
+})
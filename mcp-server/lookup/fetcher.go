@@ -0,0 +1,386 @@
+package lookup
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+var userAgent = "navidrome-mcp-server/" + Version + " (https://github.com/navidrome/navidrome)"
+
+// SetUserAgent overrides the User-Agent sent with every upstream request, as
+// from MCP_USER_AGENT. Wikimedia's API policy asks for a real product and
+// contact; the default identifies this mcp-server build.
+func SetUserAgent(ua string) {
+	if ua != "" {
+		userAgent = ua
+	}
+}
+
+// maxResponseBody caps how much of an upstream response is read, protecting
+// the server from endless or hostile payloads.
+const maxResponseBody = 2 * 1024 * 1024
+
+// logBodyLimit is how much of an upstream response body makes it into errors
+// and logs. A misbehaving SPARQL endpoint answers with hundreds of KB of HTML;
+// 2KB is plenty to diagnose it.
+const logBodyLimit = 2 * 1024
+
+// LogFullBodies, set from MCP_LOG_FULL_BODIES, additionally logs the complete
+// body of failed responses for deep debugging.
+var LogFullBodies bool
+
+// truncateForLog renders a response body for inclusion in an error or log
+// line, cut at n bytes (logBodyLimit when n is zero) with an indication of how
+// much was omitted.
+func truncateForLog(b []byte, n int) string {
+	if n <= 0 {
+		n = logBodyLimit
+	}
+	if len(b) <= n {
+		return string(b)
+	}
+	return fmt.Sprintf("%s... (%d bytes omitted)", b[:n], len(b)-n)
+}
+
+// RequestLogMode controls how outbound URLs appear in this package's log
+// lines: "full-url" (the default), "hosts-only" (paths and queries redacted)
+// or "off". Set from MCP_REQUEST_LOG.
+var RequestLogMode = "full-url"
+
+// loggedURL renders a URL for logging according to RequestLogMode.
+// Unparseable URLs are redacted in hosts-only mode rather than risk leaking a
+// query through a malformed value.
+func loggedURL(rawURL string) string {
+	switch strings.ToLower(RequestLogMode) {
+	case "off":
+		return "[redacted]"
+	case "hosts-only":
+		u, err := url.Parse(rawURL)
+		if err != nil || u.Host == "" {
+			return "[redacted]"
+		}
+		return u.Scheme + "://" + u.Host
+	default:
+		return rawURL
+	}
+}
+
+// logFullBody logs the complete body of a failed response when enabled.
+func logFullBody(what string, body []byte) {
+	if LogFullBodies {
+		log.Printf("full %s body: %s", what, body)
+	}
+}
+
+// sparqlCacheTTL bounds how long SPARQL responses are reused. Upstream data
+// changes slowly, but an hour keeps corrections from being invisible all day.
+const sparqlCacheTTL = time.Hour
+
+// Retry defaults: transient upstream failures (5xx, connection resets) are
+// retried with exponential backoff starting at defaultRetryDelay. Client
+// errors (4xx) are never retried - the answer will not change.
+const (
+	defaultMaxRetries = 2
+	defaultRetryDelay = 500 * time.Millisecond
+)
+
+// musicbrainzInterval spaces out requests to musicbrainz.org, which mandates
+// at most one request per second for anonymous clients.
+const musicbrainzInterval = time.Second
+
+// fetcher is the single HTTP entry point for all sources, so policies like
+// User-Agent, body limits and cache-control handling are applied uniformly.
+type Fetcher struct {
+	client *http.Client
+	// maxRetries is how many times a transiently failed request is retried;
+	// retryDelay is the backoff base, doubling per attempt.
+	maxRetries int
+	retryDelay time.Duration
+
+	// mbMu serializes requests to musicbrainz.org; mbNext is the earliest the
+	// next one may go out, mbInterval (musicbrainzInterval, shrunk in tests)
+	// apart from the last.
+	mbMu       sync.Mutex
+	mbNext     time.Time
+	mbInterval time.Duration
+
+	mu          sync.Mutex
+	sparqlCache map[string]sparqlCacheEntry
+	headCache   map[string]headCacheEntry
+}
+
+type sparqlCacheEntry struct {
+	body    []byte
+	expires time.Time
+}
+
+type headCacheEntry struct {
+	status  int
+	expires time.Time
+}
+
+func NewFetcher() *Fetcher {
+	return &Fetcher{
+		client:      &http.Client{Timeout: 30 * time.Second},
+		maxRetries:  defaultMaxRetries,
+		retryDelay:  defaultRetryDelay,
+		mbInterval:  musicbrainzInterval,
+		sparqlCache: map[string]sparqlCacheEntry{},
+		headCache:   map[string]headCacheEntry{},
+	}
+}
+
+// SetRetryPolicy overrides how transient failures are retried: maxRetries
+// additional attempts (zero disables retrying), backed off exponentially from
+// baseDelay.
+func (f *Fetcher) SetRetryPolicy(maxRetries int, baseDelay time.Duration) {
+	f.maxRetries = maxRetries
+	if baseDelay > 0 {
+		f.retryDelay = baseDelay
+	}
+}
+
+// get fetches the URL and returns the response body.
+func (f *Fetcher) get(ctx context.Context, rawURL string, headers map[string]string) ([]byte, error) {
+	body, _, _, err := f.getWithHeaders(ctx, rawURL, headers)
+	return body, err
+}
+
+// getWithHeaders fetches the URL and returns the response body along with the
+// response headers and the final URL after any redirects, so callers can honor
+// upstream caching directives and use the canonical location (the REST summary
+// endpoint redirects non-canonical titles).
+func (f *Fetcher) getWithHeaders(ctx context.Context, rawURL string, headers map[string]string) ([]byte, http.Header, string, error) {
+	delay := f.retryDelay
+	for attempt := 0; ; attempt++ {
+		a, err := f.fetchOnce(ctx, rawURL, headers)
+		if (err != nil || a.status >= http.StatusInternalServerError) && attempt < f.maxRetries {
+			log.Printf("transient failure fetching %s (attempt %d of %d), retrying in %s",
+				loggedURL(rawURL), attempt+1, f.maxRetries+1, delay)
+			select {
+			case <-ctx.Done():
+				return nil, nil, rawURL, ctx.Err()
+			case <-time.After(delay):
+			}
+			delay *= 2
+			continue
+		}
+		// The final answer - success or not - reflects the last attempt.
+		if err != nil {
+			return nil, nil, rawURL, err
+		}
+		switch a.status {
+		case http.StatusNotFound:
+			return nil, a.header, a.finalURL, ErrNotFound
+		case http.StatusOK:
+			return a.body, a.header, a.finalURL, nil
+		default:
+			logFullBody("error response", a.body)
+			return nil, a.header, a.finalURL, fmt.Errorf("unexpected status %d from %s: %s",
+				a.status, a.host, truncateForLog(a.body, 0))
+		}
+	}
+}
+
+// fetchAttempt is the raw outcome of a single HTTP attempt, before status
+// interpretation.
+type fetchAttempt struct {
+	body     []byte
+	header   http.Header
+	finalURL string
+	status   int
+	host     string
+}
+
+// fetchOnce performs one GET attempt. The returned error covers request
+// creation, transport and body-read failures - all retryable; HTTP-level
+// failures are left in status for the caller to interpret.
+func (f *Fetcher) fetchOnce(ctx context.Context, rawURL string, headers map[string]string) (fetchAttempt, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return fetchAttempt{}, err
+	}
+	req.Header.Set("User-Agent", userAgent)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	if err := f.throttle(ctx, req.URL.Host); err != nil {
+		return fetchAttempt{}, err
+	}
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return fetchAttempt{}, err
+	}
+	defer resp.Body.Close()
+	a := fetchAttempt{header: resp.Header, finalURL: rawURL, status: resp.StatusCode, host: req.URL.Host}
+	if resp.Request != nil && resp.Request.URL != nil {
+		a.finalURL = resp.Request.URL.String()
+	}
+	a.body, err = io.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
+	if err != nil && resp.StatusCode == http.StatusOK {
+		return fetchAttempt{}, err
+	}
+	return a, nil
+}
+
+// head issues a HEAD request and returns the response status, cached for
+// sparqlCacheTTL so repeated validations of the same link are free.
+func (f *Fetcher) head(ctx context.Context, rawURL string) (int, error) {
+	f.mu.Lock()
+	entry, ok := f.headCache[rawURL]
+	if ok && time.Now().Before(entry.expires) {
+		f.mu.Unlock()
+		return entry.status, nil
+	}
+	delete(f.headCache, rawURL)
+	f.mu.Unlock()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, rawURL, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("User-Agent", userAgent)
+	if err := f.throttle(ctx, req.URL.Host); err != nil {
+		return 0, err
+	}
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	if cacheable(resp.Header) {
+		f.mu.Lock()
+		f.headCache[rawURL] = headCacheEntry{status: resp.StatusCode, expires: time.Now().Add(sparqlCacheTTL)}
+		f.mu.Unlock()
+	}
+	return resp.StatusCode, nil
+}
+
+// throttle delays the request when the host has a mandated pacing policy.
+// Only musicbrainz.org is paced today; everything else passes straight
+// through. Waiters queue fairly: each reserves its slot before sleeping, so
+// concurrent requests go out mbInterval apart instead of racing.
+func (f *Fetcher) throttle(ctx context.Context, host string) error {
+	if host != "musicbrainz.org" && !strings.HasSuffix(host, ".musicbrainz.org") {
+		return nil
+	}
+	f.mbMu.Lock()
+	now := time.Now()
+	wait := f.mbNext.Sub(now)
+	if wait < 0 {
+		wait = 0
+	}
+	f.mbNext = now.Add(wait + f.mbInterval)
+	f.mbMu.Unlock()
+	if wait == 0 {
+		return nil
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(wait):
+		return nil
+	}
+}
+
+// cacheable reports whether the upstream allows this response to be stored:
+// error pages and maintenance notices come with Cache-Control no-store or
+// private, and must not be served later as stale failures.
+func cacheable(h http.Header) bool {
+	cc := strings.ToLower(h.Get("Cache-Control"))
+	return !strings.Contains(cc, "no-store") && !strings.Contains(cc, "private")
+}
+
+// getJSON fetches the URL and decodes its JSON body into out.
+func (f *Fetcher) getJSON(ctx context.Context, rawURL string, headers map[string]string, out any) error {
+	_, err := f.getJSONFinal(ctx, rawURL, headers, out)
+	return err
+}
+
+// getJSONFinal is getJSON, additionally returning the final URL after any
+// redirects.
+func (f *Fetcher) getJSONFinal(ctx context.Context, rawURL string, headers map[string]string, out any) (string, error) {
+	body, _, finalURL, err := f.getWithHeaders(ctx, rawURL, headers)
+	if err != nil {
+		return finalURL, err
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		logFullBody("JSON response", body)
+		return finalURL, fmt.Errorf("malformed JSON response: %w (body: %s)", err, truncateForLog(body, 0))
+	}
+	return finalURL, nil
+}
+
+// sparql runs a SPARQL query against the given endpoint and decodes the
+// standard JSON results format. Responses are cached for sparqlCacheTTL,
+// except when the upstream forbids it (Cache-Control no-store/private), the
+// status is not 200, or the body is not valid JSON.
+func (f *Fetcher) sparql(ctx context.Context, endpoint, query string) (*sparqlResults, error) {
+	u := sparqlRequestURL(endpoint, query)
+	var results sparqlResults
+	if body, ok := f.cachedSPARQL(u); ok {
+		if err := json.Unmarshal(body, &results); err == nil {
+			return &results, nil
+		}
+	}
+	body, headers, _, err := f.getWithHeaders(ctx, u, map[string]string{"Accept": "application/sparql-results+json"})
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(body, &results); err != nil {
+		logFullBody("SPARQL response", body)
+		return nil, fmt.Errorf("malformed SPARQL response: %w (body: %s)", err, truncateForLog(body, 0))
+	}
+	if cacheable(headers) {
+		f.storeSPARQL(u, body)
+	}
+	return &results, nil
+}
+
+// sparqlRequestURL is the full GET URL sparql fetches for a query, shared
+// with the dry-run planner so previews match the real requests exactly.
+func sparqlRequestURL(endpoint, query string) string {
+	return endpoint + "?query=" + url.QueryEscape(query) + "&format=json"
+}
+
+func (f *Fetcher) cachedSPARQL(key string) ([]byte, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	entry, ok := f.sparqlCache[key]
+	if !ok || time.Now().After(entry.expires) {
+		delete(f.sparqlCache, key)
+		return nil, false
+	}
+	return entry.body, true
+}
+
+func (f *Fetcher) storeSPARQL(key string, body []byte) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.sparqlCache[key] = sparqlCacheEntry{body: body, expires: time.Now().Add(sparqlCacheTTL)}
+}
+
+// sparqlResults is the subset of the SPARQL 1.1 JSON results format we use.
+type sparqlResults struct {
+	Results struct {
+		Bindings []map[string]struct {
+			Value string `json:"value"`
+		} `json:"bindings"`
+	} `json:"results"`
+}
+
+// first returns the value of the named variable in the first binding, or ""
+func (r *sparqlResults) first(name string) string {
+	if len(r.Results.Bindings) == 0 {
+		return ""
+	}
+	return r.Results.Bindings[0][name].Value
+}
@@ -0,0 +1,302 @@
+package lookup
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("fetcher SPARQL caching", func() {
+	var ctx context.Context
+	var f *Fetcher
+	var hits int
+	var status int
+	var cacheControl string
+	var body string
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		hits = 0
+		status = http.StatusOK
+		cacheControl = ""
+		body = `{"results":{"bindings":[]}}`
+	})
+
+	newServer := func() *httptest.Server {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			hits++
+			if cacheControl != "" {
+				w.Header().Set("Cache-Control", cacheControl)
+			}
+			w.WriteHeader(status)
+			_, _ = w.Write([]byte(body))
+		}))
+		DeferCleanup(srv.Close)
+		f = NewFetcher()
+		f.client = srv.Client()
+		f.SetRetryPolicy(0, 0) // Retries are covered separately
+		return srv
+	}
+
+	It("serves repeated queries from the cache", func() {
+		srv := newServer()
+		for i := 0; i < 3; i++ {
+			_, err := f.sparql(ctx, srv.URL, "SELECT 1")
+			Expect(err).ToNot(HaveOccurred())
+		}
+		Expect(hits).To(Equal(1))
+	})
+
+	It("does not cache responses marked no-store", func() {
+		cacheControl = "no-store"
+		srv := newServer()
+		for i := 0; i < 2; i++ {
+			_, err := f.sparql(ctx, srv.URL, "SELECT 1")
+			Expect(err).ToNot(HaveOccurred())
+		}
+		Expect(hits).To(Equal(2))
+	})
+
+	It("does not cache responses marked private", func() {
+		cacheControl = "private, max-age=60"
+		srv := newServer()
+		for i := 0; i < 2; i++ {
+			_, err := f.sparql(ctx, srv.URL, "SELECT 1")
+			Expect(err).ToNot(HaveOccurred())
+		}
+		Expect(hits).To(Equal(2))
+	})
+
+	It("does not cache non-200 responses", func() {
+		status = http.StatusServiceUnavailable
+		srv := newServer()
+		_, err := f.sparql(ctx, srv.URL, "SELECT 1")
+		Expect(err).To(HaveOccurred())
+
+		status = http.StatusOK
+		_, err = f.sparql(ctx, srv.URL, "SELECT 1")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(hits).To(Equal(2))
+	})
+
+	It("does not cache bodies that fail JSON validation", func() {
+		body = `<html>maintenance</html>`
+		srv := newServer()
+		_, err := f.sparql(ctx, srv.URL, "SELECT 1")
+		Expect(err).To(HaveOccurred())
+
+		body = `{"results":{"bindings":[]}}`
+		_, err = f.sparql(ctx, srv.URL, "SELECT 1")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(hits).To(Equal(2))
+	})
+})
+
+var _ = Describe("truncateForLog", func() {
+	It("passes short bodies through unchanged", func() {
+		Expect(truncateForLog([]byte("short body"), 0)).To(Equal("short body"))
+	})
+
+	It("cuts long bodies at the limit, indicating the omitted bytes", func() {
+		body := []byte(strings.Repeat("x", logBodyLimit+100))
+		out := truncateForLog(body, 0)
+		Expect(out).To(HaveSuffix("... (100 bytes omitted)"))
+		Expect(len(out)).To(BeNumerically("<=", logBodyLimit+len("... (100 bytes omitted)")))
+	})
+
+	It("honors an explicit limit", func() {
+		Expect(truncateForLog([]byte("0123456789"), 4)).To(Equal("0123... (6 bytes omitted)"))
+	})
+})
+
+var _ = Describe("fetcher error bodies", func() {
+	It("embeds a bounded body snippet in unexpected-status errors", func() {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = w.Write([]byte("<html>" + strings.Repeat("maintenance ", 500) + "</html>"))
+		}))
+		DeferCleanup(srv.Close)
+		f := NewFetcher()
+		f.client = srv.Client()
+		f.SetRetryPolicy(0, 0)
+
+		_, err := f.get(context.Background(), srv.URL, nil)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("unexpected status 503"))
+		Expect(err.Error()).To(ContainSubstring("bytes omitted)"))
+		Expect(len(err.Error())).To(BeNumerically("<", logBodyLimit+200))
+	})
+})
+
+var _ = Describe("fetcher redirects", func() {
+	It("follows relative redirects and reports the final URL", func() {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/old" {
+				http.Redirect(w, r, "/new", http.StatusMovedPermanently)
+				return
+			}
+			_, _ = w.Write([]byte(`{"title":"canonical"}`))
+		}))
+		DeferCleanup(srv.Close)
+		f := NewFetcher()
+		f.client = srv.Client()
+
+		body, _, finalURL, err := f.getWithHeaders(context.Background(), srv.URL+"/old", nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(body)).To(Equal(`{"title":"canonical"}`))
+		Expect(finalURL).To(Equal(srv.URL + "/new"))
+
+		var out map[string]string
+		finalURL, err = f.getJSONFinal(context.Background(), srv.URL+"/old", nil, &out)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(finalURL).To(Equal(srv.URL + "/new"))
+		Expect(out).To(HaveKeyWithValue("title", "canonical"))
+	})
+})
+
+var _ = Describe("fetcher HEAD", func() {
+	It("issues a HEAD and returns the status without reading a body", func() {
+		var methods []string
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			methods = append(methods, r.Method)
+			w.Header().Set("Cache-Control", "no-store")
+			_, _ = w.Write([]byte("a body a HEAD must not download"))
+		}))
+		DeferCleanup(srv.Close)
+		f := NewFetcher()
+		f.client = srv.Client()
+
+		status, err := f.head(context.Background(), srv.URL+"/image.jpg")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(status).To(Equal(http.StatusOK))
+		Expect(methods).To(Equal([]string{http.MethodHead}))
+	})
+})
+
+var _ = Describe("loggedURL", func() {
+	BeforeEach(func() {
+		mode := RequestLogMode
+		DeferCleanup(func() { RequestLogMode = mode })
+	})
+
+	It("logs the full URL in the default mode", func() {
+		Expect(loggedURL("https://en.wikipedia.org/wiki/Artist?action=raw")).To(
+			Equal("https://en.wikipedia.org/wiki/Artist?action=raw"))
+	})
+
+	It("redacts paths and queries in hosts-only mode", func() {
+		RequestLogMode = "hosts-only"
+		Expect(loggedURL("https://en.wikipedia.org/wiki/Artist?action=raw")).To(
+			Equal("https://en.wikipedia.org"))
+		Expect(loggedURL("not a url")).To(Equal("[redacted]"))
+	})
+
+	It("redacts everything in off mode", func() {
+		RequestLogMode = "off"
+		Expect(loggedURL("https://en.wikipedia.org/wiki/Artist")).To(Equal("[redacted]"))
+	})
+})
+
+var _ = Describe("fetcher throttling", func() {
+	var ctx context.Context
+	var f *Fetcher
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		f = NewFetcher()
+		f.mbInterval = 30 * time.Millisecond
+	})
+
+	It("spaces consecutive MusicBrainz requests by the interval", func() {
+		start := time.Now()
+		for i := 0; i < 3; i++ {
+			Expect(f.throttle(ctx, "musicbrainz.org")).To(Succeed())
+		}
+		Expect(time.Since(start)).To(BeNumerically(">=", 2*f.mbInterval))
+	})
+
+	It("covers subdomains of musicbrainz.org", func() {
+		Expect(f.throttle(ctx, "beta.musicbrainz.org")).To(Succeed())
+		start := time.Now()
+		Expect(f.throttle(ctx, "beta.musicbrainz.org")).To(Succeed())
+		Expect(time.Since(start)).To(BeNumerically(">=", f.mbInterval))
+	})
+
+	It("does not pace other hosts", func() {
+		start := time.Now()
+		for i := 0; i < 3; i++ {
+			Expect(f.throttle(ctx, "query.wikidata.org")).To(Succeed())
+		}
+		Expect(time.Since(start)).To(BeNumerically("<", f.mbInterval))
+	})
+
+	It("gives up waiting when the caller's context expires", func() {
+		f.mbInterval = time.Minute
+		Expect(f.throttle(ctx, "musicbrainz.org")).To(Succeed())
+
+		shortCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+		Expect(f.throttle(shortCtx, "musicbrainz.org")).To(MatchError(context.DeadlineExceeded))
+	})
+})
+
+var _ = Describe("fetcher retries", func() {
+	var hits int
+
+	BeforeEach(func() {
+		hits = 0
+	})
+
+	newFlaky := func(failures int, failStatus int) (*httptest.Server, *Fetcher) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			hits++
+			if hits <= failures {
+				w.WriteHeader(failStatus)
+				return
+			}
+			_, _ = w.Write([]byte("ok"))
+		}))
+		DeferCleanup(srv.Close)
+		f := NewFetcher()
+		f.client = srv.Client()
+		f.SetRetryPolicy(3, time.Millisecond)
+		return srv, f
+	}
+
+	It("retries 5xx responses and returns the eventual success", func() {
+		srv, f := newFlaky(2, http.StatusServiceUnavailable)
+		body, err := f.get(context.Background(), srv.URL, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(body)).To(Equal("ok"))
+		Expect(hits).To(Equal(3))
+	})
+
+	It("does not retry client errors", func() {
+		srv, f := newFlaky(5, http.StatusTooManyRequests)
+		_, err := f.get(context.Background(), srv.URL, nil)
+		Expect(err).To(MatchError(ContainSubstring("unexpected status 429")))
+		Expect(hits).To(Equal(1))
+	})
+
+	It("gives up after the configured retries, reporting the last attempt", func() {
+		srv, f := newFlaky(10, http.StatusBadGateway)
+		_, err := f.get(context.Background(), srv.URL, nil)
+		Expect(err).To(MatchError(ContainSubstring("unexpected status 502")))
+		Expect(hits).To(Equal(4)) // The first attempt plus three retries
+	})
+
+	It("stops retrying when the caller's context expires", func() {
+		srv, f := newFlaky(10, http.StatusServiceUnavailable)
+		f.SetRetryPolicy(10, time.Minute)
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		_, err := f.get(ctx, srv.URL, nil)
+		Expect(err).To(MatchError(context.DeadlineExceeded))
+		Expect(hits).To(Equal(1))
+	})
+})
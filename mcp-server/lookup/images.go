@@ -0,0 +1,138 @@
+package lookup
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// maxImageFiles bounds how many distinct Commons files are pulled per artist;
+// most entities carry one P18 value, a handful carry a few alternates.
+const maxImageFiles = 5
+
+// imageWidths are the scaled variants rendered for each Commons file, largest
+// first, roughly matching the sizes music clients request for artist art.
+var imageWidths = []int{600, 300, 174}
+
+// ArtistImage is one artist image candidate: a Commons thumbnail URL and the
+// pixel width it renders at.
+type ArtistImage struct {
+	URL  string `json:"url"`
+	Size int    `json:"size"`
+}
+
+// imageSource resolves the artist's images (P18) on Wikidata and expands each
+// Commons file into scaled thumbnail variants. Entities without a P18 value
+// fall back to the lead image of the English Wikipedia article (the MediaWiki
+// pageimages prop), which is almost always hosted on Commons too.
+type imageSource struct {
+	fetcher *Fetcher
+}
+
+func newImageSource(f *Fetcher) *imageSource {
+	return &imageSource{fetcher: f}
+}
+
+func (s *imageSource) getImages(ctx context.Context, q ArtistQuery) ([]ArtistImage, error) {
+	if q.MBID == "" {
+		return nil, ErrNotFound
+	}
+	query := fmt.Sprintf(`SELECT ?image ?article WHERE {
+  ?artist wdt:P434 %q .
+  OPTIONAL { ?artist wdt:P18 ?image . }
+  OPTIONAL { ?article schema:about ?artist ;
+                      schema:isPartOf <https://en.wikipedia.org/> . }
+} LIMIT %d`, q.MBID, maxImageFiles)
+	results, err := s.fetcher.sparql(ctx, wikidataSPARQLEndpoint, query)
+	if err != nil {
+		return nil, err
+	}
+	if images := imagesFromResults(results); len(images) > 0 {
+		return images, nil
+	}
+	return s.pageImages(ctx, results.first("article"))
+}
+
+// pageImages resolves the lead image of the artist's Wikipedia article, used
+// when the Wikidata entity carries no P18 value.
+func (s *imageSource) pageImages(ctx context.Context, article string) ([]ArtistImage, error) {
+	title := titleFromArticleURL(article)
+	if title == "" {
+		return nil, ErrNotFound
+	}
+	u := fmt.Sprintf("%s?action=query&prop=pageimages&piprop=name&titles=%s&format=json",
+		wikipediaActionAPI, url.QueryEscape(title))
+	var resp pageImageResponse
+	if err := s.fetcher.getJSON(ctx, u, nil, &resp); err != nil {
+		return nil, err
+	}
+	name := pageImageFromResponse(&resp)
+	if name == "" {
+		return nil, ErrNotFound
+	}
+	return imageVariants(commonsFileURL(name)), nil
+}
+
+// pageImageResponse is the subset of the MediaWiki prop=pageimages response we
+// use.
+type pageImageResponse struct {
+	Query struct {
+		Pages map[string]struct {
+			PageImage string `json:"pageimage"`
+		} `json:"pages"`
+	} `json:"query"`
+}
+
+// pageImageFromResponse returns the first lead-image file name in the
+// response; pages without one are skipped.
+func pageImageFromResponse(resp *pageImageResponse) string {
+	for _, page := range resp.Query.Pages {
+		if page.PageImage != "" {
+			return page.PageImage
+		}
+	}
+	return ""
+}
+
+// imagesFromResults expands every distinct image binding into its scaled
+// variants, largest first, preserving response order across files.
+func imagesFromResults(results *sparqlResults) []ArtistImage {
+	var images []ArtistImage
+	seen := map[string]struct{}{}
+	for _, binding := range results.Results.Bindings {
+		value := binding["image"].Value
+		if value == "" {
+			continue
+		}
+		base := commonsFileURL(value)
+		if _, ok := seen[base]; ok {
+			continue
+		}
+		seen[base] = struct{}{}
+		images = append(images, imageVariants(base)...)
+	}
+	return images
+}
+
+// imageVariants renders a Commons file at every configured width, largest
+// first.
+func imageVariants(base string) []ArtistImage {
+	variants := make([]ArtistImage, 0, len(imageWidths))
+	for _, width := range imageWidths {
+		variants = append(variants, ArtistImage{URL: fmt.Sprintf("%s?width=%d", base, width), Size: width})
+	}
+	return variants
+}
+
+// commonsFileURL normalizes a P18 value into a Commons Special:FilePath URL,
+// which scales on demand via its width parameter. SPARQL answers with full
+// URLs already, but entity dumps and some mirrors return the bare file name
+// (with or without the "File:" prefix), which needs the URL constructed.
+func commonsFileURL(value string) string {
+	if strings.HasPrefix(value, "http://") || strings.HasPrefix(value, "https://") {
+		return value
+	}
+	name := strings.TrimPrefix(value, "File:")
+	return "https://commons.wikimedia.org/wiki/Special:FilePath/" + url.PathEscape(name)
+}
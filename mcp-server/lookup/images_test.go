@@ -0,0 +1,79 @@
+package lookup
+
+import (
+	"context"
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("imagesFromResults", func() {
+	parse := func(body string) *sparqlResults {
+		var results sparqlResults
+		Expect(json.Unmarshal([]byte(body), &results)).To(Succeed())
+		return &results
+	}
+
+	It("expands each file into scaled variants, largest first", func() {
+		results := parse(`{"results":{"bindings":[
+			{"image":{"value":"http://commons.wikimedia.org/wiki/Special:FilePath/Artist.jpg"}}
+		]}}`)
+		Expect(imagesFromResults(results)).To(Equal([]ArtistImage{
+			{URL: "http://commons.wikimedia.org/wiki/Special:FilePath/Artist.jpg?width=600", Size: 600},
+			{URL: "http://commons.wikimedia.org/wiki/Special:FilePath/Artist.jpg?width=300", Size: 300},
+			{URL: "http://commons.wikimedia.org/wiki/Special:FilePath/Artist.jpg?width=174", Size: 174},
+		}))
+	})
+
+	It("de-duplicates files and skips empty bindings", func() {
+		results := parse(`{"results":{"bindings":[
+			{"image":{"value":"http://commons.wikimedia.org/wiki/Special:FilePath/One.jpg"}},
+			{"image":{"value":""}},
+			{"image":{"value":"http://commons.wikimedia.org/wiki/Special:FilePath/One.jpg"}}
+		]}}`)
+		Expect(imagesFromResults(results)).To(HaveLen(len(imageWidths)))
+	})
+})
+
+var _ = Describe("commonsFileURL", func() {
+	It("passes full URLs through untouched", func() {
+		url := "https://commons.wikimedia.org/wiki/Special:FilePath/Artist.jpg"
+		Expect(commonsFileURL(url)).To(Equal(url))
+	})
+
+	It("constructs the FilePath URL from a bare file name", func() {
+		Expect(commonsFileURL("File:Some Artist (2019).jpg")).To(
+			Equal("https://commons.wikimedia.org/wiki/Special:FilePath/Some%20Artist%20%282019%29.jpg"))
+	})
+
+	It("accepts file names without the File: prefix", func() {
+		Expect(commonsFileURL("Artist.jpg")).To(
+			Equal("https://commons.wikimedia.org/wiki/Special:FilePath/Artist.jpg"))
+	})
+})
+
+var _ = Describe("pageImageFromResponse", func() {
+	It("returns the first lead-image file name, skipping pages without one", func() {
+		var resp pageImageResponse
+		Expect(json.Unmarshal([]byte(`{"query":{"pages":{
+			"-1":{},
+			"42":{"pageimage":"Some_Artist_2019.jpg"}
+		}}}`), &resp)).To(Succeed())
+		Expect(pageImageFromResponse(&resp)).To(Equal("Some_Artist_2019.jpg"))
+	})
+
+	It("returns empty when no page carries a lead image", func() {
+		var resp pageImageResponse
+		Expect(json.Unmarshal([]byte(`{"query":{"pages":{"42":{}}}}`), &resp)).To(Succeed())
+		Expect(pageImageFromResponse(&resp)).To(BeEmpty())
+	})
+})
+
+var _ = Describe("imageSource", func() {
+	It("requires an MBID", func() {
+		s := newImageSource(nil)
+		_, err := s.getImages(context.Background(), ArtistQuery{Name: "No MBID"})
+		Expect(err).To(MatchError(ErrNotFound))
+	})
+})
@@ -0,0 +1,80 @@
+package lookup
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+const wikipediaActionAPI = "https://en.wikipedia.org/w/api.php"
+
+// WikiLink is an outbound article link found in a biography, usable for
+// building a relationship graph ("related artists") from prose.
+type WikiLink struct {
+	Title string `json:"title"`
+	URL   string `json:"url"`
+}
+
+// linksResponse is the subset of the MediaWiki prop=links response we use.
+type linksResponse struct {
+	Query struct {
+		Pages map[string]struct {
+			Links []struct {
+				Title string `json:"title"`
+			} `json:"links"`
+		} `json:"pages"`
+	} `json:"query"`
+}
+
+// articleLinks lists the article-namespace links of a Wikipedia page, filtered
+// to likely music-related targets.
+func (s *wikipediaSource) articleLinks(ctx context.Context, title string) ([]WikiLink, error) {
+	if err := validateTitle(title); err != nil {
+		return nil, err
+	}
+	u := fmt.Sprintf("%s?action=query&prop=links&titles=%s&plnamespace=0&pllimit=500&format=json",
+		wikipediaActionAPI, url.QueryEscape(title))
+	var resp linksResponse
+	if err := s.fetcher.getJSON(ctx, u, nil, &resp); err != nil {
+		return nil, err
+	}
+	return musicLinksFromResponse(&resp), nil
+}
+
+// musicLinksFromResponse extracts the music-related links, in response order.
+func musicLinksFromResponse(resp *linksResponse) []WikiLink {
+	var links []WikiLink
+	for _, page := range resp.Query.Pages {
+		for _, l := range page.Links {
+			if !musicRelated(l.Title) {
+				continue
+			}
+			links = append(links, WikiLink{
+				Title: l.Title,
+				URL:   "https://en.wikipedia.org/wiki/" + url.PathEscape(strings.ReplaceAll(l.Title, " ", "_")),
+			})
+		}
+	}
+	return links
+}
+
+// musicMarkers are title fragments that signal a music-related article. The
+// filter is deliberately conservative: disambiguated titles and obviously
+// musical words, rather than trying to classify every page.
+var musicMarkers = []string{
+	"(band)", "(musician)", "(singer)", "(rapper)", "(album)", "(song)", "(ep)",
+	"discography", "records", "album", "guitarist", "drummer", "bassist",
+	"singer", "songwriter", "composer", "producer",
+}
+
+// musicRelated reports whether an article title looks music-related.
+func musicRelated(title string) bool {
+	lower := strings.ToLower(title)
+	for _, marker := range musicMarkers {
+		if strings.Contains(lower, marker) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,41 @@
+package lookup
+
+import (
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("bio links", func() {
+	It("parses links from a canned response and filters to music pages", func() {
+		var resp linksResponse
+		Expect(json.Unmarshal([]byte(`{"query":{"pages":{"42":{"links":[
+			{"title":"Paul McCartney"},
+			{"title":"Wings (band)"},
+			{"title":"Abbey Road (album)"},
+			{"title":"Liverpool"},
+			{"title":"The Beatles discography"},
+			{"title":"Apple Records"},
+			{"title":"British Invasion"}
+		]}}}}`), &resp)).To(Succeed())
+
+		links := musicLinksFromResponse(&resp)
+		titles := make([]string, len(links))
+		for i, l := range links {
+			titles[i] = l.Title
+		}
+		Expect(titles).To(Equal([]string{
+			"Wings (band)", "Abbey Road (album)", "The Beatles discography", "Apple Records",
+		}))
+		Expect(links[0].URL).To(Equal("https://en.wikipedia.org/wiki/Wings_%28band%29"))
+	})
+
+	It("classifies titles conservatively", func() {
+		Expect(musicRelated("John Lennon (musician)")).To(BeTrue())
+		Expect(musicRelated("Imagine (song)")).To(BeTrue())
+		Expect(musicRelated("Lead guitarist")).To(BeTrue())
+		Expect(musicRelated("Liverpool")).To(BeFalse())
+		Expect(musicRelated("World War II")).To(BeFalse())
+	})
+})
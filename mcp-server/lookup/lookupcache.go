@@ -0,0 +1,125 @@
+package lookup
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// Lookup cache defaults: hits live long (upstream data changes slowly),
+// misses shorter, so a newly tagged artist is not invisible for hours.
+const (
+	defaultLookupCacheEntries = 512
+	defaultLookupCacheTTL     = time.Hour
+	defaultLookupCacheMissTTL = 10 * time.Minute
+)
+
+// lookupCache memoizes pipeline results in memory, keyed by metadata type and
+// artist identity. Every biography call otherwise re-queries Wikidata and
+// DBpedia even for the same MBID, which is wasteful and gets us rate-limited;
+// a small TTL+LRU cache absorbs the repeats. Not-found answers are cached
+// too, under their own TTL, so unknown artists do not hammer upstreams.
+type lookupCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	hitTTL     time.Duration
+	missTTL    time.Duration
+	entries    map[string]*list.Element
+	order      *list.List // Front is most recently used
+	now        func() time.Time
+}
+
+// lookupCacheEntry holds one memoized answer; a nil result records a
+// not-found.
+type lookupCacheEntry struct {
+	key     string
+	result  *BioResult
+	expires time.Time
+}
+
+func newLookupCache() *lookupCache {
+	return &lookupCache{
+		maxEntries: defaultLookupCacheEntries,
+		hitTTL:     defaultLookupCacheTTL,
+		missTTL:    defaultLookupCacheMissTTL,
+		entries:    map[string]*list.Element{},
+		order:      list.New(),
+		now:        time.Now,
+	}
+}
+
+// configure overrides the cache bounds; zero values keep the defaults.
+func (c *lookupCache) configure(maxEntries int, hitTTL, missTTL time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if maxEntries > 0 {
+		c.maxEntries = maxEntries
+	}
+	if hitTTL > 0 {
+		c.hitTTL = hitTTL
+	}
+	if missTTL > 0 {
+		c.missTTL = missTTL
+	}
+}
+
+// key identifies an answer by metadata type and artist identity. Both MBID
+// and name are included: the same name with a corrected MBID is a different
+// artist, and must not reuse the old answer.
+func (c *lookupCache) key(typ string, q ArtistQuery) string {
+	return typ + "\x00" + q.MBID + "\x00" + q.Name
+}
+
+// get returns the memoized answer for the query. ok reports a cache hit; a
+// hit with a nil result is a cached not-found. Hits return a copy, so callers
+// can annotate their result without mutating the shared entry.
+func (c *lookupCache) get(typ string, q ArtistQuery) (*BioResult, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.entries[c.key(typ, q)]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*lookupCacheEntry)
+	if c.now().After(entry.expires) {
+		c.order.Remove(elem)
+		delete(c.entries, entry.key)
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+	if entry.result == nil {
+		return nil, true
+	}
+	result := *entry.result
+	return &result, true
+}
+
+// put memoizes a successful answer.
+func (c *lookupCache) put(typ string, q ArtistQuery, result *BioResult) {
+	copied := *result
+	c.store(c.key(typ, q), &copied, c.hitTTL)
+}
+
+// putNotFound memoizes a not-found answer, under the (shorter) miss TTL.
+func (c *lookupCache) putNotFound(typ string, q ArtistQuery) {
+	c.store(c.key(typ, q), nil, c.missTTL)
+}
+
+func (c *lookupCache) store(key string, result *BioResult, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	expires := c.now().Add(ttl)
+	if elem, ok := c.entries[key]; ok {
+		entry := elem.Value.(*lookupCacheEntry)
+		entry.result = result
+		entry.expires = expires
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&lookupCacheEntry{key: key, result: result, expires: expires})
+	for len(c.entries) > c.maxEntries {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*lookupCacheEntry).key)
+	}
+}
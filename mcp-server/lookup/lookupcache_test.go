@@ -0,0 +1,142 @@
+package lookup
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("lookupCache", func() {
+	var c *lookupCache
+	var now time.Time
+	q := ArtistQuery{Name: "The Artist", MBID: "mbid-1"}
+
+	BeforeEach(func() {
+		c = newLookupCache()
+		now = time.Now()
+		c.now = func() time.Time { return now }
+	})
+
+	It("misses for unknown queries", func() {
+		_, ok := c.get(typeBio, q)
+		Expect(ok).To(BeFalse())
+	})
+
+	It("round-trips successful answers", func() {
+		c.put(typeBio, q, &BioResult{Text: "a bio"})
+		result, ok := c.get(typeBio, q)
+		Expect(ok).To(BeTrue())
+		Expect(result.Text).To(Equal("a bio"))
+	})
+
+	It("keeps metadata types and artist identities apart", func() {
+		c.put(typeBio, q, &BioResult{Text: "a bio"})
+		_, ok := c.get(typeLinks, q)
+		Expect(ok).To(BeFalse())
+		_, ok = c.get(typeBio, ArtistQuery{Name: "The Artist", MBID: "mbid-2"})
+		Expect(ok).To(BeFalse())
+	})
+
+	It("records not-found as a hit with no result", func() {
+		c.putNotFound(typeBio, q)
+		result, ok := c.get(typeBio, q)
+		Expect(ok).To(BeTrue())
+		Expect(result).To(BeNil())
+	})
+
+	It("expires hits and misses under their own TTLs", func() {
+		c.configure(0, time.Hour, time.Minute)
+		c.put(typeBio, q, &BioResult{Text: "a bio"})
+		c.putNotFound(typeLinks, q)
+
+		now = now.Add(2 * time.Minute)
+		_, ok := c.get(typeBio, q)
+		Expect(ok).To(BeTrue(), "hit should survive the miss TTL")
+		_, ok = c.get(typeLinks, q)
+		Expect(ok).To(BeFalse(), "miss should expire after its TTL")
+
+		now = now.Add(time.Hour)
+		_, ok = c.get(typeBio, q)
+		Expect(ok).To(BeFalse())
+	})
+
+	It("evicts the least recently used entry beyond maxEntries", func() {
+		c.configure(2, 0, 0)
+		a := ArtistQuery{Name: "A"}
+		b := ArtistQuery{Name: "B"}
+		c.put(typeBio, a, &BioResult{Text: "a"})
+		c.put(typeBio, b, &BioResult{Text: "b"})
+		// Touch "a" so "b" becomes the eviction candidate.
+		_, _ = c.get(typeBio, a)
+		c.put(typeBio, ArtistQuery{Name: "C"}, &BioResult{Text: "c"})
+
+		_, ok := c.get(typeBio, b)
+		Expect(ok).To(BeFalse())
+		_, ok = c.get(typeBio, a)
+		Expect(ok).To(BeTrue())
+	})
+
+	It("hands out copies, so callers cannot mutate the cached entry", func() {
+		c.put(typeBio, q, &BioResult{Text: "a bio"})
+		first, _ := c.get(typeBio, q)
+		first.Text = "mutated"
+		second, _ := c.get(typeBio, q)
+		Expect(second.Text).To(Equal("a bio"))
+	})
+})
+
+var _ = Describe("pipeline lookup caching", func() {
+	q := ArtistQuery{Name: "The Artist", MBID: "mbid-1"}
+
+	It("serves a repeated biography lookup without consulting the sources", func() {
+		src := &fakeSource{name: "first", result: &BioResult{Text: "a bio"}}
+		p := newPipeline([]BioSource{src})
+
+		for i := 0; i < 3; i++ {
+			result, err := p.getBiography(context.Background(), q)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.Text).To(Equal("a bio"))
+		}
+		Expect(src.calls).To(Equal(1))
+	})
+
+	It("caches not-found, so unknown artists do not hammer the sources", func() {
+		src := &fakeSource{name: "first", err: ErrNotFound}
+		p := newPipeline([]BioSource{src})
+
+		for i := 0; i < 3; i++ {
+			_, err := p.getBiography(context.Background(), q)
+			Expect(err).To(MatchError(ErrNotFound))
+		}
+		Expect(src.calls).To(Equal(1))
+	})
+
+	It("serves repeated URL lookups from the cache too", func() {
+		src := &fakeSource{name: "first", result: &BioResult{URL: "https://example.com/artist"}}
+		p := newPipeline([]BioSource{src})
+
+		for i := 0; i < 3; i++ {
+			result, err := p.getURL(context.Background(), q)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.URL).To(Equal("https://example.com/artist"))
+		}
+		Expect(src.calls).To(Equal(1))
+	})
+
+	It("does not cache canceled lookups", func() {
+		src := &fakeSource{name: "first", err: context.Canceled}
+		p := newPipeline([]BioSource{src})
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := p.getBiography(ctx, q)
+		Expect(err).To(MatchError(context.Canceled))
+
+		// The aborted lookup left no entry behind; a retry reaches the source.
+		src.err, src.result = nil, &BioResult{Text: "a bio"}
+		result, err := p.getBiography(context.Background(), q)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.Text).To(Equal("a bio"))
+	})
+})
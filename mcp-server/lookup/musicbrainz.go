@@ -0,0 +1,70 @@
+package lookup
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+)
+
+// defaultMBIDMinScore is the minimum MusicBrainz search score (0-100) a match
+// must reach before its MBID is trusted. Search scores are fuzzy; below this,
+// a homonym is likelier than the right artist.
+const defaultMBIDMinScore = 90
+
+// artistSearchResponse is the subset of the MusicBrainz artist search response
+// we use. Results come ordered by descending score.
+type artistSearchResponse struct {
+	Artists []struct {
+		ID    string `json:"id"`
+		Name  string `json:"name"`
+		Score int    `json:"score"`
+	} `json:"artists"`
+}
+
+// mbidResolver resolves an artist name to an MBID through the MusicBrainz
+// search API, for library artists whose tags carry none. Without an MBID the
+// Wikidata-keyed sources are skipped entirely, leaving only fragile
+// exact-label matching; a confident search hit restores the reliable path.
+type mbidResolver struct {
+	fetcher  *Fetcher
+	minScore int
+}
+
+func newMBIDResolver(f *Fetcher) *mbidResolver {
+	return &mbidResolver{fetcher: f, minScore: defaultMBIDMinScore}
+}
+
+// resolve returns the MBID of the best search match for the artist name, or
+// ErrNotFound when no match reaches the minimum score.
+func (r *mbidResolver) resolve(ctx context.Context, name string) (string, error) {
+	if name == "" {
+		return "", ErrNotFound
+	}
+	var resp artistSearchResponse
+	if err := r.fetcher.getJSON(ctx, r.searchURL(name), nil, &resp); err != nil {
+		return "", err
+	}
+	mbid := bestSearchMatch(&resp, r.minScore)
+	if mbid == "" {
+		return "", ErrNotFound
+	}
+	return mbid, nil
+}
+
+// searchURL is the artist search request resolve issues for a name, shared
+// with the dry-run planner.
+func (r *mbidResolver) searchURL(name string) string {
+	return fmt.Sprintf("%s/artist?query=%s&limit=5&fmt=json",
+		musicbrainzAPIEndpoint, url.QueryEscape(fmt.Sprintf("artist:%q", name)))
+}
+
+// bestSearchMatch returns the MBID of the first (highest-scoring) artist at or
+// above minScore, or "" when no match qualifies.
+func bestSearchMatch(resp *artistSearchResponse, minScore int) string {
+	for _, a := range resp.Artists {
+		if a.ID != "" && a.Score >= minScore {
+			return a.ID
+		}
+	}
+	return ""
+}
@@ -0,0 +1,46 @@
+package lookup
+
+import (
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("bestSearchMatch", func() {
+	parse := func(body string) *artistSearchResponse {
+		var resp artistSearchResponse
+		Expect(json.Unmarshal([]byte(body), &resp)).To(Succeed())
+		return &resp
+	}
+
+	It("returns the MBID of an exact match", func() {
+		resp := parse(`{"artists":[
+			{"id":"b10bbbfc-cf9e-42e0-be17-e2c3e1d2600d","name":"The Beatles","score":100},
+			{"id":"d2ee1dd5-b95b-4f8b-91b9-5b6f8b4b0aad","name":"The Beatles Revival Band","score":62}]}`)
+		Expect(bestSearchMatch(resp, defaultMBIDMinScore)).To(Equal("b10bbbfc-cf9e-42e0-be17-e2c3e1d2600d"))
+	})
+
+	It("rejects matches below the minimum score", func() {
+		resp := parse(`{"artists":[
+			{"id":"d2ee1dd5-b95b-4f8b-91b9-5b6f8b4b0aad","name":"Beatallica","score":71}]}`)
+		Expect(bestSearchMatch(resp, defaultMBIDMinScore)).To(BeEmpty())
+	})
+
+	It("honors a lowered minimum score", func() {
+		resp := parse(`{"artists":[
+			{"id":"d2ee1dd5-b95b-4f8b-91b9-5b6f8b4b0aad","name":"Beatallica","score":71}]}`)
+		Expect(bestSearchMatch(resp, 70)).To(Equal("d2ee1dd5-b95b-4f8b-91b9-5b6f8b4b0aad"))
+	})
+
+	It("returns nothing for zero results", func() {
+		Expect(bestSearchMatch(parse(`{"artists":[]}`), defaultMBIDMinScore)).To(BeEmpty())
+	})
+
+	It("skips qualifying entries without an MBID", func() {
+		resp := parse(`{"artists":[
+			{"name":"Broken Entry","score":100},
+			{"id":"f27ec8db-af05-4f36-916e-3d57f91ecf5e","name":"Michael Jackson","score":98}]}`)
+		Expect(bestSearchMatch(resp, defaultMBIDMinScore)).To(Equal("f27ec8db-af05-4f36-916e-3d57f91ecf5e"))
+	})
+})
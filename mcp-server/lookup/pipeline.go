@@ -0,0 +1,304 @@
+package lookup
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrNotFound is returned by a source when the artist is unknown to it. The
+// pipeline treats it as "try the next source"; any other error is considered
+// transient and is also skipped, but logged at a higher level.
+var ErrNotFound = errors.New("not found")
+
+// ArtistQuery identifies the artist a tool call is about. Validate asks the
+// URL tool to check candidate links with a HEAD request before returning them;
+// IncludeLinks asks the biography tool for the bio's outbound article links.
+type ArtistQuery struct {
+	ID           string `json:"id"`
+	Name         string `json:"name"`
+	MBID         string `json:"mbid"`
+	Validate     bool   `json:"validate,omitempty"`
+	IncludeLinks bool   `json:"includeLinks,omitempty"`
+	// DryRun asks the tools to preview the requests the lookup would issue
+	// instead of running it; handled by the server, not the lookups.
+	DryRun bool `json:"dryRun,omitempty"`
+	// SearchURLTemplate overrides the search-URL fallback template for the URL
+	// tool ({query} is replaced with the escaped artist name).
+	SearchURLTemplate string `json:"searchUrlTemplate,omitempty"`
+}
+
+// BioResult is what a source knows about an artist: a biography, a canonical
+// URL, or both, plus the attribution for where it came from.
+type BioResult struct {
+	Text        string
+	URL         string
+	Source      string
+	License     string
+	RetrievedAt time.Time
+	// Validated is set when the URL was confirmed reachable with a HEAD
+	// request before being returned.
+	Validated bool
+	// ResolvedMBID is filled when the query carried no MBID and a MusicBrainz
+	// search resolved one, so the caller can persist it.
+	ResolvedMBID string
+	// Links are the bio's music-related outbound article links, filled only
+	// when the query asks for them.
+	Links []WikiLink
+}
+
+// BioSource is a single upstream provider of artist biographies and URLs.
+// Implementations should return ErrNotFound when they have nothing for the
+// artist, and leave fields they cannot fill empty.
+type BioSource interface {
+	Name() string
+	// License is the license the source publishes its content under.
+	License() string
+	Lookup(ctx context.Context, q ArtistQuery) (*BioResult, error)
+}
+
+// Metadata types with their own source ordering. Different sources are best
+// for different fields, so each tool consults the chain in its own order.
+const (
+	typeBio   = "bio"
+	typeImage = "image"
+	typeGenre = "genre"
+	typeLinks = "links"
+)
+
+// defaultSourceOrders is the per-type preference when config does not override
+// it. Types without an entry (or whose sources are not registered) fall back
+// to the registry order; image and genre get theirs once such sources exist.
+var defaultSourceOrders = map[string][]string{
+	typeBio:   {"wikipedia", "dbpedia", "wikidata-description"},
+	typeLinks: {"wikipedia", "dbpedia"},
+}
+
+// pipeline runs sources in order until one produces the requested field. The
+// same source registry drives both the biography and URL tools, each with its
+// own type-specific ordering.
+type pipeline struct {
+	sources          []BioSource
+	orders           map[string][]BioSource
+	perSourceTimeout time.Duration
+	// head, when set, is used to validate candidate URLs on request.
+	head func(ctx context.Context, url string) (int, error)
+	// cache memoizes biography and URL answers (including not-found), so
+	// repeated lookups for the same artist skip the upstream queries.
+	cache *lookupCache
+
+	mu sync.Mutex
+	// skipped holds sources temporarily out of rotation (e.g. their endpoint
+	// failed the startup probe), each with the time it rejoins.
+	skipped map[string]time.Time
+}
+
+const defaultPerSourceTimeout = 10 * time.Second
+
+func newPipeline(sources []BioSource) *pipeline {
+	p := &pipeline{sources: sources, perSourceTimeout: defaultPerSourceTimeout, cache: newLookupCache()}
+	p.applySourceOrders(defaultSourceOrders)
+	return p
+}
+
+// applySourceOrders resolves per-type source-name orders against the registry.
+// Unknown names are logged and skipped; a type that resolves to nothing keeps
+// its previous (or registry) order.
+func (p *pipeline) applySourceOrders(orders map[string][]string) {
+	if p.orders == nil {
+		p.orders = map[string][]BioSource{}
+	}
+	byName := map[string]BioSource{}
+	for _, src := range p.sources {
+		byName[src.Name()] = src
+	}
+	for typ, names := range orders {
+		var resolved []BioSource
+		for _, name := range names {
+			src, ok := byName[name]
+			if !ok {
+				log.Printf("unknown source %q in %s order, skipping", name, typ)
+				continue
+			}
+			resolved = append(resolved, src)
+		}
+		if len(resolved) > 0 {
+			p.orders[typ] = resolved
+		}
+	}
+}
+
+// sourcesFor returns the source chain for a metadata type, falling back to the
+// full registry when the type has no specific order.
+func (p *pipeline) sourcesFor(typ string) []BioSource {
+	if sources := p.orders[typ]; len(sources) > 0 {
+		return sources
+	}
+	return p.sources
+}
+
+// getBiography returns the first non-empty biography produced by the sources.
+// Answers are memoized; link-including queries bypass the cache, as a cached
+// plain answer would silently drop the links.
+func (p *pipeline) getBiography(ctx context.Context, q ArtistQuery) (*BioResult, error) {
+	accept := func(r *BioResult) bool { return r.Text != "" }
+	if q.IncludeLinks {
+		return p.lookupIn(ctx, p.sourcesFor(typeBio), q, accept)
+	}
+	return p.cachedLookup(typeBio, q, func() (*BioResult, error) {
+		return p.lookupIn(ctx, p.sourcesFor(typeBio), q, accept)
+	})
+}
+
+// cachedLookup serves the query from the lookup cache, falling back to find
+// and memoizing its answer. Only definitive answers are cached: transient
+// errors (timeouts, cancellations) are returned as-is and retried next time.
+func (p *pipeline) cachedLookup(typ string, q ArtistQuery, find func() (*BioResult, error)) (*BioResult, error) {
+	if result, ok := p.cache.get(typ, q); ok {
+		if result == nil {
+			return nil, ErrNotFound
+		}
+		return result, nil
+	}
+	result, err := find()
+	switch {
+	case errors.Is(err, ErrNotFound):
+		p.cache.putNotFound(typ, q)
+	case err == nil:
+		p.cache.put(typ, q, result)
+	}
+	return result, err
+}
+
+// getURL returns the first canonical artist URL produced by the sources. With
+// q.Validate set, candidates that answer a HEAD request with 404 or 410 are
+// skipped, falling back to the next tier, and surviving results are marked
+// Validated. Validating queries bypass the cache - a memoized URL may have
+// died since - while the search-URL fallback runs on every call, so it always
+// reflects the current template.
+func (p *pipeline) getURL(ctx context.Context, q ArtistQuery) (*BioResult, error) {
+	var result *BioResult
+	var err error
+	if q.Validate && p.head != nil {
+		accept := func(r *BioResult) bool {
+			if r.URL == "" {
+				return false
+			}
+			if !p.urlAlive(ctx, r.URL) {
+				log.Printf("candidate URL %s is gone, trying next source", loggedURL(r.URL))
+				return false
+			}
+			r.Validated = true
+			return true
+		}
+		result, err = p.lookupIn(ctx, p.sourcesFor(typeLinks), q, accept)
+	} else {
+		result, err = p.cachedLookup(typeLinks, q, func() (*BioResult, error) {
+			return p.lookupIn(ctx, p.sourcesFor(typeLinks), q, func(r *BioResult) bool { return r.URL != "" })
+		})
+	}
+	if errors.Is(err, ErrNotFound) {
+		if u := searchFallbackURL(q); u != "" {
+			return &BioResult{URL: u, Source: "search", RetrievedAt: time.Now()}, nil
+		}
+	}
+	return result, err
+}
+
+// urlAlive checks the URL with a HEAD request. Only a definite 404/410 fails
+// the candidate; transport errors leave it standing, as nothing better is
+// known.
+func (p *pipeline) urlAlive(ctx context.Context, url string) bool {
+	return headAlive(ctx, p.head, url)
+}
+
+// headAlive is the shared HEAD liveness check, also used by the thumbnail
+// chain for image-URL validation.
+func headAlive(ctx context.Context, head func(ctx context.Context, url string) (int, error), url string) bool {
+	status, err := head(ctx, url)
+	if err != nil {
+		return true
+	}
+	return status != http.StatusNotFound && status != http.StatusGone
+}
+
+func (p *pipeline) lookup(ctx context.Context, q ArtistQuery, accept func(*BioResult) bool) (*BioResult, error) {
+	return p.lookupIn(ctx, p.sources, q, accept)
+}
+
+// skipSource takes a source out of rotation for the given duration.
+func (p *pipeline) skipSource(name string, d time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.skipped == nil {
+		p.skipped = map[string]time.Time{}
+	}
+	p.skipped[name] = time.Now().Add(d)
+}
+
+// sourceSkipped reports whether the source is currently out of rotation,
+// clearing expired entries as a side effect.
+func (p *pipeline) sourceSkipped(name string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	until, ok := p.skipped[name]
+	if !ok {
+		return false
+	}
+	if time.Now().After(until) {
+		delete(p.skipped, name)
+		return false
+	}
+	return true
+}
+
+func (p *pipeline) lookupIn(ctx context.Context, sources []BioSource, q ArtistQuery, accept func(*BioResult) bool) (*BioResult, error) {
+	for _, src := range sources {
+		if p.sourceSkipped(src.Name()) {
+			continue
+		}
+		result, err := p.lookupOne(ctx, src, q)
+		switch {
+		case errors.Is(err, ErrNotFound):
+			continue
+		case errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded):
+			if ctx.Err() != nil {
+				return nil, ctx.Err() // Overall deadline is gone, stop trying
+			}
+			log.Printf("source %s timed out for %q, trying next", src.Name(), q.Name)
+			continue
+		case err != nil:
+			log.Printf("source %s failed for %q: %v", src.Name(), q.Name, err)
+			continue
+		}
+		if accept(result) {
+			result.Source = src.Name()
+			result.License = src.License()
+			result.RetrievedAt = time.Now()
+			return result, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (p *pipeline) lookupOne(ctx context.Context, src BioSource, q ArtistQuery) (*BioResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.perSourceTimeout)
+	defer cancel()
+	return src.Lookup(ctx, q)
+}
+
+// defaultSources returns the source chain in its default order: Wikipedia via
+// the artist's MBID, then DBpedia by name, then the bare Wikidata description.
+// The store may be nil, which disables lookup memoization.
+func defaultSources(f *Fetcher, store Store) []BioSource {
+	wikipedia := newWikipediaSource(f)
+	wikipedia.store = store
+	return []BioSource{
+		wikipedia,
+		newDBpediaSource(f),
+		newWikidataDescriptionSource(f),
+	}
+}
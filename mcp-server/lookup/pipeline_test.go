@@ -0,0 +1,200 @@
+package lookup
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// fakeSource is a scriptable BioSource for pipeline tests.
+type fakeSource struct {
+	name    string
+	license string
+	result  *BioResult
+	err     error
+	calls   int
+}
+
+func (f *fakeSource) Name() string { return f.name }
+
+func (f *fakeSource) License() string { return f.license }
+
+func (f *fakeSource) Lookup(_ context.Context, _ ArtistQuery) (*BioResult, error) {
+	f.calls++
+	return f.result, f.err
+}
+
+var _ = Describe("pipeline", func() {
+	var q ArtistQuery
+
+	BeforeEach(func() {
+		q = ArtistQuery{Name: "The Artist", MBID: "mbid-1"}
+	})
+
+	It("returns the first source's biography and stops", func() {
+		first := &fakeSource{name: "first", result: &BioResult{Text: "a bio"}}
+		second := &fakeSource{name: "second", result: &BioResult{Text: "other bio"}}
+		p := newPipeline([]BioSource{first, second})
+
+		result, err := p.getBiography(context.Background(), q)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.Text).To(Equal("a bio"))
+		Expect(result.Source).To(Equal("first"))
+		Expect(second.calls).To(BeZero())
+	})
+
+	It("falls through sources that have nothing", func() {
+		first := &fakeSource{name: "first", err: ErrNotFound}
+		second := &fakeSource{name: "second", result: &BioResult{Text: "a bio"}}
+		p := newPipeline([]BioSource{first, second})
+
+		result, err := p.getBiography(context.Background(), q)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.Source).To(Equal("second"))
+		Expect(first.calls).To(Equal(1))
+	})
+
+	It("treats transient errors as skippable", func() {
+		first := &fakeSource{name: "first", err: errors.New("boom")}
+		second := &fakeSource{name: "second", result: &BioResult{Text: "a bio"}}
+		p := newPipeline([]BioSource{first, second})
+
+		result, err := p.getBiography(context.Background(), q)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.Source).To(Equal("second"))
+	})
+
+	It("skips sources that only have a URL when a biography is wanted", func() {
+		first := &fakeSource{name: "first", result: &BioResult{URL: "https://example.com"}}
+		second := &fakeSource{name: "second", result: &BioResult{Text: "a bio"}}
+		p := newPipeline([]BioSource{first, second})
+
+		result, err := p.getBiography(context.Background(), q)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.Source).To(Equal("second"))
+	})
+
+	It("serves URLs from the same registry", func() {
+		first := &fakeSource{name: "first", result: &BioResult{Text: "a bio", URL: "https://example.com/artist"}}
+		p := newPipeline([]BioSource{first})
+
+		result, err := p.getURL(context.Background(), q)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.URL).To(Equal("https://example.com/artist"))
+	})
+
+	It("returns ErrNotFound when all sources are exhausted", func() {
+		p := newPipeline([]BioSource{
+			&fakeSource{name: "first", err: ErrNotFound},
+			&fakeSource{name: "second", err: errors.New("boom")},
+		})
+
+		_, err := p.getBiography(context.Background(), q)
+		Expect(err).To(MatchError(ErrNotFound))
+	})
+
+	It("falls back to a search URL when no source has one", func() {
+		p := newPipeline([]BioSource{&fakeSource{name: "first", err: ErrNotFound}})
+
+		result, err := p.getURL(context.Background(), q)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.Source).To(Equal("search"))
+		Expect(result.URL).To(HavePrefix("https://en.wikipedia.org/wiki/Special:Search?search="))
+	})
+
+	It("consults sources in the type-specific configured order", func() {
+		bioFirst := &fakeSource{name: "bio-first", result: &BioResult{Text: "a bio", URL: "https://a"}}
+		linksFirst := &fakeSource{name: "links-first", result: &BioResult{Text: "other bio", URL: "https://b"}}
+		p := newPipeline([]BioSource{bioFirst, linksFirst})
+		p.applySourceOrders(map[string][]string{
+			typeBio:   {"bio-first", "links-first"},
+			typeLinks: {"links-first", "bio-first"},
+		})
+
+		bio, err := p.getBiography(context.Background(), q)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(bio.Source).To(Equal("bio-first"))
+
+		link, err := p.getURL(context.Background(), q)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(link.Source).To(Equal("links-first"))
+		Expect(bioFirst.calls).To(Equal(1))
+		Expect(linksFirst.calls).To(Equal(1))
+	})
+
+	It("ignores unknown source names in a configured order", func() {
+		first := &fakeSource{name: "first", result: &BioResult{Text: "a bio"}}
+		p := newPipeline([]BioSource{first})
+		p.applySourceOrders(map[string][]string{typeBio: {"nonexistent", "first"}})
+
+		result, err := p.getBiography(context.Background(), q)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.Source).To(Equal("first"))
+	})
+
+	It("falls back to the registry order for types without one", func() {
+		first := &fakeSource{name: "first", result: &BioResult{URL: "https://a"}}
+		p := newPipeline([]BioSource{first})
+
+		result, err := p.getURL(context.Background(), q)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.Source).To(Equal("first"))
+	})
+
+	It("falls back past URLs that HEAD-check as gone when validation is requested", func() {
+		dead := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+		}))
+		defer dead.Close()
+		alive := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer alive.Close()
+
+		primary := &fakeSource{name: "wikipedia", result: &BioResult{URL: dead.URL}}
+		fallback := &fakeSource{name: "dbpedia", result: &BioResult{URL: alive.URL}}
+		p := newPipeline([]BioSource{primary, fallback})
+		f := NewFetcher()
+		f.client = alive.Client()
+		p.head = f.head
+
+		q.Validate = true
+		result, err := p.getURL(context.Background(), q)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.URL).To(Equal(alive.URL))
+		Expect(result.Source).To(Equal("dbpedia"))
+		Expect(result.Validated).To(BeTrue())
+	})
+
+	It("does not HEAD-check URLs when validation is not requested", func() {
+		headCalls := 0
+		primary := &fakeSource{name: "wikipedia", result: &BioResult{URL: "https://example.com/artist"}}
+		p := newPipeline([]BioSource{primary})
+		p.head = func(_ context.Context, _ string) (int, error) {
+			headCalls++
+			return http.StatusNotFound, nil
+		}
+
+		result, err := p.getURL(context.Background(), q)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.URL).To(Equal("https://example.com/artist"))
+		Expect(result.Validated).To(BeFalse())
+		Expect(headCalls).To(BeZero())
+	})
+
+	It("stops when the overall context is done", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		first := &fakeSource{name: "first", err: context.Canceled}
+		second := &fakeSource{name: "second", result: &BioResult{Text: "a bio"}}
+		p := newPipeline([]BioSource{first, second})
+
+		_, err := p.lookup(ctx, q, func(*BioResult) bool { return true })
+		Expect(err).To(MatchError(context.Canceled))
+		Expect(second.calls).To(BeZero())
+	})
+})
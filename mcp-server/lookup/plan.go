@@ -0,0 +1,40 @@
+package lookup
+
+import "net/http"
+
+// PlannedRequest describes one HTTP request a lookup would issue, for the
+// dry-run preview. Only requests derivable from the query alone are listed;
+// follow-ups whose URL depends on an earlier response (e.g. the Wikipedia
+// summary fetch after the article resolution) are not.
+type PlannedRequest struct {
+	Source string `json:"source"`
+	Method string `json:"method"`
+	URL    string `json:"url"`
+}
+
+// requestPlanner is implemented by sources that can describe the requests a
+// lookup would issue without executing them.
+type requestPlanner interface {
+	plan(q ArtistQuery) []PlannedRequest
+}
+
+// plannedGet builds the common single-GET plan entry.
+func plannedGet(source, url string) []PlannedRequest {
+	return []PlannedRequest{{Source: source, Method: http.MethodGet, URL: url}}
+}
+
+// planLookup lists the requests a lookup of the given type would issue, in
+// the order the sources would be consulted. Skipped sources are left out,
+// mirroring a real run.
+func (p *pipeline) planLookup(typ string, q ArtistQuery) []PlannedRequest {
+	var planned []PlannedRequest
+	for _, src := range p.sourcesFor(typ) {
+		if p.sourceSkipped(src.Name()) {
+			continue
+		}
+		if planner, ok := src.(requestPlanner); ok {
+			planned = append(planned, planner.plan(q)...)
+		}
+	}
+	return planned
+}
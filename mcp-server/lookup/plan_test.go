@@ -0,0 +1,95 @@
+package lookup
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// recordingTransport records every request URL and answers with an empty but
+// valid payload for both the SPARQL and MusicBrainz decoders, so each source
+// reports not-found and the pipeline walks the full chain.
+type recordingTransport struct {
+	mu   sync.Mutex
+	urls []string
+}
+
+func (t *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.mu.Lock()
+	t.urls = append(t.urls, req.URL.String())
+	t.mu.Unlock()
+	body := `{"results":{"bindings":[]},"artists":[]}`
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{},
+		Body:       io.NopCloser(strings.NewReader(body)),
+		Request:    req,
+	}, nil
+}
+
+func (t *recordingTransport) recorded() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]string(nil), t.urls...)
+}
+
+var _ = Describe("dry-run planning", func() {
+	var c *Client
+	var rec *recordingTransport
+
+	BeforeEach(func() {
+		rec = &recordingTransport{}
+		f := NewFetcher()
+		f.client = &http.Client{Transport: rec}
+		c = NewClient(f, nil)
+	})
+
+	urlsOf := func(planned []PlannedRequest) []string {
+		var urls []string
+		for _, p := range planned {
+			Expect(p.Method).To(Equal(http.MethodGet))
+			urls = append(urls, p.URL)
+		}
+		return urls
+	}
+
+	It("previews exactly the requests a biography lookup issues", func() {
+		q := ArtistQuery{Name: "The Artist", MBID: "mbid-1"}
+		planned := c.PlanBiography(q)
+		_, err := c.GetBiography(context.Background(), q)
+		Expect(err).To(MatchError(ErrNotFound))
+		Expect(urlsOf(planned)).To(Equal(rec.recorded()))
+	})
+
+	It("lists the sources in their configured order", func() {
+		planned := c.PlanBiography(ArtistQuery{Name: "The Artist", MBID: "mbid-1"})
+		Expect(planned).To(HaveLen(3))
+		Expect(planned[0].Source).To(Equal("wikipedia"))
+		Expect(planned[1].Source).To(Equal("dbpedia"))
+		Expect(planned[2].Source).To(Equal("wikidata-description"))
+	})
+
+	It("includes the MBID resolution search when the query has none", func() {
+		q := ArtistQuery{Name: "The Artist"}
+		planned := c.PlanBiography(q)
+		_, err := c.GetBiography(context.Background(), q)
+		Expect(err).To(MatchError(ErrNotFound))
+		Expect(urlsOf(planned)).To(Equal(rec.recorded()))
+		Expect(planned[0].Source).To(Equal("musicbrainz"))
+	})
+
+	It("previews exactly the requests a URL lookup issues", func() {
+		q := ArtistQuery{Name: "The Artist", MBID: "mbid-1"}
+		planned := c.PlanURL(q)
+		result, err := c.GetURL(context.Background(), q)
+		Expect(err).ToNot(HaveOccurred())
+		// The search fallback builds a URL locally, without a request.
+		Expect(result.Source).To(Equal("search"))
+		Expect(urlsOf(planned)).To(Equal(rec.recorded()))
+	})
+})
@@ -0,0 +1,54 @@
+package lookup
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// Startup probe budgets: each endpoint gets probeTimeout to answer, and
+// sources behind an unreachable endpoint are skipped for probeSkipDuration,
+// after which they rejoin the chain (the outage may be over).
+const (
+	probeTimeout      = 5 * time.Second
+	probeSkipDuration = 10 * time.Minute
+)
+
+// probeTarget ties an upstream endpoint to the sources that depend on it, so
+// an unreachable endpoint skips exactly the right sources.
+type probeTarget struct {
+	url     string
+	sources []string
+}
+
+// defaultProbeTargets covers the upstreams the default source chain talks to.
+func defaultProbeTargets() []probeTarget {
+	return []probeTarget{
+		{url: wikidataSPARQLEndpoint, sources: []string{"wikidata-description"}},
+		{url: wikipediaRESTSummary, sources: []string{"wikipedia"}},
+		{url: dbpediaSPARQLEndpoint, sources: []string{"dbpedia"}},
+	}
+}
+
+// probeEndpoints checks each endpoint with a cheap HEAD request, logging
+// reachability and latency, and temporarily skips sources behind unreachable
+// ones. Any HTTP answer counts as reachable - only transport failures mark an
+// endpoint down.
+func probeEndpoints(ctx context.Context, f *Fetcher, p *pipeline, targets []probeTarget) {
+	for _, target := range targets {
+		probeCtx, cancel := context.WithTimeout(ctx, probeTimeout)
+		start := time.Now()
+		status, err := f.head(probeCtx, target.url)
+		latency := time.Since(start)
+		cancel()
+		if err != nil {
+			log.Printf("startup probe: %s unreachable after %s, skipping %v for %s: %v",
+				loggedURL(target.url), latency.Round(time.Millisecond), target.sources, probeSkipDuration, err)
+			for _, name := range target.sources {
+				p.skipSource(name, probeSkipDuration)
+			}
+			continue
+		}
+		log.Printf("startup probe: %s reachable in %s (status %d)", loggedURL(target.url), latency.Round(time.Millisecond), status)
+	}
+}
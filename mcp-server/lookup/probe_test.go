@@ -0,0 +1,69 @@
+package lookup
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("startup probe", func() {
+	It("skips sources behind unreachable endpoints and keeps the rest", func() {
+		alive := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}))
+		DeferCleanup(alive.Close)
+		dead := httptest.NewServer(http.HandlerFunc(nil))
+		deadURL := dead.URL
+		dead.Close() // Nothing listens here anymore
+
+		reachable := &fakeSource{name: "reachable", result: &BioResult{Text: "a bio"}}
+		unreachable := &fakeSource{name: "unreachable", result: &BioResult{Text: "other bio"}}
+		p := newPipeline([]BioSource{unreachable, reachable})
+		f := NewFetcher()
+		f.client = alive.Client()
+
+		probeEndpoints(context.Background(), f, p, []probeTarget{
+			{url: alive.URL, sources: []string{"reachable"}},
+			{url: deadURL, sources: []string{"unreachable"}},
+		})
+
+		Expect(p.sourceSkipped("unreachable")).To(BeTrue())
+		Expect(p.sourceSkipped("reachable")).To(BeFalse())
+
+		result, err := p.getBiography(context.Background(), ArtistQuery{Name: "The Artist"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.Source).To(Equal("reachable"))
+		Expect(unreachable.calls).To(BeZero())
+	})
+
+	It("lets skipped sources rejoin after the skip expires", func() {
+		src := &fakeSource{name: "flaky", result: &BioResult{Text: "a bio"}}
+		p := newPipeline([]BioSource{src})
+		p.skipSource("flaky", time.Millisecond)
+
+		Eventually(func() bool { return p.sourceSkipped("flaky") }).Should(BeFalse())
+		result, err := p.getBiography(context.Background(), ArtistQuery{Name: "The Artist"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.Source).To(Equal("flaky"))
+	})
+
+	It("treats any HTTP answer as reachable", func() {
+		erroring := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		DeferCleanup(erroring.Close)
+
+		p := newPipeline([]BioSource{&fakeSource{name: "busy"}})
+		f := NewFetcher()
+		f.client = erroring.Client()
+
+		probeEndpoints(context.Background(), f, p, []probeTarget{
+			{url: erroring.URL, sources: []string{"busy"}},
+		})
+		Expect(p.sourceSkipped("busy")).To(BeFalse())
+	})
+})
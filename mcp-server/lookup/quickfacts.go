@@ -0,0 +1,106 @@
+package lookup
+
+import (
+	"context"
+	"fmt"
+)
+
+// topGenresLimit caps the genres in a quick-facts bundle; importers want the
+// defining few, not the full tag cloud.
+const topGenresLimit = 5
+
+// ArtistQuickFacts is the payload of get_artist_quick_facts: the compact
+// bundle an importer needs for a brand-new artist. Every field is
+// independently optional - whatever Wikidata has is returned, the rest stays
+// empty.
+type ArtistQuickFacts struct {
+	Tagline   string   `json:"tagline,omitempty"`
+	Bio       string   `json:"bio,omitempty"`
+	Thumbnail string   `json:"thumbnail,omitempty"`
+	Genres    []string `json:"genres,omitempty"`
+	Origin    string   `json:"origin,omitempty"`
+	URL       string   `json:"url,omitempty"`
+}
+
+// quickFactsSource gathers the whole bundle with a single entity resolution:
+// one SPARQL query pulls every fact as OPTIONAL clauses, and only the bio
+// summary needs one extra (non-SPARQL) extract fetch when the artist has a
+// Wikipedia article.
+type quickFactsSource struct {
+	fetcher  *Fetcher
+	endpoint string
+	// extract fetches the intro extract for a Wikipedia article title, used
+	// for the bio summary. May be nil, leaving the bio empty.
+	extract func(ctx context.Context, title string) (string, error)
+}
+
+func newQuickFactsSource(f *Fetcher) *quickFactsSource {
+	return &quickFactsSource{
+		fetcher:  f,
+		endpoint: wikidataSPARQLEndpoint,
+		extract:  newWikipediaSource(f).extract,
+	}
+}
+
+func (s *quickFactsSource) getQuickFacts(ctx context.Context, q ArtistQuery) (*ArtistQuickFacts, error) {
+	if q.MBID == "" {
+		return nil, ErrNotFound
+	}
+	query := fmt.Sprintf(`SELECT ?description ?genreLabel ?originLabel ?image ?article WHERE {
+  ?artist wdt:P434 %q .
+  OPTIONAL { ?artist schema:description ?description . FILTER (lang(?description) = "en") }
+  OPTIONAL { ?artist wdt:P136 ?genre . }
+  OPTIONAL { ?artist wdt:P495 ?origin . }
+  OPTIONAL { ?artist wdt:P18 ?image . }
+  OPTIONAL { ?article schema:about ?artist ; schema:isPartOf <https://en.wikipedia.org/> . }
+  SERVICE wikibase:label { bd:serviceParam wikibase:language "en,[AUTO_LANGUAGE]". }
+} LIMIT 50`, q.MBID)
+	results, err := s.fetcher.sparql(ctx, s.endpoint, query)
+	if err != nil {
+		return nil, err
+	}
+	if len(results.Results.Bindings) == 0 {
+		return nil, ErrNotFound
+	}
+	facts := quickFactsFromResults(results)
+	if facts.URL != "" && s.extract != nil {
+		if title := titleFromArticleURL(facts.URL); title != "" {
+			if text, err := s.extract(ctx, title); err == nil {
+				facts.Bio = text
+			}
+		}
+	}
+	return facts, nil
+}
+
+// quickFactsFromResults assembles the bundle from the single entity query. The
+// scalar fields repeat on every binding (one row per genre), so the first
+// non-empty value wins; genres are de-duplicated and capped at topGenresLimit.
+func quickFactsFromResults(results *sparqlResults) *ArtistQuickFacts {
+	facts := &ArtistQuickFacts{}
+	seen := map[string]struct{}{}
+	for _, binding := range results.Results.Bindings {
+		if facts.Tagline == "" {
+			facts.Tagline = binding["description"].Value
+		}
+		if facts.URL == "" {
+			facts.URL = binding["article"].Value
+		}
+		if facts.Thumbnail == "" && binding["image"].Value != "" {
+			facts.Thumbnail = fmt.Sprintf("%s?width=%d", binding["image"].Value, thumbnailWidth)
+		}
+		if origin := binding["originLabel"].Value; facts.Origin == "" && origin != "" && !entityIDLabel.MatchString(origin) {
+			facts.Origin = origin
+		}
+		genre := binding["genreLabel"].Value
+		if genre == "" || entityIDLabel.MatchString(genre) || len(facts.Genres) >= topGenresLimit {
+			continue
+		}
+		if _, ok := seen[genre]; ok {
+			continue
+		}
+		seen[genre] = struct{}{}
+		facts.Genres = append(facts.Genres, genre)
+	}
+	return facts
+}
@@ -0,0 +1,95 @@
+package lookup
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("quickFactsFromResults", func() {
+	parse := func(body string) *sparqlResults {
+		var results sparqlResults
+		Expect(json.Unmarshal([]byte(body), &results)).To(Succeed())
+		return &results
+	}
+
+	It("assembles the bundle from a multi-genre response", func() {
+		results := parse(`{"results":{"bindings":[
+			{"description":{"value":"American jazz trumpeter"},
+			 "genreLabel":{"value":"jazz"},
+			 "originLabel":{"value":"United States of America"},
+			 "image":{"value":"http://commons.wikimedia.org/wiki/Special:FilePath/Miles.jpg"},
+			 "article":{"value":"https://en.wikipedia.org/wiki/Miles_Davis"}},
+			{"description":{"value":"American jazz trumpeter"},
+			 "genreLabel":{"value":"bebop"},
+			 "article":{"value":"https://en.wikipedia.org/wiki/Miles_Davis"}},
+			{"genreLabel":{"value":"jazz"}}
+		]}}`)
+		facts := quickFactsFromResults(results)
+		Expect(facts.Tagline).To(Equal("American jazz trumpeter"))
+		Expect(facts.Genres).To(Equal([]string{"jazz", "bebop"}))
+		Expect(facts.Origin).To(Equal("United States of America"))
+		Expect(facts.Thumbnail).To(Equal("http://commons.wikimedia.org/wiki/Special:FilePath/Miles.jpg?width=250"))
+		Expect(facts.URL).To(Equal("https://en.wikipedia.org/wiki/Miles_Davis"))
+	})
+
+	It("caps the genres and drops unresolved labels", func() {
+		results := parse(`{"results":{"bindings":[
+			{"genreLabel":{"value":"Q11399"}},
+			{"genreLabel":{"value":"one"}},{"genreLabel":{"value":"two"}},
+			{"genreLabel":{"value":"three"}},{"genreLabel":{"value":"four"}},
+			{"genreLabel":{"value":"five"}},{"genreLabel":{"value":"six"}}
+		]}}`)
+		Expect(quickFactsFromResults(results).Genres).To(Equal([]string{"one", "two", "three", "four", "five"}))
+	})
+
+	It("leaves absent facts empty", func() {
+		facts := quickFactsFromResults(parse(`{"results":{"bindings":[{"description":{"value":"a singer"}}]}}`))
+		Expect(facts.Tagline).To(Equal("a singer"))
+		Expect(facts.Bio).To(BeEmpty())
+		Expect(facts.Thumbnail).To(BeEmpty())
+		Expect(facts.Genres).To(BeEmpty())
+		Expect(facts.Origin).To(BeEmpty())
+		Expect(facts.URL).To(BeEmpty())
+	})
+})
+
+var _ = Describe("getQuickFacts", func() {
+	It("returns the populated bundle with one entity resolution", func() {
+		var sparqlCalls atomic.Int32
+		upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			sparqlCalls.Add(1)
+			w.Header().Set("Cache-Control", "no-store")
+			_, _ = w.Write([]byte(`{"results":{"bindings":[
+				{"description":{"value":"American jazz trumpeter"},
+				 "genreLabel":{"value":"jazz"},
+				 "originLabel":{"value":"United States of America"},
+				 "image":{"value":"http://commons.wikimedia.org/wiki/Special:FilePath/Miles.jpg"},
+				 "article":{"value":"https://en.wikipedia.org/wiki/Miles_Davis"}}
+			]}}`))
+		}))
+		defer upstream.Close()
+
+		source := &quickFactsSource{
+			fetcher:  NewFetcher(),
+			endpoint: upstream.URL,
+			extract: func(_ context.Context, title string) (string, error) {
+				Expect(title).To(Equal("Miles_Davis"))
+				return "Miles Davis was an American jazz trumpeter.", nil
+			},
+		}
+		facts, err := source.getQuickFacts(context.Background(), ArtistQuery{Name: "Miles Davis", MBID: "mbid-1"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(facts.Tagline).To(Equal("American jazz trumpeter"))
+		Expect(facts.Bio).To(Equal("Miles Davis was an American jazz trumpeter."))
+		Expect(facts.Genres).To(Equal([]string{"jazz"}))
+		Expect(facts.Origin).To(Equal("United States of America"))
+		Expect(facts.URL).To(Equal("https://en.wikipedia.org/wiki/Miles_Davis"))
+		Expect(sparqlCalls.Load()).To(Equal(int32(1)))
+	})
+})
@@ -0,0 +1,45 @@
+package lookup
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// defaultSearchURLTemplate is the final fallback for get_artist_url when no
+// source knows the artist: a Wikipedia search deep link.
+const defaultSearchURLTemplate = "https://en.wikipedia.org/wiki/Special:Search?search={query}"
+
+// queryPlaceholder marks where the artist name goes in a search URL template.
+const queryPlaceholder = "{query}"
+
+// validateSearchURLTemplate checks a template: it must contain the {query}
+// placeholder and expand to an https URL.
+func validateSearchURLTemplate(template string) error {
+	if !strings.Contains(template, queryPlaceholder) {
+		return fmt.Errorf("search URL template %q does not contain %s", template, queryPlaceholder)
+	}
+	u, err := url.Parse(strings.ReplaceAll(template, queryPlaceholder, "probe"))
+	if err != nil {
+		return fmt.Errorf("invalid search URL template %q: %w", template, err)
+	}
+	if u.Scheme != "https" {
+		return fmt.Errorf("search URL template %q must use https", template)
+	}
+	return nil
+}
+
+// searchFallbackURL expands the search template for the artist: the one the
+// query carries (e.g. a DuckDuckGo template), else the default. An invalid
+// query template falls back to the default rather than failing the lookup -
+// the host already validates its configured template at startup.
+func searchFallbackURL(q ArtistQuery) string {
+	if q.Name == "" {
+		return ""
+	}
+	template := q.SearchURLTemplate
+	if template == "" || validateSearchURLTemplate(template) != nil {
+		template = defaultSearchURLTemplate
+	}
+	return strings.ReplaceAll(template, queryPlaceholder, url.QueryEscape(q.Name))
+}
@@ -0,0 +1,49 @@
+package lookup
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("validateSearchURLTemplate", func() {
+	It("accepts the default template", func() {
+		Expect(validateSearchURLTemplate(defaultSearchURLTemplate)).To(Succeed())
+	})
+
+	It("rejects a template without the {query} placeholder", func() {
+		err := validateSearchURLTemplate("https://duckduckgo.com/?q=fixed")
+		Expect(err).To(MatchError(ContainSubstring("{query}")))
+	})
+
+	It("rejects a non-https template", func() {
+		err := validateSearchURLTemplate("http://duckduckgo.com/?q={query}")
+		Expect(err).To(MatchError(ContainSubstring("https")))
+	})
+})
+
+var _ = Describe("searchFallbackURL", func() {
+	It("expands the default Wikipedia template with the escaped name", func() {
+		url := searchFallbackURL(ArtistQuery{Name: "AC/DC & Friends"})
+		Expect(url).To(Equal("https://en.wikipedia.org/wiki/Special:Search?search=AC%2FDC+%26+Friends"))
+	})
+
+	It("expands a DuckDuckGo template from the query", func() {
+		url := searchFallbackURL(ArtistQuery{
+			Name:              "Miles Davis",
+			SearchURLTemplate: "https://duckduckgo.com/?q={query}",
+		})
+		Expect(url).To(Equal("https://duckduckgo.com/?q=Miles+Davis"))
+	})
+
+	It("falls back to the default for an invalid query template", func() {
+		url := searchFallbackURL(ArtistQuery{
+			Name:              "Miles Davis",
+			SearchURLTemplate: "http://insecure.example/?q={query}",
+		})
+		Expect(url).To(Equal("https://en.wikipedia.org/wiki/Special:Search?search=Miles+Davis"))
+	})
+
+	It("returns nothing without an artist name", func() {
+		Expect(searchFallbackURL(ArtistQuery{})).To(BeEmpty())
+	})
+})
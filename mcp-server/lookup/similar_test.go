@@ -0,0 +1,48 @@
+package lookup
+
+import (
+	"context"
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("similarFromResults", func() {
+	parse := func(body string) *sparqlResults {
+		var results sparqlResults
+		Expect(json.Unmarshal([]byte(body), &results)).To(Succeed())
+		return &results
+	}
+
+	It("parses similar artists with their MBIDs, de-duplicated and in order", func() {
+		results := parse(`{"results":{"bindings":[
+			{"similarLabel":{"value":"First Artist"},"mbid":{"value":"mbid-1"}},
+			{"similarLabel":{"value":"Second Artist"},"mbid":{"value":"mbid-2"}},
+			{"similarLabel":{"value":"First Artist"},"mbid":{"value":"mbid-1"}}
+		]}}`)
+		Expect(similarFromResults(results)).To(Equal([]SimilarArtist{
+			{Name: "First Artist", MBID: "mbid-1"},
+			{Name: "Second Artist", MBID: "mbid-2"},
+		}))
+	})
+
+	It("drops entries without an MBID or with unresolved labels", func() {
+		results := parse(`{"results":{"bindings":[
+			{"similarLabel":{"value":"No MBID"}},
+			{"similarLabel":{"value":"Q547262"},"mbid":{"value":"mbid-raw"}},
+			{"similarLabel":{"value":"Kept"},"mbid":{"value":"mbid-kept"}}
+		]}}`)
+		Expect(similarFromResults(results)).To(Equal([]SimilarArtist{
+			{Name: "Kept", MBID: "mbid-kept"},
+		}))
+	})
+})
+
+var _ = Describe("similarSource", func() {
+	It("requires an MBID", func() {
+		s := newSimilarSource(nil)
+		_, err := s.getSimilar(context.Background(), SimilarQuery{ArtistQuery: ArtistQuery{Name: "No MBID"}})
+		Expect(err).To(MatchError(ErrNotFound))
+	})
+})
@@ -0,0 +1,97 @@
+package lookup
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// artistLookupResponse is the subset of the MusicBrainz artist lookup response
+// used for sort names.
+type artistLookupResponse struct {
+	Name     string `json:"name"`
+	SortName string `json:"sort-name"`
+	Type     string `json:"type"`
+}
+
+// sortNameSource resolves an artist's sort name ("Beatles, The"), preferring
+// the curated MusicBrainz sort-name, then deriving one from the Wikidata label
+// or the queried name.
+type sortNameSource struct {
+	fetcher *Fetcher
+}
+
+func newSortNameSource(f *Fetcher) *sortNameSource {
+	return &sortNameSource{fetcher: f}
+}
+
+func (s *sortNameSource) getSortName(ctx context.Context, q ArtistQuery) (string, error) {
+	if q.MBID != "" {
+		u := fmt.Sprintf("%s/artist/%s?fmt=json", musicbrainzAPIEndpoint, url.PathEscape(q.MBID))
+		var artist artistLookupResponse
+		if err := s.fetcher.getJSON(ctx, u, nil, &artist); err == nil {
+			if sortName := sortNameFromArtist(&artist); sortName != "" {
+				return sortName, nil
+			}
+		}
+		if label, person, err := s.wikidataLabel(ctx, q.MBID); err == nil && label != "" {
+			return deriveSortName(label, person), nil
+		}
+	}
+	if q.Name == "" {
+		return "", ErrNotFound
+	}
+	return deriveSortName(q.Name, false), nil
+}
+
+// wikidataLabel returns the English label for the artist with the given MBID,
+// and whether the entity is a person (instance of human, Q5).
+func (s *sortNameSource) wikidataLabel(ctx context.Context, mbid string) (string, bool, error) {
+	query := fmt.Sprintf(`SELECT ?label ?person WHERE {
+  ?artist wdt:P434 %q ;
+          rdfs:label ?label .
+  FILTER(LANG(?label) = "en")
+  BIND(EXISTS { ?artist wdt:P31 wd:Q5 } AS ?person)
+}`, mbid)
+	results, err := s.fetcher.sparql(ctx, wikidataSPARQLEndpoint, query)
+	if err != nil {
+		return "", false, err
+	}
+	return results.first("label"), results.first("person") == "true", nil
+}
+
+// sortNameFromArtist extracts the sort name from a MusicBrainz artist lookup:
+// the curated sort-name when present, otherwise one derived from the name.
+func sortNameFromArtist(artist *artistLookupResponse) string {
+	if artist.SortName != "" {
+		return artist.SortName
+	}
+	if artist.Name != "" {
+		return deriveSortName(artist.Name, artist.Type == "Person")
+	}
+	return ""
+}
+
+// sortArticles are the leading articles moved to the end of a band name, the
+// way MusicBrainz sorts them ("The Beatles" -> "Beatles, The").
+var sortArticles = []string{"The", "A", "An"}
+
+// deriveSortName constructs a sort name from a display name: leading articles
+// move to the end, and person names are inverted ("John Lennon" ->
+// "Lennon, John"). Names without an article or a last name pass through.
+func deriveSortName(name string, person bool) string {
+	name = strings.TrimSpace(name)
+	for _, article := range sortArticles {
+		prefix := article + " "
+		if len(name) > len(prefix) && strings.EqualFold(name[:len(prefix)], prefix) {
+			return strings.TrimSpace(name[len(prefix):]) + ", " + name[:len(article)]
+		}
+	}
+	if person {
+		if i := strings.LastIndex(name, " "); i > 0 {
+			return name[i+1:] + ", " + name[:i]
+		}
+	}
+	return name
+}
@@ -0,0 +1,67 @@
+package lookup
+
+import (
+	"context"
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("sort names", func() {
+	Describe("deriveSortName", func() {
+		It("moves leading articles to the end for bands", func() {
+			Expect(deriveSortName("The Beatles", false)).To(Equal("Beatles, The"))
+			Expect(deriveSortName("A Tribe Called Quest", false)).To(Equal("Tribe Called Quest, A"))
+			Expect(deriveSortName("An Horse", false)).To(Equal("Horse, An"))
+		})
+
+		It("inverts person names", func() {
+			Expect(deriveSortName("John Lennon", true)).To(Equal("Lennon, John"))
+			Expect(deriveSortName("Johann Sebastian Bach", true)).To(Equal("Bach, Johann Sebastian"))
+		})
+
+		It("leaves names without an article or last name alone", func() {
+			Expect(deriveSortName("Radiohead", false)).To(Equal("Radiohead"))
+			Expect(deriveSortName("Madonna", true)).To(Equal("Madonna"))
+			Expect(deriveSortName("Therapy?", false)).To(Equal("Therapy?"))
+		})
+	})
+
+	Describe("sortNameFromArtist", func() {
+		parse := func(body string) *artistLookupResponse {
+			var artist artistLookupResponse
+			Expect(json.Unmarshal([]byte(body), &artist)).To(Succeed())
+			return &artist
+		}
+
+		It("prefers the curated MusicBrainz sort-name", func() {
+			artist := parse(`{"name":"The Beatles","sort-name":"Beatles, The","type":"Group"}`)
+			Expect(sortNameFromArtist(artist)).To(Equal("Beatles, The"))
+		})
+
+		It("derives from the name when the sort-name is missing", func() {
+			Expect(sortNameFromArtist(parse(`{"name":"John Lennon","type":"Person"}`))).To(Equal("Lennon, John"))
+			Expect(sortNameFromArtist(parse(`{"name":"The Who","type":"Group"}`))).To(Equal("Who, The"))
+		})
+
+		It("returns nothing for an empty lookup", func() {
+			Expect(sortNameFromArtist(parse(`{}`))).To(BeEmpty())
+		})
+	})
+
+	Describe("getSortName", func() {
+		It("derives from the queried name without an MBID", func() {
+			s := newSortNameSource(NewFetcher())
+			sortName, err := s.getSortName(context.Background(), ArtistQuery{Name: "The Kinks"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(sortName).To(Equal("Kinks, The"))
+		})
+
+		It("returns ErrNotFound when there is nothing to derive from", func() {
+			s := newSortNameSource(NewFetcher())
+			_, err := s.getSortName(context.Background(), ArtistQuery{})
+			Expect(err).To(MatchError(ErrNotFound))
+		})
+	})
+})
@@ -0,0 +1,63 @@
+package lookup
+
+import (
+	"strings"
+)
+
+// maxBioRunes is the length bios are truncated to, in runes, so multi-byte
+// scripts are not cut mid-character.
+const maxBioRunes = 2000
+
+// CleanBio normalizes a biography for display. All transforms are rune-safe
+// and locale-aware: non-breaking spaces (used in French before punctuation
+// like « ! ; ? ») and other typographic whitespace are preserved, and only
+// runs of plain ASCII whitespace are collapsed. Set MCP_DISABLE_BIO_CLEANUP
+// to skip everything except trimming.
+func CleanBio(text string, aggressive bool) string {
+	text = strings.TrimSpace(text)
+	if !aggressive {
+		return text
+	}
+	text = collapseASCIIWhitespace(text)
+	return truncateBio(text, maxBioRunes)
+}
+
+// collapseASCIIWhitespace collapses runs of spaces, tabs and newlines into a
+// single space, leaving typographic whitespace (NBSP, narrow NBSP, etc.)
+// untouched.
+func collapseASCIIWhitespace(text string) string {
+	var b strings.Builder
+	b.Grow(len(text))
+	inRun := false
+	for _, r := range text {
+		if r == ' ' || r == '\t' || r == '\n' || r == '\r' {
+			if !inRun {
+				b.WriteRune(' ')
+				inRun = true
+			}
+			continue
+		}
+		inRun = false
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// truncateBio shortens the text to at most limit runes, cutting at the last
+// plain space and appending an ellipsis. Only ASCII spaces are considered
+// boundaries, so tokens glued with non-breaking spaces (like "100 000" or
+// "célèbre !") are never split.
+func truncateBio(text string, limit int) string {
+	runes := []rune(text)
+	if len(runes) <= limit {
+		return text
+	}
+	cut := limit
+	for cut > 0 && runes[cut-1] != ' ' {
+		cut--
+	}
+	if cut == 0 {
+		cut = limit // No boundary found, hard cut on a rune
+	}
+	return strings.TrimRight(string(runes[:cut]), " ") + "…"
+}
@@ -0,0 +1,46 @@
+package lookup
+
+import (
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+const nbsp = " "
+
+var _ = Describe("CleanBio", func() {
+	It("collapses runs of ASCII whitespace", func() {
+		Expect(CleanBio("a  band\n\tfrom\r\nBerlin", true)).To(Equal("a band from Berlin"))
+	})
+
+	It("preserves French non-breaking spaces before punctuation", func() {
+		bio := "Un groupe célèbre" + nbsp + "! Plus de 100" + nbsp + "000 albums vendus" + nbsp + "?"
+		Expect(CleanBio(bio, true)).To(Equal(bio))
+	})
+
+	It("preserves German typographic quotes and numbers", func() {
+		bio := "Die Band verkaufte 1.000.000 Alben – „ein Rekord“."
+		Expect(CleanBio(bio, true)).To(Equal(bio))
+	})
+
+	It("only trims when cleanup is disabled", func() {
+		Expect(CleanBio("  a  messy\n bio  ", false)).To(Equal("a  messy\n bio"))
+	})
+
+	It("truncates long bios at a word boundary without splitting runes", func() {
+		bio := strings.Repeat("wör ", 1000)
+		result := CleanBio(bio, true)
+		Expect(len([]rune(result))).To(BeNumerically("<=", maxBioRunes+1))
+		Expect(strings.HasSuffix(result, "…")).To(BeTrue())
+		Expect(strings.Contains(result, "wö…")).To(BeFalse(), "should not cut inside a word")
+	})
+
+	It("never splits tokens glued with non-breaking spaces when truncating", func() {
+		token := "100" + nbsp + "000"
+		bio := strings.Repeat("x ", maxBioRunes-3) + token
+		result := CleanBio(bio, true)
+		Expect(result).ToNot(ContainSubstring("100" + nbsp + "0…"))
+		Expect(result).ToNot(ContainSubstring("100…"))
+	})
+})
@@ -0,0 +1,84 @@
+package lookup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+)
+
+// thumbnailWidth is the pixel width requested for thumbnails - small enough
+// for list views, large enough for retina grids.
+const thumbnailWidth = 250
+
+// thumbSource produces a single small image URL for an artist. Unlike the bio
+// pipeline, thumbnail sources are ordered fastest first and the chain
+// short-circuits on the first hit, as list views only need one image quickly.
+type thumbSource interface {
+	Name() string
+	Thumbnail(ctx context.Context, q ArtistQuery) (string, error)
+}
+
+// wikidataThumbSource resolves the artist's image (P18) on Wikidata and
+// returns a Commons thumbnail URL at thumbnailWidth. It is the fastest source:
+// one SPARQL query, no page fetches.
+type wikidataThumbSource struct {
+	fetcher *Fetcher
+}
+
+func (s *wikidataThumbSource) Name() string { return "wikidata-p18" }
+
+func (s *wikidataThumbSource) Thumbnail(ctx context.Context, q ArtistQuery) (string, error) {
+	if q.MBID == "" {
+		return "", ErrNotFound
+	}
+	query := fmt.Sprintf(`SELECT ?image WHERE {
+  ?artist wdt:P434 %q ;
+          wdt:P18 ?image .
+} LIMIT 1`, q.MBID)
+	results, err := s.fetcher.sparql(ctx, wikidataSPARQLEndpoint, query)
+	if err != nil {
+		return "", err
+	}
+	image := results.first("image")
+	if image == "" {
+		return "", ErrNotFound
+	}
+	// P18 values are Commons Special:FilePath URLs, which scale on demand.
+	return fmt.Sprintf("%s?width=%d", image, thumbnailWidth), nil
+}
+
+// thumbnailChain consults its sources in order and returns the first URL. With
+// q.Validate set, candidates whose URL HEAD-checks as gone are skipped, so a
+// deleted Commons file falls through to the next source.
+type thumbnailChain struct {
+	sources []thumbSource
+	// head, when set, is used to validate candidate image URLs on request.
+	head func(ctx context.Context, url string) (int, error)
+}
+
+func newThumbnailChain(f *Fetcher) *thumbnailChain {
+	return &thumbnailChain{
+		sources: []thumbSource{&wikidataThumbSource{fetcher: f}},
+		head:    f.head,
+	}
+}
+
+func (c *thumbnailChain) getThumbnail(ctx context.Context, q ArtistQuery) (string, error) {
+	for _, src := range c.sources {
+		url, err := src.Thumbnail(ctx, q)
+		switch {
+		case errors.Is(err, ErrNotFound):
+			continue
+		case err != nil:
+			log.Printf("thumbnail source %s failed for %q: %v", src.Name(), q.Name, err)
+			continue
+		}
+		if q.Validate && c.head != nil && !headAlive(ctx, c.head, url) {
+			log.Printf("thumbnail URL %s is gone, trying next source", loggedURL(url))
+			continue
+		}
+		return url, nil
+	}
+	return "", ErrNotFound
+}
@@ -0,0 +1,85 @@
+package lookup
+
+import (
+	"context"
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// fakeThumbSource is a scriptable thumbSource for chain tests.
+type fakeThumbSource struct {
+	name  string
+	url   string
+	err   error
+	calls int
+}
+
+func (f *fakeThumbSource) Name() string { return f.name }
+
+func (f *fakeThumbSource) Thumbnail(_ context.Context, _ ArtistQuery) (string, error) {
+	f.calls++
+	return f.url, f.err
+}
+
+var _ = Describe("thumbnailChain", func() {
+	var q ArtistQuery
+
+	BeforeEach(func() {
+		q = ArtistQuery{Name: "The Artist", MBID: "mbid-1"}
+	})
+
+	It("returns from the first source without contacting the others", func() {
+		first := &fakeThumbSource{name: "fast", url: "https://img.example/fast.jpg?width=250"}
+		second := &fakeThumbSource{name: "slow", url: "https://img.example/slow.jpg"}
+		c := &thumbnailChain{sources: []thumbSource{first, second}}
+
+		url, err := c.getThumbnail(context.Background(), q)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(url).To(Equal("https://img.example/fast.jpg?width=250"))
+		Expect(second.calls).To(BeZero())
+	})
+
+	It("falls through sources that have nothing or fail", func() {
+		first := &fakeThumbSource{name: "empty", err: ErrNotFound}
+		second := &fakeThumbSource{name: "broken", err: errors.New("boom")}
+		third := &fakeThumbSource{name: "hit", url: "https://img.example/a.jpg"}
+		c := &thumbnailChain{sources: []thumbSource{first, second, third}}
+
+		url, err := c.getThumbnail(context.Background(), q)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(url).To(Equal("https://img.example/a.jpg"))
+	})
+
+	It("returns ErrNotFound when no source has an image", func() {
+		c := &thumbnailChain{sources: []thumbSource{&fakeThumbSource{name: "empty", err: ErrNotFound}}}
+		_, err := c.getThumbnail(context.Background(), q)
+		Expect(err).To(MatchError(ErrNotFound))
+	})
+
+	It("falls back past image URLs that HEAD-check as gone when validation is requested", func() {
+		first := &fakeThumbSource{name: "stale", url: "https://img.example/deleted.jpg"}
+		second := &fakeThumbSource{name: "fresh", url: "https://img.example/ok.jpg"}
+		c := &thumbnailChain{
+			sources: []thumbSource{first, second},
+			head: func(_ context.Context, url string) (int, error) {
+				if url == "https://img.example/deleted.jpg" {
+					return 404, nil
+				}
+				return 200, nil
+			},
+		}
+
+		q.Validate = true
+		url, err := c.getThumbnail(context.Background(), q)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(url).To(Equal("https://img.example/ok.jpg"))
+
+		// Without validation the stale URL is returned as-is.
+		q.Validate = false
+		url, err = c.getThumbnail(context.Background(), q)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(url).To(Equal("https://img.example/deleted.jpg"))
+	})
+})
@@ -0,0 +1,79 @@
+package lookup
+
+import (
+	"context"
+	"fmt"
+)
+
+// defaultTopSongsCount bounds a top-songs answer when the caller does not pass
+// its own count.
+const defaultTopSongsCount = 10
+
+// TopSong is one entry of the get_artist_top_songs payload. MBID is the
+// MusicBrainz recording ID when Wikidata knows it, and empty otherwise.
+type TopSong struct {
+	Title string `json:"title"`
+	MBID  string `json:"mbid,omitempty"`
+}
+
+// TopSongsQuery is ArtistQuery plus the result count.
+type TopSongsQuery struct {
+	ArtistQuery
+	Count int `json:"count,omitempty"`
+}
+
+// topSongsSource finds the artist's best-known songs on Wikidata via notable
+// works (P800). Notable works are curated, which keeps the answer short and
+// actually notable - unlike a full discography join, which would rank deep
+// cuts next to the hits.
+type topSongsSource struct {
+	fetcher *Fetcher
+}
+
+func newTopSongsSource(f *Fetcher) *topSongsSource {
+	return &topSongsSource{fetcher: f}
+}
+
+func (s *topSongsSource) getTopSongs(ctx context.Context, q TopSongsQuery) ([]TopSong, error) {
+	if q.MBID == "" {
+		return nil, ErrNotFound
+	}
+	count := q.Count
+	if count <= 0 {
+		count = defaultTopSongsCount
+	}
+	query := fmt.Sprintf(`SELECT DISTINCT ?workLabel ?mbid WHERE {
+  ?artist wdt:P434 %q ;
+          wdt:P800 ?work .
+  OPTIONAL { ?work wdt:P4404 ?mbid . }
+  SERVICE wikibase:label { bd:serviceParam wikibase:language "en,[AUTO_LANGUAGE]". }
+} LIMIT %d`, q.MBID, count)
+	results, err := s.fetcher.sparql(ctx, wikidataSPARQLEndpoint, query)
+	if err != nil {
+		return nil, err
+	}
+	songs := topSongsFromResults(results)
+	if len(songs) == 0 {
+		return nil, ErrNotFound
+	}
+	return songs, nil
+}
+
+// topSongsFromResults extracts the songs, de-duplicated by title and in
+// response order. Unresolved entity-ID labels are dropped.
+func topSongsFromResults(results *sparqlResults) []TopSong {
+	var songs []TopSong
+	seen := map[string]struct{}{}
+	for _, binding := range results.Results.Bindings {
+		title := binding["workLabel"].Value
+		if title == "" || entityIDLabel.MatchString(title) {
+			continue
+		}
+		if _, ok := seen[title]; ok {
+			continue
+		}
+		seen[title] = struct{}{}
+		songs = append(songs, TopSong{Title: title, MBID: binding["mbid"].Value})
+	}
+	return songs
+}
@@ -0,0 +1,48 @@
+package lookup
+
+import (
+	"context"
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("topSongsFromResults", func() {
+	parse := func(body string) *sparqlResults {
+		var results sparqlResults
+		Expect(json.Unmarshal([]byte(body), &results)).To(Succeed())
+		return &results
+	}
+
+	It("parses songs with their recording MBIDs, de-duplicated and in order", func() {
+		results := parse(`{"results":{"bindings":[
+			{"workLabel":{"value":"First Song"},"mbid":{"value":"rec-1"}},
+			{"workLabel":{"value":"Second Song"}},
+			{"workLabel":{"value":"First Song"},"mbid":{"value":"rec-1"}}
+		]}}`)
+		Expect(topSongsFromResults(results)).To(Equal([]TopSong{
+			{Title: "First Song", MBID: "rec-1"},
+			{Title: "Second Song"},
+		}))
+	})
+
+	It("drops entries with empty or unresolved labels", func() {
+		results := parse(`{"results":{"bindings":[
+			{"workLabel":{"value":"Q1234567"},"mbid":{"value":"rec-raw"}},
+			{"mbid":{"value":"rec-nameless"}},
+			{"workLabel":{"value":"Kept"}}
+		]}}`)
+		Expect(topSongsFromResults(results)).To(Equal([]TopSong{
+			{Title: "Kept"},
+		}))
+	})
+})
+
+var _ = Describe("topSongsSource", func() {
+	It("requires an MBID", func() {
+		s := newTopSongsSource(nil)
+		_, err := s.getTopSongs(context.Background(), TopSongsQuery{ArtistQuery: ArtistQuery{Name: "No MBID"}})
+		Expect(err).To(MatchError(ErrNotFound))
+	})
+})
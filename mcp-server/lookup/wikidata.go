@@ -0,0 +1,54 @@
+package lookup
+
+import (
+	"context"
+	"fmt"
+)
+
+// wikidataDescriptionSource returns the short English description of the
+// artist's Wikidata entity. It is the last resort: always short, but
+// available for many artists that have no Wikipedia article.
+type wikidataDescriptionSource struct {
+	fetcher *Fetcher
+}
+
+func newWikidataDescriptionSource(f *Fetcher) *wikidataDescriptionSource {
+	return &wikidataDescriptionSource{fetcher: f}
+}
+
+func (s *wikidataDescriptionSource) Name() string { return "wikidata-description" }
+
+func (s *wikidataDescriptionSource) License() string { return "CC0 1.0" }
+
+func (s *wikidataDescriptionSource) Lookup(ctx context.Context, q ArtistQuery) (*BioResult, error) {
+	if q.MBID == "" {
+		return nil, ErrNotFound
+	}
+	results, err := s.fetcher.sparql(ctx, wikidataSPARQLEndpoint, wikidataDescriptionQuery(q.MBID))
+	if err != nil {
+		return nil, err
+	}
+	description := results.first("description")
+	if description == "" {
+		return nil, ErrNotFound
+	}
+	return &BioResult{Text: description, URL: results.first("artist")}, nil
+}
+
+// wikidataDescriptionQuery is the SPARQL query fetching the artist's English
+// description, shared with the dry-run planner.
+func wikidataDescriptionQuery(mbid string) string {
+	return fmt.Sprintf(`SELECT ?artist ?description WHERE {
+  ?artist wdt:P434 %q ;
+          schema:description ?description .
+  FILTER (lang(?description) = "en")
+} LIMIT 1`, mbid)
+}
+
+// plan lists the description request a lookup would issue.
+func (s *wikidataDescriptionSource) plan(q ArtistQuery) []PlannedRequest {
+	if q.MBID == "" {
+		return nil
+	}
+	return plannedGet(s.Name(), sparqlRequestURL(wikidataSPARQLEndpoint, wikidataDescriptionQuery(q.MBID)))
+}
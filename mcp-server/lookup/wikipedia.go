@@ -0,0 +1,162 @@
+package lookup
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+const (
+	wikidataSPARQLEndpoint = "https://query.wikidata.org/sparql"
+	wikipediaRESTSummary   = "https://en.wikipedia.org/api/rest_v1/page/summary/"
+)
+
+// wikipediaSource resolves the artist's MBID to a Wikidata entity (via the
+// MusicBrainz artist ID property P434), follows its English Wikipedia
+// sitelink, and returns the page's summary extract. It is the most reliable
+// source, but requires an MBID.
+type wikipediaSource struct {
+	fetcher *Fetcher
+	// store, when set, memoizes MBID→article resolutions across restarts.
+	store Store
+}
+
+func newWikipediaSource(f *Fetcher) *wikipediaSource {
+	return &wikipediaSource{fetcher: f}
+}
+
+func (s *wikipediaSource) Name() string { return "wikipedia" }
+
+func (s *wikipediaSource) License() string { return "CC BY-SA 4.0" }
+
+func (s *wikipediaSource) Lookup(ctx context.Context, q ArtistQuery) (*BioResult, error) {
+	if q.MBID == "" {
+		return nil, ErrNotFound
+	}
+	article, err := s.articleForMBID(ctx, q.MBID)
+	if err != nil {
+		return nil, err
+	}
+	title := titleFromArticleURL(article)
+	if title == "" {
+		return nil, ErrNotFound
+	}
+	result, err := s.summary(ctx, title)
+	if err != nil {
+		return nil, err
+	}
+	if q.IncludeLinks {
+		// Best-effort: a failed link listing should not cost the biography.
+		if links, err := s.articleLinks(ctx, title); err == nil {
+			result.Links = links
+		}
+	}
+	// The summary endpoint redirects non-canonical titles; remember the
+	// canonical article so the next lookup skips the redirect.
+	if s.store != nil && result.URL != "" && result.URL != article {
+		s.store.Set(q.MBID, result.URL)
+	}
+	return result, nil
+}
+
+// articleForMBID finds the English Wikipedia article for an artist MBID.
+func (s *wikipediaSource) articleForMBID(ctx context.Context, mbid string) (string, error) {
+	if s.store != nil {
+		if article, ok := s.store.Get(mbid); ok {
+			return article, nil
+		}
+	}
+	results, err := s.fetcher.sparql(ctx, wikidataSPARQLEndpoint, wikipediaArticleQuery(mbid))
+	if err != nil {
+		return "", err
+	}
+	article := results.first("article")
+	if article == "" {
+		return "", ErrNotFound
+	}
+	if s.store != nil {
+		s.store.Set(mbid, article)
+	}
+	return article, nil
+}
+
+// wikipediaArticleQuery is the SPARQL query resolving an artist MBID to its
+// English Wikipedia article, shared with the dry-run planner.
+func wikipediaArticleQuery(mbid string) string {
+	return fmt.Sprintf(`SELECT ?article WHERE {
+  ?artist wdt:P434 %q .
+  ?article schema:about ?artist ;
+           schema:isPartOf <https://en.wikipedia.org/> .
+} LIMIT 1`, mbid)
+}
+
+// plan lists the article-resolution request a lookup would issue; the summary
+// fetch that follows depends on its result and cannot be previewed.
+func (s *wikipediaSource) plan(q ArtistQuery) []PlannedRequest {
+	if q.MBID == "" {
+		return nil
+	}
+	return plannedGet(s.Name(), sparqlRequestURL(wikidataSPARQLEndpoint, wikipediaArticleQuery(q.MBID)))
+}
+
+func (s *wikipediaSource) summary(ctx context.Context, title string) (*BioResult, error) {
+	var summary struct {
+		Extract     string `json:"extract"`
+		ContentURLs struct {
+			Desktop struct {
+				Page string `json:"page"`
+			} `json:"desktop"`
+		} `json:"content_urls"`
+	}
+	u := wikipediaRESTSummary + url.PathEscape(title)
+	finalURL, err := s.fetcher.getJSONFinal(ctx, u, nil, &summary)
+	if err != nil {
+		return nil, err
+	}
+	if summary.Extract == "" {
+		return nil, ErrNotFound
+	}
+	pageURL := summary.ContentURLs.Desktop.Page
+	if pageURL == "" {
+		// Build the article URL from the post-redirect title, so a
+		// non-canonical lookup still yields the canonical page.
+		pageURL = "https://en.wikipedia.org/wiki/" + url.PathEscape(canonicalTitle(finalURL, title))
+	}
+	return &BioResult{Text: summary.Extract, URL: pageURL}, nil
+}
+
+// canonicalTitle extracts the page title from the final summary-endpoint URL
+// after redirects, falling back to the requested title.
+func canonicalTitle(finalURL, fallback string) string {
+	idx := strings.LastIndex(finalURL, "/")
+	if idx < 0 || idx == len(finalURL)-1 {
+		return fallback
+	}
+	title, err := url.PathUnescape(finalURL[idx+1:])
+	if err != nil || title == "" {
+		return fallback
+	}
+	return title
+}
+
+// titleFromArticleURL extracts the page title from a Wikipedia article URL.
+// The title is percent-decoded exactly once - a double-encoded separator stays
+// encoded and is treated as literal text - and validated, so a hostile
+// Wikidata-provided URL cannot smuggle pipes or control characters into a
+// later titles= parameter.
+func titleFromArticleURL(article string) string {
+	const marker = "/wiki/"
+	idx := strings.LastIndex(article, marker)
+	if idx < 0 {
+		return ""
+	}
+	title, err := url.PathUnescape(article[idx+len(marker):])
+	if err != nil {
+		return ""
+	}
+	if err := validateTitle(title); err != nil {
+		return ""
+	}
+	return title
+}
@@ -0,0 +1,60 @@
+// mcp-server is a small MCP (Model Context Protocol) server exposing artist
+// metadata tools, backed by Wikidata, Wikipedia and DBpedia. It is meant to be
+// run by Navidrome's MCP agent over stdio, either as a native process or as a
+// WASI module. The metadata resolution itself lives in the lookup package;
+// this program only adds the MCP transport, caching and persistence around it.
+//
+// `make mcp-server` builds both artifacts (native and wasip1) reproducibly,
+// with the version stamped via ldflags; the directive below covers the wasm
+// one for go:generate workflows.
+//
+//go:generate env GOOS=wasip1 GOARCH=wasm go build -trimpath -o mcp-server.wasm .
+package main
+
+import (
+	"context"
+	"os"
+
+	"github.com/navidrome/navidrome/mcp-server/lookup"
+)
+
+func main() {
+	cfg := loadConfig()
+	lookup.LogFullBodies = cfg.LogFullBodies
+	lookup.SetUserAgent(cfg.UserAgent)
+	if cfg.RequestLog != "" {
+		lookup.RequestLogMode = cfg.RequestLog
+	}
+	srv := newServer(os.Stdin, os.Stdout)
+	st := newServerStores(cfg)
+	f := lookup.NewFetcher()
+	if cfg.FetchMaxRetries > 0 || cfg.FetchRetryDelay > 0 {
+		f.SetRetryPolicy(cfg.FetchMaxRetries, cfg.FetchRetryDelay)
+	}
+	c := lookup.NewClient(f, st.mbidQid)
+	c.ApplySourceOrders(cfg.SourceOrders)
+	c.SetMBIDMinScore(cfg.MBIDMinScore)
+	c.SetLookupCachePolicy(cfg.LookupCacheSize, cfg.LookupCacheTTL, cfg.LookupCacheMissTTL)
+	if cfg.StartupProbe {
+		go c.ProbeEndpoints(context.Background())
+	}
+	if cfg.StoreCompactInterval > 0 {
+		go st.compactEvery(context.Background(), cfg.StoreCompactInterval)
+	}
+	registerTools(srv, c, newCache(cfg), cfg, st)
+	registerDetailsTool(srv, c)
+	registerAwardsTool(srv, c)
+	registerDiscographyTool(srv, c)
+	registerSortNameTool(srv, c)
+	registerAlbumTool(srv, c)
+	registerSimilarTool(srv, c)
+	registerQuickFactsTool(srv, c)
+	registerThumbnailTool(srv, c)
+	registerImagesTool(srv, c)
+	registerTopSongsTool(srv, c)
+	registerMBIDTool(srv, c)
+	registerServerInfoTool(srv)
+	if err := srv.serve(); err != nil {
+		os.Exit(1)
+	}
+}
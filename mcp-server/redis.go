@@ -0,0 +1,165 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// redisCache caches entries in a shared Redis instance, so multiple Navidrome
+// instances do not re-query upstreams for the same artists. It speaks a
+// minimal subset of RESP (AUTH, GET, SET with EX) to avoid pulling in a
+// client dependency. Any Redis failure degrades to a cache miss.
+type redisCache struct {
+	addr     string
+	password string
+	ttl      time.Duration
+
+	mu   sync.Mutex
+	conn net.Conn
+	rw   *bufio.ReadWriter
+}
+
+const redisTimeout = 2 * time.Second
+
+func newRedisCache(addr, password string, ttl time.Duration) *redisCache {
+	if ttl <= 0 {
+		ttl = defaultCacheTTL
+	}
+	return &redisCache{addr: addr, password: password, ttl: ttl}
+}
+
+func (c *redisCache) get(_ context.Context, key string) (*cacheEntry, bool) {
+	reply, err := c.command("GET", key)
+	if err != nil {
+		c.fail("GET", err)
+		return nil, false
+	}
+	if reply == nil {
+		return nil, false
+	}
+	var entry cacheEntry
+	if err := json.Unmarshal(reply, &entry); err != nil {
+		return nil, false
+	}
+	return &entry, true
+}
+
+func (c *redisCache) set(_ context.Context, key string, entry cacheEntry) {
+	entry.Expires = time.Time{} // Redis owns expiry via EX
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	seconds := strconv.Itoa(int(c.ttl / time.Second))
+	if _, err := c.command("SET", key, string(data), "EX", seconds); err != nil {
+		c.fail("SET", err)
+	}
+}
+
+// fail logs a Redis problem and drops the connection, so the next command
+// reconnects. The cache keeps serving misses meanwhile.
+func (c *redisCache) fail(op string, err error) {
+	log.Printf("redis %s failed, serving cache misses: %v", op, err)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.conn != nil {
+		_ = c.conn.Close()
+		c.conn = nil
+	}
+}
+
+// command sends one command and reads its reply. A nil reply without error is
+// a Redis null (missing key).
+func (c *redisCache) command(args ...string) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.connect(); err != nil {
+		return nil, err
+	}
+	_ = c.conn.SetDeadline(time.Now().Add(redisTimeout))
+	if err := writeRESPCommand(c.rw.Writer, args); err != nil {
+		return nil, err
+	}
+	if err := c.rw.Flush(); err != nil {
+		return nil, err
+	}
+	return readRESPReply(c.rw.Reader)
+}
+
+func (c *redisCache) connect() error {
+	if c.conn != nil {
+		return nil
+	}
+	conn, err := net.DialTimeout("tcp", c.addr, redisTimeout)
+	if err != nil {
+		return err
+	}
+	c.conn = conn
+	c.rw = bufio.NewReadWriter(bufio.NewReader(conn), bufio.NewWriter(conn))
+	if c.password != "" {
+		_ = conn.SetDeadline(time.Now().Add(redisTimeout))
+		if err := writeRESPCommand(c.rw.Writer, []string{"AUTH", c.password}); err == nil {
+			err = c.rw.Flush()
+		}
+		if _, err := readRESPReply(c.rw.Reader); err != nil {
+			_ = conn.Close()
+			c.conn = nil
+			return fmt.Errorf("redis auth: %w", err)
+		}
+	}
+	return nil
+}
+
+func writeRESPCommand(w *bufio.Writer, args []string) error {
+	if _, err := fmt.Fprintf(w, "*%d\r\n", len(args)); err != nil {
+		return err
+	}
+	for _, arg := range args {
+		if _, err := fmt.Fprintf(w, "$%d\r\n%s\r\n", len(arg), arg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readRESPReply reads a single RESP reply, returning nil for nulls.
+func readRESPReply(r *bufio.Reader) ([]byte, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 {
+		return nil, errors.New("short redis reply")
+	}
+	body := line[1 : len(line)-2]
+	switch line[0] {
+	case '+', ':':
+		return []byte(body), nil
+	case '-':
+		return nil, errors.New("redis: " + body)
+	case '$':
+		size, err := strconv.Atoi(body)
+		if err != nil {
+			return nil, err
+		}
+		if size < 0 {
+			return nil, nil // Null bulk string
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		return buf[:size], nil
+	default:
+		return nil, fmt.Errorf("unexpected redis reply type %q", line[0])
+	}
+}
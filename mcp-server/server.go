@@ -0,0 +1,292 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/navidrome/navidrome/mcp-server/lookup"
+)
+
+const protocolVersion = "2024-11-05"
+
+const serverName = "navidrome-mcp-server"
+
+var serverVersion = lookup.Version
+
+// JSON-RPC 2.0 error codes used by the server.
+const (
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+	codeInternalError  = -32603
+)
+
+type jsonRPCMessage struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      *int64          `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  any             `json:"result,omitempty"`
+	Error   *jsonRPCError   `json:"error,omitempty"`
+}
+
+type jsonRPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// toolContent is a single content item in a tool response.
+type toolContent struct {
+	Type string `json:"type"`
+	Text string `json:"text,omitempty"`
+}
+
+type toolResponse struct {
+	Content []toolContent  `json:"content"`
+	IsError bool           `json:"isError,omitempty"`
+	Meta    map[string]any `json:"_meta,omitempty"`
+}
+
+func textResponse(text string) *toolResponse {
+	return &toolResponse{Content: []toolContent{{Type: "text", Text: text}}}
+}
+
+// Error codes carried in the structured error envelope, so hosts can tell a
+// genuine not-found from an upstream failure without matching message strings.
+const (
+	errCodeNotFound = "not_found"
+	errCodeUpstream = "upstream"
+	errCodeTimeout  = "timeout"
+)
+
+// toolEnvelope is the structured payload of an error response:
+// {"ok": false, "error": {"code": ..., "message": ...}}. Hosts decode it
+// instead of sniffing message text; ones that do not know the envelope still
+// see a human-readable JSON message. Successful responses keep their plain
+// payloads, so older hosts and previously cached entries are unaffected.
+type toolEnvelope struct {
+	OK    bool       `json:"ok"`
+	Data  any        `json:"data,omitempty"`
+	Error *toolError `json:"error,omitempty"`
+}
+
+type toolError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// errorResponse builds an error response carrying the structured envelope
+// with the given code.
+func errorResponse(code, format string, args ...any) *toolResponse {
+	data, _ := json.Marshal(toolEnvelope{Error: &toolError{Code: code, Message: fmt.Sprintf(format, args...)}})
+	return &toolResponse{Content: []toolContent{{Type: "text", Text: string(data)}}, IsError: true}
+}
+
+// failureResponse maps a lookup failure to an error response: timeouts get
+// their own code, anything else counts as an upstream failure.
+func failureResponse(err error) *toolResponse {
+	code := errCodeUpstream
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		code = errCodeTimeout
+	}
+	return errorResponse(code, "%s", err)
+}
+
+type toolHandler func(ctx context.Context, args json.RawMessage) (*toolResponse, error)
+
+type tool struct {
+	name        string
+	description string
+	inputSchema map[string]any
+	handler     toolHandler
+}
+
+// resource is a piece of server metadata addressable by URI, per the MCP
+// resources capability.
+type resource struct {
+	uri         string
+	name        string
+	description string
+	mimeType    string
+	read        func(ctx context.Context) ([]byte, error)
+}
+
+// server speaks the MCP stdio protocol: newline-delimited JSON-RPC 2.0 frames
+// on stdin/stdout. Logging goes to stderr, keeping stdout clean for frames.
+type server struct {
+	in        io.Reader
+	out       io.Writer
+	outMu     sync.Mutex
+	tools     []tool
+	resources []resource
+}
+
+func newServer(in io.Reader, out io.Writer) *server {
+	return &server{in: in, out: out}
+}
+
+func (s *server) registerTool(name, description string, inputSchema map[string]any, handler toolHandler) {
+	s.tools = append(s.tools, tool{name: name, description: description, inputSchema: inputSchema, handler: handler})
+}
+
+func (s *server) registerResource(r resource) {
+	s.resources = append(s.resources, r)
+}
+
+// serve processes requests until stdin is closed.
+func (s *server) serve() error {
+	scanner := bufio.NewScanner(s.in)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var msg jsonRPCMessage
+		if err := json.Unmarshal(line, &msg); err != nil {
+			log.Printf("discarding malformed frame: %v", err)
+			continue
+		}
+		s.dispatch(&msg)
+	}
+	return scanner.Err()
+}
+
+func (s *server) dispatch(msg *jsonRPCMessage) {
+	switch msg.Method {
+	case "initialize":
+		s.reply(msg, map[string]any{
+			"protocolVersion": protocolVersion,
+			"serverInfo":      map[string]string{"name": serverName, "version": serverVersion},
+			"capabilities":    map[string]any{"tools": map[string]any{}, "resources": map[string]any{}},
+		}, nil)
+	case "notifications/initialized":
+		// Nothing to do
+	case "tools/list":
+		var list []map[string]any
+		for _, t := range s.tools {
+			list = append(list, map[string]any{
+				"name":        t.name,
+				"description": t.description,
+				"inputSchema": t.inputSchema,
+			})
+		}
+		s.reply(msg, map[string]any{"tools": list}, nil)
+	case "tools/call":
+		s.callTool(msg)
+	case "resources/list":
+		var list []map[string]any
+		for _, r := range s.resources {
+			list = append(list, map[string]any{
+				"uri":         r.uri,
+				"name":        r.name,
+				"description": r.description,
+				"mimeType":    r.mimeType,
+			})
+		}
+		s.reply(msg, map[string]any{"resources": list}, nil)
+	case "resources/read":
+		s.readResource(msg)
+	default:
+		if msg.ID != nil {
+			s.reply(msg, nil, &jsonRPCError{Code: codeMethodNotFound, Message: "method not found: " + msg.Method})
+		}
+	}
+}
+
+func (s *server) callTool(msg *jsonRPCMessage) {
+	var params struct {
+		Name      string          `json:"name"`
+		Arguments json.RawMessage `json:"arguments"`
+	}
+	if err := json.Unmarshal(msg.Params, &params); err != nil {
+		s.reply(msg, nil, &jsonRPCError{Code: codeInvalidParams, Message: err.Error()})
+		return
+	}
+	for _, t := range s.tools {
+		if t.name != params.Name {
+			continue
+		}
+		ctx := context.Background()
+		if timeout := callTimeout(params.Arguments); timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+		resp, err := t.handler(ctx, params.Arguments)
+		if err != nil {
+			s.reply(msg, nil, &jsonRPCError{Code: codeInternalError, Message: err.Error()})
+			return
+		}
+		s.reply(msg, resp, nil)
+		return
+	}
+	s.reply(msg, nil, &jsonRPCError{Code: codeInvalidParams, Message: "unknown tool: " + params.Name})
+}
+
+// callTimeout extracts the caller's deadline hint from the tool arguments.
+// The stdio protocol carries no contexts, so hosts pass what is left of
+// theirs as timeoutMs; zero or absent means no limit, and upstream fetches
+// abort once it elapses instead of running to completion.
+func callTimeout(args json.RawMessage) time.Duration {
+	var hint struct {
+		TimeoutMs int64 `json:"timeoutMs"`
+	}
+	if err := json.Unmarshal(args, &hint); err != nil || hint.TimeoutMs <= 0 {
+		return 0
+	}
+	return time.Duration(hint.TimeoutMs) * time.Millisecond
+}
+
+func (s *server) readResource(msg *jsonRPCMessage) {
+	var params struct {
+		URI string `json:"uri"`
+	}
+	if err := json.Unmarshal(msg.Params, &params); err != nil {
+		s.reply(msg, nil, &jsonRPCError{Code: codeInvalidParams, Message: err.Error()})
+		return
+	}
+	for _, r := range s.resources {
+		if r.uri != params.URI {
+			continue
+		}
+		data, err := r.read(context.Background())
+		if err != nil {
+			s.reply(msg, nil, &jsonRPCError{Code: codeInternalError, Message: err.Error()})
+			return
+		}
+		s.reply(msg, map[string]any{
+			"contents": []map[string]any{{"uri": r.uri, "mimeType": r.mimeType, "text": string(data)}},
+		}, nil)
+		return
+	}
+	s.reply(msg, nil, &jsonRPCError{Code: codeInvalidParams, Message: "unknown resource: " + params.URI})
+}
+
+func (s *server) reply(req *jsonRPCMessage, result any, rpcErr *jsonRPCError) {
+	if req.ID == nil {
+		return // Notifications get no response
+	}
+	resp := jsonRPCMessage{JSONRPC: "2.0", ID: req.ID, Result: result, Error: rpcErr}
+	data, err := json.Marshal(resp)
+	if err != nil {
+		log.Printf("could not marshal response: %v", err)
+		return
+	}
+	s.outMu.Lock()
+	defer s.outMu.Unlock()
+	_, _ = s.out.Write(append(data, '\n'))
+}
+
+func init() {
+	// stdout carries the protocol; all logging must go to stderr
+	log.SetOutput(os.Stderr)
+	log.SetPrefix("mcp-server: ")
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("tool call timeouts", func() {
+	type probe struct {
+		hasDeadline bool
+		err         error
+	}
+
+	var out bytes.Buffer
+	var srv *server
+	var probes chan probe
+
+	BeforeEach(func() {
+		out.Reset()
+		srv = newServer(strings.NewReader(""), &out)
+		probes = make(chan probe, 1)
+		// The probe tool stands in for a handler's fetcher: it reports whether
+		// its context carries a deadline and waits briefly to see it fire.
+		srv.registerTool("probe", "records its context", map[string]any{},
+			func(ctx context.Context, args json.RawMessage) (*toolResponse, error) {
+				p := probe{}
+				_, p.hasDeadline = ctx.Deadline()
+				select {
+				case <-ctx.Done():
+					p.err = ctx.Err()
+				case <-time.After(250 * time.Millisecond):
+				}
+				probes <- p
+				return textResponse("ok"), nil
+			})
+	})
+
+	call := func(args string) {
+		id := int64(1)
+		srv.dispatch(&jsonRPCMessage{
+			JSONRPC: "2.0",
+			ID:      &id,
+			Method:  "tools/call",
+			Params:  json.RawMessage(`{"name":"probe","arguments":` + args + `}`),
+		})
+	}
+
+	It("cancels the handler context once the timeoutMs hint elapses", func() {
+		call(`{"name":"The Artist","timeoutMs":50}`)
+		p := <-probes
+		Expect(p.hasDeadline).To(BeTrue())
+		Expect(p.err).To(MatchError(context.DeadlineExceeded))
+	})
+
+	It("leaves the context unbounded without a hint", func() {
+		call(`{"name":"The Artist"}`)
+		p := <-probes
+		Expect(p.hasDeadline).To(BeFalse())
+		Expect(p.err).ToNot(HaveOccurred())
+	})
+
+	It("ignores zero and negative hints", func() {
+		call(`{"name":"The Artist","timeoutMs":0}`)
+		p := <-probes
+		Expect(p.hasDeadline).To(BeFalse())
+	})
+})
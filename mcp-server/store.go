@@ -0,0 +1,257 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Defaults for the persistent stores, overridable via MCP_STORE_MAX_ENTRIES
+// and MCP_STORE_TTL.
+const (
+	defaultStoreMaxEntries = 100_000
+	defaultStoreTTL        = 30 * 24 * time.Hour
+)
+
+// minEvictionAge protects recently used entries: eviction never removes an
+// entry touched in the last 24 hours, even when the store is over its cap.
+const minEvictionAge = 24 * time.Hour
+
+type storeEntry struct {
+	Value   string    `json:"value,omitempty"`
+	Expires time.Time `json:"expires"`
+	Touched time.Time `json:"touched"`
+}
+
+// boundedStore is a small persistent key/value map with a size cap, per-entry
+// TTLs and LRU eviction. It backs the MBID lookup memoization and the
+// persistent not-found tracking, which would otherwise grow unbounded for huge
+// libraries with churn. A load/compaction pass on startup drops expired rows.
+type boundedStore struct {
+	path       string
+	maxEntries int
+	ttl        time.Duration
+	now        func() time.Time // Injectable for tests
+
+	mu      sync.Mutex
+	entries map[string]storeEntry
+}
+
+func newBoundedStore(path string, maxEntries int, ttl time.Duration) *boundedStore {
+	if maxEntries <= 0 {
+		maxEntries = defaultStoreMaxEntries
+	}
+	if ttl <= 0 {
+		ttl = defaultStoreTTL
+	}
+	s := &boundedStore{
+		path:       path,
+		maxEntries: maxEntries,
+		ttl:        ttl,
+		now:        time.Now,
+		entries:    map[string]storeEntry{},
+	}
+	s.load()
+	return s
+}
+
+// load reads the store from disk and compacts it, dropping expired rows. A
+// missing file just means an empty store; a corrupt one (e.g. from a crash
+// mid-write) is quarantined and a clean file is written in its place, so one
+// bad file never disables the server. Decoding the JSON is the integrity
+// check: a truncated or garbled file cannot unmarshal.
+func (s *boundedStore) load() {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return
+	}
+	if err := json.Unmarshal(data, &s.entries); err != nil {
+		log.Printf("store %s is corrupt, starting empty: %v", s.path, err)
+		s.entries = map[string]storeEntry{}
+		s.quarantine()
+		s.save()
+		return
+	}
+	before := len(s.entries)
+	for key, entry := range s.entries {
+		if s.now().After(entry.Expires) {
+			delete(s.entries, key)
+		}
+	}
+	if dropped := before - len(s.entries); dropped > 0 {
+		log.Printf("store %s: dropped %d expired entries on startup", s.path, dropped)
+		s.save()
+	}
+}
+
+// Get returns the stored value for key, refreshing its LRU position. Together
+// with Set it satisfies lookup.Store.
+func (s *boundedStore) Get(key string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.entries[key]
+	if !ok || s.now().After(entry.Expires) {
+		delete(s.entries, key)
+		return "", false
+	}
+	entry.Touched = s.now()
+	s.entries[key] = entry
+	return entry.Value, true
+}
+
+func (s *boundedStore) Set(key, value string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	s.entries[key] = storeEntry{Value: value, Expires: now.Add(s.ttl), Touched: now}
+	s.evict()
+	s.save()
+}
+
+func (s *boundedStore) size() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.entries)
+}
+
+// evict removes least-recently-touched entries until the store is back under
+// its cap, skipping anything touched within minEvictionAge. Called with the
+// lock held.
+func (s *boundedStore) evict() {
+	over := len(s.entries) - s.maxEntries
+	if over <= 0 {
+		return
+	}
+	type aged struct {
+		key     string
+		touched time.Time
+	}
+	cutoff := s.now().Add(-minEvictionAge)
+	var candidates []aged
+	for key, entry := range s.entries {
+		if entry.Touched.Before(cutoff) {
+			candidates = append(candidates, aged{key: key, touched: entry.Touched})
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].touched.Before(candidates[j].touched) })
+	for _, c := range candidates {
+		if over == 0 {
+			return
+		}
+		delete(s.entries, c.key)
+		over--
+	}
+}
+
+// quarantine moves an unreadable store file aside (keeping it for inspection)
+// so the store can recreate a clean file at the same path.
+func (s *boundedStore) quarantine() {
+	quarantined := fmt.Sprintf("%s.corrupt-%d", s.path, s.now().Unix())
+	if err := os.Rename(s.path, quarantined); err != nil {
+		log.Printf("cannot quarantine corrupt store %s: %v", s.path, err)
+		return
+	}
+	log.Printf("store %s: quarantined corrupt file to %s", s.path, quarantined)
+}
+
+// compact rewrites the store file, dropping expired entries, and logs the
+// progress and reclaimed bytes. It runs periodically so tombstones from large
+// libraries do not accumulate between restarts.
+func (s *boundedStore) compact() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var sizeBefore int64
+	if info, err := os.Stat(s.path); err == nil {
+		sizeBefore = info.Size()
+	}
+	before := len(s.entries)
+	for key, entry := range s.entries {
+		if s.now().After(entry.Expires) {
+			delete(s.entries, key)
+		}
+	}
+	dropped := before - len(s.entries)
+	if dropped == 0 {
+		return
+	}
+	s.save()
+	var reclaimed int64
+	if info, err := os.Stat(s.path); err == nil {
+		reclaimed = sizeBefore - info.Size()
+	}
+	log.Printf("store %s: compacted %d of %d entries, reclaimed %d bytes", s.path, dropped, before, reclaimed)
+}
+
+// save writes the store to disk. Called with the lock held; failures are only
+// logged, the store keeps working in memory.
+func (s *boundedStore) save() {
+	data, err := json.Marshal(s.entries)
+	if err == nil {
+		err = os.WriteFile(s.path, data, 0600)
+	}
+	if err != nil {
+		log.Printf("cannot persist store %s: %v", s.path, err)
+	}
+}
+
+// serverStores groups the persistent stores, so their sizes can be exposed as
+// a resource.
+type serverStores struct {
+	mbidQid  *boundedStore
+	notFound *boundedStore
+}
+
+func newServerStores(cfg serverConfig) *serverStores {
+	dir := cfg.CacheDir
+	if dir == "" {
+		dir = filepath.Join(os.TempDir(), "navidrome-mcp-cache")
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		log.Printf("cannot create store dir %s: %v", dir, err)
+	}
+	return &serverStores{
+		mbidQid:  newBoundedStore(filepath.Join(dir, "mbid-qid.json"), cfg.StoreMaxEntries, cfg.StoreTTL),
+		notFound: newBoundedStore(filepath.Join(dir, "not-found.json"), cfg.StoreMaxEntries, cfg.StoreTTL),
+	}
+}
+
+// compactEvery runs a compaction pass over all stores on the given interval,
+// until the context is canceled.
+func (st *serverStores) compactEvery(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			st.mbidQid.compact()
+			st.notFound.compact()
+		}
+	}
+}
+
+// storeStatsResourceURI identifies the resource reporting the current sizes of
+// the persistent stores.
+const storeStatsResourceURI = "mcp://server/store-stats"
+
+func storeStatsResource(st *serverStores) resource {
+	return resource{
+		uri:         storeStatsResourceURI,
+		name:        "Persistent store sizes",
+		description: "Current entry counts of the MBID and not-found persistent stores",
+		mimeType:    "application/json",
+		read: func(_ context.Context) ([]byte, error) {
+			return json.Marshal(map[string]int{
+				"mbidQid":  st.mbidQid.size(),
+				"notFound": st.notFound.size(),
+			})
+		},
+	}
+}
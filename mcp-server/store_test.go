@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("boundedStore", func() {
+	var store *boundedStore
+	var now time.Time
+	var path string
+
+	BeforeEach(func() {
+		now = time.Date(2024, 1, 10, 12, 0, 0, 0, time.UTC)
+		path = filepath.Join(GinkgoT().TempDir(), "store.json")
+		store = newBoundedStore(path, 5, 7*24*time.Hour)
+		store.now = func() time.Time { return now }
+	})
+
+	It("stores and retrieves values", func() {
+		store.Set("mbid-1", "Q1")
+		value, ok := store.Get("mbid-1")
+		Expect(ok).To(BeTrue())
+		Expect(value).To(Equal("Q1"))
+	})
+
+	It("expires entries after their TTL", func() {
+		store.Set("mbid-1", "Q1")
+		now = now.Add(8 * 24 * time.Hour)
+		_, ok := store.Get("mbid-1")
+		Expect(ok).To(BeFalse())
+		Expect(store.size()).To(BeZero())
+	})
+
+	It("evicts the least recently touched entries past the cap", func() {
+		for i := 0; i < 5; i++ {
+			store.Set(fmt.Sprintf("mbid-%d", i), "Q")
+			now = now.Add(time.Hour)
+		}
+		// Refresh mbid-0 so mbid-1 becomes the oldest, then age everything
+		// past the eviction protection window.
+		_, _ = store.Get("mbid-0")
+		now = now.Add(2 * minEvictionAge)
+		store.Set("mbid-new", "Q")
+
+		Expect(store.size()).To(Equal(5))
+		_, ok := store.Get("mbid-1")
+		Expect(ok).To(BeFalse())
+		for _, key := range []string{"mbid-0", "mbid-2", "mbid-3", "mbid-4", "mbid-new"} {
+			_, ok := store.Get(key)
+			Expect(ok).To(BeTrue(), "expected %s to survive", key)
+		}
+	})
+
+	It("never evicts entries touched in the last 24 hours", func() {
+		for i := 0; i < 7; i++ {
+			store.Set(fmt.Sprintf("mbid-%d", i), "Q")
+		}
+		// Everything was touched just now, so nothing is evictable.
+		Expect(store.size()).To(Equal(7))
+	})
+
+	It("drops expired rows on startup compaction", func() {
+		// The reloaded store uses the real clock, so entries are written with
+		// expirations relative to it: one already past, one still valid.
+		now = time.Now().Add(-8 * 24 * time.Hour)
+		store.Set("stale", "Q1")
+		now = time.Now()
+		store.Set("fresh", "Q2")
+
+		reloaded := newBoundedStore(path, 5, 7*24*time.Hour)
+		Expect(reloaded.size()).To(Equal(1))
+		_, ok := reloaded.Get("fresh")
+		Expect(ok).To(BeTrue())
+	})
+
+	It("persists entries across restarts", func() {
+		now = time.Now()
+		store.Set("mbid-1", "Q1")
+		reloaded := newBoundedStore(path, 5, 7*24*time.Hour)
+		value, ok := reloaded.Get("mbid-1")
+		Expect(ok).To(BeTrue())
+		Expect(value).To(Equal("Q1"))
+	})
+
+	It("quarantines a corrupt file and recreates a clean one", func() {
+		Expect(os.WriteFile(path, []byte(`{"mbid-1":{"value":"Q1","exp`), 0o600)).To(Succeed())
+
+		reloaded := newBoundedStore(path, 5, 7*24*time.Hour)
+		Expect(reloaded.size()).To(BeZero())
+
+		// The bad bytes are kept aside for inspection...
+		quarantined, err := filepath.Glob(path + ".corrupt-*")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(quarantined).To(HaveLen(1))
+
+		// ...and the store file itself is valid again.
+		data, err := os.ReadFile(path)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(data)).To(Equal("{}"))
+
+		reloaded.Set("mbid-1", "Q1")
+		value, ok := newBoundedStore(path, 5, 7*24*time.Hour).Get("mbid-1")
+		Expect(ok).To(BeTrue())
+		Expect(value).To(Equal("Q1"))
+	})
+
+	It("reclaims space for expired entries on compaction", func() {
+		now = time.Now()
+		store.Set("fresh", "Q1")
+		for i := 0; i < 3; i++ {
+			store.Set(fmt.Sprintf("stale-%d", i), "Q")
+		}
+		sizeBefore := fileSize(path)
+
+		// Age only the stale entries past their TTL.
+		now = now.Add(8 * 24 * time.Hour)
+		entry := store.entries["fresh"]
+		entry.Expires = now.Add(time.Hour)
+		store.entries["fresh"] = entry
+		store.compact()
+
+		Expect(store.size()).To(Equal(1))
+		Expect(fileSize(path)).To(BeNumerically("<", sizeBefore))
+		_, ok := store.Get("fresh")
+		Expect(ok).To(BeTrue())
+	})
+})
+
+func fileSize(path string) int64 {
+	info, err := os.Stat(path)
+	Expect(err).ToNot(HaveOccurred())
+	return info.Size()
+}
@@ -0,0 +1,427 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+
+	"github.com/navidrome/navidrome/mcp-server/lookup"
+)
+
+// artistArgsSchema is the input schema shared by tools that take an artist.
+var artistArgsSchema = map[string]any{
+	"type": "object",
+	"properties": map[string]any{
+		"id":                map[string]any{"type": "string", "description": "Navidrome artist ID"},
+		"name":              map[string]any{"type": "string", "description": "Artist name"},
+		"mbid":              map[string]any{"type": "string", "description": "MusicBrainz artist ID"},
+		"validate":          map[string]any{"type": "boolean", "description": "Check candidate URLs with a HEAD request before returning them"},
+		"includeLinks":      map[string]any{"type": "boolean", "description": "Also return the bio's music-related outbound article links"},
+		"dryRun":            map[string]any{"type": "boolean", "description": "Preview the requests the lookup would issue, as JSON, without any network access"},
+		"timeoutMs":         map[string]any{"type": "integer", "description": "Abort the lookup after this many milliseconds (the caller's remaining deadline)"},
+		"searchUrlTemplate": map[string]any{"type": "string", "description": "Search URL template for the URL fallback, with a {query} placeholder (https only)"},
+	},
+	"required": []string{"name"},
+}
+
+// similarArgsSchema extends the artist arguments with a result limit.
+var similarArgsSchema = map[string]any{
+	"type": "object",
+	"properties": map[string]any{
+		"id":    map[string]any{"type": "string", "description": "Navidrome artist ID"},
+		"name":  map[string]any{"type": "string", "description": "Artist name"},
+		"mbid":  map[string]any{"type": "string", "description": "MusicBrainz artist ID"},
+		"limit": map[string]any{"type": "integer", "description": "Maximum number of artists to return"},
+	},
+	"required": []string{"name"},
+}
+
+// topSongsArgsSchema extends the artist arguments with a result count.
+var topSongsArgsSchema = map[string]any{
+	"type": "object",
+	"properties": map[string]any{
+		"id":    map[string]any{"type": "string", "description": "Navidrome artist ID"},
+		"name":  map[string]any{"type": "string", "description": "Artist name"},
+		"mbid":  map[string]any{"type": "string", "description": "MusicBrainz artist ID"},
+		"count": map[string]any{"type": "integer", "description": "Maximum number of songs to return"},
+	},
+	"required": []string{"name"},
+}
+
+// albumArgsSchema is the input schema of the album tool.
+var albumArgsSchema = map[string]any{
+	"type": "object",
+	"properties": map[string]any{
+		"album":  map[string]any{"type": "string", "description": "Album title"},
+		"artist": map[string]any{"type": "string", "description": "Artist name"},
+		"mbid":   map[string]any{"type": "string", "description": "MusicBrainz release-group ID"},
+	},
+	"required": []string{"album"},
+}
+
+// registerTools wires the biography and URL tools to the lookup client and the
+// configured result cache.
+func registerTools(srv *server, c *lookup.Client, cache resultCache, cfg serverConfig, st *serverStores) {
+	var notFound *boundedStore
+	if st != nil {
+		notFound = st.notFound
+	}
+	srv.registerTool("get_artist_biography", "Get a biography for an artist", artistArgsSchema,
+		artistTool("get_artist_biography", cache, notFound, c.GetBiography, c.PlanBiography, func(r *lookup.BioResult) string {
+			return lookup.CleanBio(r.Text, !cfg.DisableBioCleanup)
+		}))
+	srv.registerTool("get_artist_url", "Get the canonical URL for an artist", artistArgsSchema,
+		artistTool("get_artist_url", cache, notFound, c.GetURL, c.PlanURL, func(r *lookup.BioResult) string {
+			return r.URL
+		}))
+	srv.registerResource(attributionsResource(c))
+	srv.registerResource(cacheKeysResource())
+	if st != nil {
+		srv.registerResource(storeStatsResource(st))
+	}
+}
+
+// artistTool adapts a lookup to a tool handler: it extracts the text via
+// render, attaches the attribution metadata, caches the rendered result
+// (including not-found, to avoid hammering upstreams), and translates lookup
+// errors into coded error responses instead of protocol errors.
+// Not-found artists are additionally tracked in the persistent store,
+// surviving restarts.
+func artistTool(name string, cache resultCache, notFound *boundedStore, find func(ctx context.Context, q lookup.ArtistQuery) (*lookup.BioResult, error), plan func(q lookup.ArtistQuery) []lookup.PlannedRequest, render func(*lookup.BioResult) string) toolHandler {
+	return func(ctx context.Context, args json.RawMessage) (*toolResponse, error) {
+		var q lookup.ArtistQuery
+		if err := json.Unmarshal(args, &q); err != nil {
+			return nil, err
+		}
+		if q.DryRun {
+			// Preview only: list the requests the lookup would issue, in
+			// pipeline order, without calling the Fetcher or any cache.
+			planned := plan(q)
+			if planned == nil {
+				planned = []lookup.PlannedRequest{}
+			}
+			return jsonResponse(map[string]any{"dryRun": true, "requests": planned})
+		}
+		key := cacheKey(name, q)
+		if q.IncludeLinks {
+			// The cache stores only the rendered text; serving a link request
+			// from it would silently drop the links.
+			return lookupResponse(ctx, q, find, render)
+		}
+		if entry, ok := cache.get(ctx, key); ok {
+			if !entry.matchesQuery(q) {
+				// The library's tags changed since the entry was fetched (an MBID
+				// correction, usually); serving it would be the wrong artist.
+				log.Printf("cache entry for %q was fetched under a different identity, refetching", q.Name)
+			} else {
+				if entry.NotFound {
+					return errorResponse(errCodeNotFound, "no results for %q", q.Name), nil
+				}
+				resp := textResponse(entry.Value)
+				if entry.Shared {
+					resp.Meta = map[string]any{"sharedContent": true}
+				}
+				return resp, nil
+			}
+		}
+		if notFound != nil {
+			if _, ok := notFound.Get(key); ok {
+				return errorResponse(errCodeNotFound, "no results for %q", q.Name), nil
+			}
+		}
+		result, err := find(ctx, q)
+		if errors.Is(err, lookup.ErrNotFound) {
+			entry := cacheEntry{NotFound: true}
+			entry.stamp(q)
+			cache.set(ctx, key, entry)
+			if notFound != nil {
+				notFound.Set(key, "")
+			}
+			return errorResponse(errCodeNotFound, "no results for %q", q.Name), nil
+		}
+		if err != nil {
+			// Upstream failures are tool results, not protocol errors: the
+			// envelope's code tells the host whether a retry is worthwhile.
+			return failureResponse(err), nil
+		}
+		text := render(result)
+		entry := cacheEntry{Value: text}
+		entry.stamp(q)
+		cache.set(ctx, key, entry)
+		resp := textResponse(text)
+		resp.Meta = map[string]any{"attribution": attributionFor(result)}
+		if result.Validated {
+			resp.Meta["validated"] = true
+		}
+		if result.ResolvedMBID != "" {
+			// The query had no MBID; hand the resolved one back so Navidrome
+			// can persist it.
+			resp.Meta["resolvedMbid"] = result.ResolvedMBID
+		}
+		if dc, ok := cache.(*dedupCache); ok && dc.sharedContent(ctx, key) {
+			resp.Meta["sharedContent"] = true
+		}
+		return resp, nil
+	}
+}
+
+// lookupResponse runs a lookup without touching the cache, attaching the
+// attribution and any structured links to the response.
+func lookupResponse(ctx context.Context, q lookup.ArtistQuery, find func(ctx context.Context, q lookup.ArtistQuery) (*lookup.BioResult, error), render func(*lookup.BioResult) string) (*toolResponse, error) {
+	result, err := find(ctx, q)
+	if errors.Is(err, lookup.ErrNotFound) {
+		return errorResponse(errCodeNotFound, "no results for %q", q.Name), nil
+	}
+	if err != nil {
+		return failureResponse(err), nil
+	}
+	resp := textResponse(render(result))
+	resp.Meta = map[string]any{"attribution": attributionFor(result)}
+	if result.Validated {
+		resp.Meta["validated"] = true
+	}
+	if result.ResolvedMBID != "" {
+		resp.Meta["resolvedMbid"] = result.ResolvedMBID
+	}
+	if len(result.Links) > 0 {
+		resp.Meta["links"] = result.Links
+	}
+	return resp, nil
+}
+
+// registerDetailsTool exposes get_artist_details, returning the structured
+// details as a JSON text content item.
+func registerDetailsTool(srv *server, c *lookup.Client) {
+	srv.registerTool("get_artist_details", "Get structured details (e.g. instruments played) for an artist",
+		artistArgsSchema, func(ctx context.Context, args json.RawMessage) (*toolResponse, error) {
+			var q lookup.ArtistQuery
+			if err := json.Unmarshal(args, &q); err != nil {
+				return nil, err
+			}
+			details, err := c.GetDetails(ctx, q)
+			if err != nil {
+				return nil, err
+			}
+			return jsonResponse(details)
+		})
+}
+
+// registerAwardsTool exposes get_artist_awards, returning the award list as a
+// JSON text content item. No awards is a successful, empty result.
+func registerAwardsTool(srv *server, c *lookup.Client) {
+	srv.registerTool("get_artist_awards", "Get awards and notable achievements for an artist",
+		artistArgsSchema, func(ctx context.Context, args json.RawMessage) (*toolResponse, error) {
+			var q lookup.ArtistQuery
+			if err := json.Unmarshal(args, &q); err != nil {
+				return nil, err
+			}
+			awards, err := c.GetAwards(ctx, q)
+			if err != nil {
+				return nil, err
+			}
+			return jsonResponse(map[string]any{"awards": awards})
+		})
+}
+
+// registerDiscographyTool exposes get_artist_discography, returning the
+// release-group list as a JSON text content item.
+func registerDiscographyTool(srv *server, c *lookup.Client) {
+	srv.registerTool("get_artist_discography", "Get the list of releases (albums, EPs, singles) for an artist",
+		artistArgsSchema, func(ctx context.Context, args json.RawMessage) (*toolResponse, error) {
+			var q lookup.ArtistQuery
+			if err := json.Unmarshal(args, &q); err != nil {
+				return nil, err
+			}
+			entries, err := c.GetDiscography(ctx, q)
+			if errors.Is(err, lookup.ErrNotFound) {
+				return errorResponse(errCodeNotFound, "no results for %q", q.Name), nil
+			}
+			if err != nil {
+				return nil, err
+			}
+			return jsonResponse(map[string]any{"discography": entries})
+		})
+}
+
+// registerSortNameTool exposes get_artist_sortname, returning the sort name as
+// a plain text content item.
+func registerSortNameTool(srv *server, c *lookup.Client) {
+	srv.registerTool("get_artist_sortname", "Get the sort name (e.g. \"Beatles, The\") for an artist",
+		artistArgsSchema, func(ctx context.Context, args json.RawMessage) (*toolResponse, error) {
+			var q lookup.ArtistQuery
+			if err := json.Unmarshal(args, &q); err != nil {
+				return nil, err
+			}
+			sortName, err := c.GetSortName(ctx, q)
+			if err != nil {
+				return errorResponse(errCodeNotFound, "no results for %q", q.Name), nil
+			}
+			return textResponse(sortName), nil
+		})
+}
+
+// registerAlbumTool exposes get_album_info, returning the structured info as a
+// JSON text content item.
+func registerAlbumTool(srv *server, c *lookup.Client) {
+	srv.registerTool("get_album_info", "Get a description for an album",
+		albumArgsSchema, func(ctx context.Context, args json.RawMessage) (*toolResponse, error) {
+			var q lookup.AlbumQuery
+			if err := json.Unmarshal(args, &q); err != nil {
+				return nil, err
+			}
+			info, err := c.GetAlbumInfo(ctx, q)
+			if errors.Is(err, lookup.ErrNotFound) {
+				return errorResponse(errCodeNotFound, "no results for %q", q.Album), nil
+			}
+			if err != nil {
+				return nil, err
+			}
+			return jsonResponse(info)
+		})
+}
+
+// registerSimilarTool exposes get_similar_artists, returning the related
+// artists as a JSON array with MBIDs populated.
+func registerSimilarTool(srv *server, c *lookup.Client) {
+	srv.registerTool("get_similar_artists", "Get artists related to an artist, with MusicBrainz IDs",
+		similarArgsSchema, func(ctx context.Context, args json.RawMessage) (*toolResponse, error) {
+			var q lookup.SimilarQuery
+			if err := json.Unmarshal(args, &q); err != nil {
+				return nil, err
+			}
+			similar, err := c.GetSimilar(ctx, q)
+			if errors.Is(err, lookup.ErrNotFound) {
+				return errorResponse(errCodeNotFound, "no results for %q", q.Name), nil
+			}
+			if err != nil {
+				return nil, err
+			}
+			return jsonResponse(similar)
+		})
+}
+
+// registerTopSongsTool exposes get_artist_top_songs, returning the artist's
+// best-known songs as a JSON array of {title, mbid} entries.
+func registerTopSongsTool(srv *server, c *lookup.Client) {
+	srv.registerTool("get_artist_top_songs", "Get an artist's best-known songs, with MusicBrainz recording IDs where available",
+		topSongsArgsSchema, func(ctx context.Context, args json.RawMessage) (*toolResponse, error) {
+			var q lookup.TopSongsQuery
+			if err := json.Unmarshal(args, &q); err != nil {
+				return nil, err
+			}
+			songs, err := c.GetTopSongs(ctx, q)
+			if errors.Is(err, lookup.ErrNotFound) {
+				return errorResponse(errCodeNotFound, "no results for %q", q.Name), nil
+			}
+			if err != nil {
+				return nil, err
+			}
+			return jsonResponse(songs)
+		})
+}
+
+// registerQuickFactsTool exposes get_artist_quick_facts, returning the bundle
+// as a JSON text content item.
+func registerQuickFactsTool(srv *server, c *lookup.Client) {
+	srv.registerTool("get_artist_quick_facts", "Get a compact bundle of facts (tagline, bio, thumbnail, genres, origin, URL) for an artist in one call",
+		artistArgsSchema, func(ctx context.Context, args json.RawMessage) (*toolResponse, error) {
+			var q lookup.ArtistQuery
+			if err := json.Unmarshal(args, &q); err != nil {
+				return nil, err
+			}
+			facts, err := c.GetQuickFacts(ctx, q)
+			if errors.Is(err, lookup.ErrNotFound) {
+				return errorResponse(errCodeNotFound, "no results for %q", q.Name), nil
+			}
+			if err != nil {
+				return nil, err
+			}
+			return jsonResponse(facts)
+		})
+}
+
+// registerThumbnailTool exposes get_artist_thumbnail, returning a single small
+// image URL from the fastest available source.
+func registerThumbnailTool(srv *server, c *lookup.Client) {
+	srv.registerTool("get_artist_thumbnail", "Get a small thumbnail image URL for an artist",
+		artistArgsSchema, func(ctx context.Context, args json.RawMessage) (*toolResponse, error) {
+			var q lookup.ArtistQuery
+			if err := json.Unmarshal(args, &q); err != nil {
+				return nil, err
+			}
+			url, err := c.GetThumbnail(ctx, q)
+			if errors.Is(err, lookup.ErrNotFound) {
+				return errorResponse(errCodeNotFound, "no results for %q", q.Name), nil
+			}
+			if err != nil {
+				return nil, err
+			}
+			return textResponse(url), nil
+		})
+}
+
+// registerImagesTool exposes get_artist_images, returning the artist's image
+// candidates as a JSON array of {url, size} entries, largest first.
+func registerImagesTool(srv *server, c *lookup.Client) {
+	srv.registerTool("get_artist_images", "Get artist image URLs in multiple sizes, largest first",
+		artistArgsSchema, func(ctx context.Context, args json.RawMessage) (*toolResponse, error) {
+			var q lookup.ArtistQuery
+			if err := json.Unmarshal(args, &q); err != nil {
+				return nil, err
+			}
+			images, err := c.GetImages(ctx, q)
+			if errors.Is(err, lookup.ErrNotFound) {
+				return errorResponse(errCodeNotFound, "no results for %q", q.Name), nil
+			}
+			if err != nil {
+				return nil, err
+			}
+			return jsonResponse(images)
+		})
+}
+
+// registerMBIDTool exposes get_artist_mbid, resolving an artist name to its
+// MusicBrainz ID through the search API, so Navidrome can backfill MBIDs for
+// artists imported from untagged files.
+func registerMBIDTool(srv *server, c *lookup.Client) {
+	srv.registerTool("get_artist_mbid", "Get the MusicBrainz ID for an artist, resolved by name",
+		artistArgsSchema, func(ctx context.Context, args json.RawMessage) (*toolResponse, error) {
+			var q lookup.ArtistQuery
+			if err := json.Unmarshal(args, &q); err != nil {
+				return nil, err
+			}
+			mbid, err := c.ResolveMBID(ctx, q)
+			if errors.Is(err, lookup.ErrNotFound) {
+				return errorResponse(errCodeNotFound, "no results for %q", q.Name), nil
+			}
+			if err != nil {
+				return nil, err
+			}
+			return textResponse(mbid), nil
+		})
+}
+
+// registerServerInfoTool exposes get_server_info, reporting the server's name,
+// version and protocol version, so deployments can tell which build they are
+// talking to (the version is stamped at build time by `make mcp-server`).
+func registerServerInfoTool(srv *server) {
+	srv.registerTool("get_server_info", "Get the server's name, version and protocol version",
+		map[string]any{"type": "object", "properties": map[string]any{}},
+		func(_ context.Context, _ json.RawMessage) (*toolResponse, error) {
+			return jsonResponse(map[string]string{
+				"name":            serverName,
+				"version":         serverVersion,
+				"protocolVersion": protocolVersion,
+			})
+		})
+}
+
+// jsonResponse marshals a payload into a JSON text content item.
+func jsonResponse(payload any) (*toolResponse, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	return textResponse(string(data)), nil
+}
@@ -0,0 +1,70 @@
+package nativeapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/navidrome/navidrome/core/agents/mcp"
+	"github.com/navidrome/navidrome/log"
+	"github.com/navidrome/navidrome/model/request"
+)
+
+func (n *Router) addMCPStatusRoute(r chi.Router) {
+	r.Get("/mcp/status", func(w http.ResponseWriter, r *http.Request) {
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		status := mcp.GetStatusPage(limit, offset)
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(status); err != nil {
+			log.Error(r.Context(), "Error encoding MCP status", err)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		}
+	})
+	r.Post("/mcp/reset", func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		user, _ := request.UserFrom(ctx)
+		if !user.IsAdmin {
+			http.Error(w, "Reset endpoint is only available to admin users", http.StatusUnauthorized)
+			return
+		}
+		log.Info(ctx, "Resetting MCP circuit breaker", "user", user.UserName)
+		mcp.ResetCircuitBreaker()
+		w.WriteHeader(http.StatusNoContent)
+	})
+	r.Get("/mcp/provenance/{id}", func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		user, _ := request.UserFrom(ctx)
+		if !user.IsAdmin {
+			http.Error(w, "Provenance endpoint is only available to admin users", http.StatusUnauthorized)
+			return
+		}
+		provenance := mcp.GetArtistProvenance(chi.URLParam(r, "id"))
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(provenance); err != nil {
+			log.Error(ctx, "Error encoding MCP provenance", err)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		}
+	})
+	r.Get("/mcp/preview", func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		user, _ := request.UserFrom(ctx)
+		if !user.IsAdmin {
+			http.Error(w, "Preview endpoint is only available to admin users", http.StatusUnauthorized)
+			return
+		}
+		field := r.URL.Query().Get("field")
+		if field == "" {
+			field = "biography"
+		}
+		preview, err := mcp.PreviewLookup(ctx, field, r.URL.Query().Get("name"), r.URL.Query().Get("mbid"))
+		if err != nil {
+			log.Error(ctx, "Error previewing MCP lookup", "field", field, err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(preview)
+	})
+}
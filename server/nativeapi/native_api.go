@@ -66,6 +66,9 @@ func (n *Router) routes() http.Handler {
 		n.addConfigRoute(r)
 		n.addKeepAliveRoute(r)
 		n.addInsightsRoute(r)
+		if conf.Server.MCP.Enabled {
+			n.addMCPStatusRoute(r)
+		}
 	})
 
 	return r